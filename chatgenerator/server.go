@@ -0,0 +1,353 @@
+package chatgenerator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/mcpgenerator"
+	"github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/providers"
+	"github.com/centralmind/gateway/xcontext"
+	"github.com/google/uuid"
+	"github.com/sashabaranov/go-openai"
+	"golang.org/x/xerrors"
+)
+
+const (
+	defaultMaxRounds    = 10
+	defaultSystemPrompt = `You are a data assistant with access to a database through tools.
+Answer questions using results of the tools, call them as many times as needed.
+Never make up data, if the tools can't answer a question say so.`
+)
+
+// Server serves an OpenAI-compatible chat completions API.
+// The conversation runs through a server-side agent loop, the model may call tools of the MCP server,
+// they are executed with headers and claims of the caller, so plugins apply the same way as for MCP clients.
+type Server struct {
+	mcp      *mcpgenerator.MCPServer
+	provider providers.ModelProvider
+	modelId  string
+	params   model.ChatParams
+}
+
+func New(mcp *mcpgenerator.MCPServer, provider providers.ModelProvider, modelId string, params model.ChatParams) *Server {
+	return &Server{
+		mcp:      mcp,
+		provider: provider,
+		modelId:  modelId,
+		params:   params,
+	}
+}
+
+func (s *Server) RegisterRoutes(mux *http.ServeMux, prefix string) {
+	mux.HandleFunc(path.Join("/", prefix, "v1/chat/completions"), s.chatCompletions)
+	mux.HandleFunc(path.Join("/", prefix, "v1/models"), s.models)
+}
+
+func (s *Server) modelName() string {
+	if s.modelId == "" {
+		return "gateway"
+	}
+	return s.modelId
+}
+
+func (s *Server) models(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "Method not allowed")
+		return
+	}
+	if s.mcp.Server().NeedAuth(r) {
+		writeError(w, http.StatusUnauthorized, "authentication_error", "Unauthorized")
+		return
+	}
+	writeJSON(w, http.StatusOK, openai.ModelsList{
+		Models: []openai.Model{{
+			ID:      s.modelName(),
+			Object:  "model",
+			OwnedBy: s.provider.GetName(),
+		}},
+	})
+}
+
+func (s *Server) chatCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "Method not allowed")
+		return
+	}
+	r, ok := s.mcp.Server().Authorize(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "authentication_error", "Unauthorized")
+		return
+	}
+
+	var body chatRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Unable to parse request: %s", err))
+		return
+	}
+	request := body.ChatCompletionRequest
+	conversation, err := s.conversation(request, body.Temperature)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	ctx := xcontext.WithHeader(r.Context(), r.Header)
+	conversation.Tools, err = s.tools(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	completion := completion{
+		ID:      "chatcmpl-" + uuid.New().String(),
+		Created: time.Now().Unix(),
+		Model:   s.modelName(),
+	}
+	if request.Stream {
+		includeUsage := request.StreamOptions != nil && request.StreamOptions.IncludeUsage
+		s.stream(ctx, w, completion, conversation, includeUsage)
+		return
+	}
+
+	resp, err := providers.RunTools(ctx, s.provider, conversation, s.callTool, s.maxRounds())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, openai.ChatCompletionResponse{
+		ID:      completion.ID,
+		Object:  "chat.completion",
+		Created: completion.Created,
+		Model:   completion.Model,
+		Choices: []openai.ChatCompletionChoice{{
+			Message: openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleAssistant,
+				Content: text(resp.Content),
+			},
+			FinishReason: finishReason(resp.StopReason),
+		}},
+		Usage: usage(resp.Usage),
+	})
+}
+
+func (s *Server) maxRounds() int {
+	if s.params.MaxRounds > 0 {
+		return s.params.MaxRounds
+	}
+	return defaultMaxRounds
+}
+
+// chatRequest is an OpenAI chat completion request that tells an omitted temperature from zero.
+type chatRequest struct {
+	openai.ChatCompletionRequest
+	Temperature *float32 `json:"temperature,omitempty"`
+}
+
+// conversation converts OpenAI messages into a provider request.
+// Tools sent by the client are ignored, the model may only call tools of the gateway.
+// Without a temperature the provider default is used.
+func (s *Server) conversation(request openai.ChatCompletionRequest, temperature *float32) (*providers.ConversationRequest, error) {
+	if len(request.Messages) == 0 {
+		return nil, xerrors.New("messages must not be empty")
+	}
+	system := []string{defaultSystemPrompt}
+	if s.params.SystemPrompt != "" {
+		system = []string{s.params.SystemPrompt}
+	}
+	conversation := &providers.ConversationRequest{
+		ModelId:            s.modelId,
+		MaxTokens:          request.MaxCompletionTokens,
+		DefaultTemperature: temperature == nil,
+	}
+	if temperature != nil {
+		conversation.Temperature = *temperature
+	}
+	if conversation.MaxTokens == 0 {
+		conversation.MaxTokens = request.MaxTokens
+	}
+	for i, message := range request.Messages {
+		content := messageText(message)
+		switch message.Role {
+		case openai.ChatMessageRoleSystem, "developer":
+			system = append(system, content)
+		case openai.ChatMessageRoleUser:
+			conversation.Messages = append(conversation.Messages, providers.Message{
+				Role:    providers.UserRole,
+				Content: []providers.ContentBlock{&providers.ContentBlockText{Value: content}},
+			})
+		case openai.ChatMessageRoleAssistant:
+			var blocks []providers.ContentBlock
+			if content != "" {
+				blocks = append(blocks, &providers.ContentBlockText{Value: content})
+			}
+			for _, call := range message.ToolCalls {
+				input := json.RawMessage(call.Function.Arguments)
+				if len(input) == 0 {
+					input = json.RawMessage("{}")
+				}
+				blocks = append(blocks, &providers.ContentBlockToolUse{ID: call.ID, Name: call.Function.Name, Input: input})
+			}
+			conversation.Messages = append(conversation.Messages, providers.Message{Role: providers.AssistantRole, Content: blocks})
+		case openai.ChatMessageRoleTool:
+			result := &providers.ContentBlockToolResult{ToolUseID: message.ToolCallID, Content: content}
+			// results of parallel tool calls are sent back in a single message
+			if last := len(conversation.Messages) - 1; last >= 0 && request.Messages[i-1].Role == openai.ChatMessageRoleTool {
+				conversation.Messages[last].Content = append(conversation.Messages[last].Content, result)
+				continue
+			}
+			conversation.Messages = append(conversation.Messages, providers.Message{
+				Role:    providers.UserRole,
+				Content: []providers.ContentBlock{result},
+			})
+		default:
+			return nil, xerrors.Errorf("unsupported message role: %q", message.Role)
+		}
+	}
+	conversation.System = strings.Join(system, "\n\n")
+	return conversation, nil
+}
+
+// tools returns tools of the MCP server the model may call: configured endpoints, or all tools if raw tools are enabled.
+// Tools hidden from the caller by tool filters, e.g. by api keys or OAuth claims, are never offered.
+func (s *Server) tools(ctx context.Context) ([]providers.Tool, error) {
+	var res []providers.Tool
+	for _, tool := range s.mcp.Server().ListToolsFor(ctx) {
+		if !s.allowed(tool.Name) {
+			continue
+		}
+		raw, err := json.Marshal(tool)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to marshal %s tool: %w", tool.Name, err)
+		}
+		var def struct {
+			InputSchema json.RawMessage `json:"inputSchema"`
+		}
+		if err := json.Unmarshal(raw, &def); err != nil {
+			return nil, xerrors.Errorf("unable to read %s tool schema: %w", tool.Name, err)
+		}
+		res = append(res, providers.Tool{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: def.InputSchema,
+		})
+	}
+	return res, nil
+}
+
+// offered reports whether the caller may see the tool, the model may ask for a tool it was not given.
+func (s *Server) offered(ctx context.Context, name string) bool {
+	for _, tool := range s.mcp.Server().ListToolsFor(ctx) {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) allowed(name string) bool {
+	if s.params.RawTools {
+		return true
+	}
+	for _, endpoint := range s.mcp.Tools() {
+		if endpoint.MCPMethod == name {
+			return true
+		}
+	}
+	return false
+}
+
+// callTool executes a tool call of the model through the MCP server, including tool middlewares of plugins.
+func (s *Server) callTool(ctx context.Context, call *providers.ContentBlockToolUse) (string, error) {
+	if !s.allowed(call.Name) || !s.offered(ctx, call.Name) {
+		return "", xerrors.Errorf("unknown tool: %s", call.Name)
+	}
+	var request mcp.CallToolRequest
+	request.Params.Name = call.Name
+	request.Params.Arguments = map[string]any{}
+	if len(call.Input) > 0 {
+		if err := json.Unmarshal(call.Input, &request.Params.Arguments); err != nil {
+			return "", xerrors.Errorf("invalid arguments: %w", err)
+		}
+	}
+	res, err := s.mcp.Server().CallTool(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	var texts []string
+	for _, content := range res.Content {
+		if t, ok := content.(mcp.TextContent); ok {
+			texts = append(texts, t.Text)
+		}
+	}
+	if res.IsError {
+		return "", errors.New(strings.Join(texts, "\n"))
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+func messageText(message openai.ChatCompletionMessage) string {
+	if len(message.MultiContent) == 0 {
+		return message.Content
+	}
+	var parts []string
+	for _, part := range message.MultiContent {
+		if part.Type == openai.ChatMessagePartTypeText {
+			parts = append(parts, part.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func text(blocks []providers.ContentBlock) string {
+	var res strings.Builder
+	for _, block := range blocks {
+		if t, ok := block.(*providers.ContentBlockText); ok {
+			res.WriteString(t.Value)
+		}
+	}
+	return res.String()
+}
+
+func finishReason(reason providers.StopReason) openai.FinishReason {
+	if reason == providers.StopReasonLength {
+		return openai.FinishReasonLength
+	}
+	return openai.FinishReasonStop
+}
+
+func usage(u *providers.ModelUsage) openai.Usage {
+	if u == nil {
+		return openai.Usage{}
+	}
+	return openai.Usage{
+		PromptTokens:     u.InputTokens,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+func writeError(w http.ResponseWriter, status int, typ string, message string) {
+	writeJSON(w, status, errorResponse{Error: errorBody{Message: message, Type: typ}})
+}
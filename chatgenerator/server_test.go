@@ -0,0 +1,253 @@
+package chatgenerator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/centralmind/gateway/connectors/connectortest"
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/mcpgenerator"
+	"github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/providers"
+	"github.com/centralmind/gateway/server"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toolProvider calls get_user in the first round and answers with text in the second one.
+type toolProvider struct {
+	requests []*providers.ConversationRequest
+}
+
+func (p *toolProvider) GetName() string { return "Fake" }
+
+func (p *toolProvider) CostEstimate(modelId string, usage providers.ModelUsage) float64 { return 0 }
+
+func (p *toolProvider) answer(req *providers.ConversationRequest) *providers.ConversationResponse {
+	snapshot := *req
+	snapshot.Messages = append([]providers.Message(nil), req.Messages...)
+	p.requests = append(p.requests, &snapshot)
+	if len(p.requests) == 1 {
+		return &providers.ConversationResponse{
+			Content: []providers.ContentBlock{&providers.ContentBlockToolUse{
+				ID:    "call_1",
+				Name:  "get_user",
+				Input: json.RawMessage(`{"id": 1}`),
+			}},
+			StopReason: providers.StopReasonToolCalls,
+			Usage:      &providers.ModelUsage{InputTokens: 10, OutputTokens: 2, TotalTokens: 12},
+		}
+	}
+	return &providers.ConversationResponse{
+		Content:    []providers.ContentBlock{&providers.ContentBlockText{Value: "The user is alice."}},
+		StopReason: providers.StopReasonStop,
+		Usage:      &providers.ModelUsage{InputTokens: 20, OutputTokens: 5, TotalTokens: 25},
+	}
+}
+
+func (p *toolProvider) Chat(ctx context.Context, req *providers.ConversationRequest) (*providers.ConversationResponse, error) {
+	return p.answer(req), nil
+}
+
+func (p *toolProvider) ChatStream(ctx context.Context, req *providers.ConversationRequest) (providers.ChatStreamOutput, error) {
+	resp := p.answer(req)
+	eventCh := make(chan providers.StreamChunk, len(resp.Content)+2)
+	for _, block := range resp.Content {
+		eventCh <- &providers.StreamChunkContent{Content: block}
+	}
+	eventCh <- &providers.StreamChunkStop{StopReason: resp.StopReason}
+	eventCh <- &providers.StreamChunkUsage{Usage: resp.Usage}
+	close(eventCh)
+	return &streamOutput{eventCh: eventCh}, nil
+}
+
+type streamOutput struct {
+	eventCh chan providers.StreamChunk
+}
+
+func (o *streamOutput) GetStream() providers.ChatStream { return o }
+
+func (o *streamOutput) Events() <-chan providers.StreamChunk { return o.eventCh }
+
+func newChatServer(t *testing.T, params model.ChatParams, filters ...server.ToolFilterFunc) (*httptest.Server, *toolProvider, *connectortest.Connector) {
+	srv, err := mcpgenerator.New(nil)
+	require.NoError(t, err)
+	for _, filter := range filters {
+		srv.Server().AddToolFilter(filter)
+	}
+	connector := &connectortest.Connector{Rows: []map[string]any{{"id": 1, "name": "alice"}}}
+	require.NoError(t, srv.SetConnector(connector))
+	srv.EnableRawProtocol()
+	srv.SetTools([]model.Endpoint{{
+		MCPMethod:   "get_user",
+		Description: "Get user by id",
+		Query:       "SELECT * FROM users WHERE id = :id",
+		Params:      []model.EndpointParams{{Name: "id", Type: "integer", Required: true}},
+	}})
+
+	provider := &toolProvider{}
+	mux := http.NewServeMux()
+	New(srv, provider, "test-model", params).RegisterRoutes(mux, "")
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts, provider, connector
+}
+
+func postChat(t *testing.T, ts *httptest.Server, request openai.ChatCompletionRequest) *http.Response {
+	body, err := json.Marshal(request)
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/v1/chat/completions", strings.NewReader(string(body)))
+	require.NoError(t, err)
+	req.Header.Set("X-User", "alice")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestChatCompletion(t *testing.T) {
+	ts, provider, connector := newChatServer(t, model.ChatParams{Enabled: true})
+
+	resp := postChat(t, ts, openai.ChatCompletionRequest{
+		Model: "anything",
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "Be brief."},
+			{Role: openai.ChatMessageRoleUser, Content: "Who is user 1?"},
+		},
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var completion openai.ChatCompletionResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&completion))
+	assert.Equal(t, "chat.completion", completion.Object)
+	assert.Equal(t, "test-model", completion.Model)
+	require.Len(t, completion.Choices, 1)
+	assert.Equal(t, "The user is alice.", completion.Choices[0].Message.Content)
+	assert.Equal(t, openai.FinishReasonStop, completion.Choices[0].FinishReason)
+	assert.Equal(t, 37, completion.Usage.TotalTokens)
+
+	require.Len(t, connector.Params, 1)
+	assert.EqualValues(t, 1, connector.Params[0]["id"])
+	assert.Equal(t, "alice", http.Header(connector.Headers[0]).Get("X-User"), "tools must run with headers of the caller")
+
+	require.Len(t, provider.requests, 2)
+	first := provider.requests[0]
+	assert.Equal(t, "test-model", first.ModelId)
+	assert.True(t, first.DefaultTemperature, "omitted temperature must leave the provider default")
+	assert.Contains(t, first.System, "Be brief.")
+	require.Len(t, first.Tools, 1, "raw tools must not be exposed by default")
+	assert.Equal(t, "get_user", first.Tools[0].Name)
+	assert.Contains(t, string(first.Tools[0].InputSchema), `"id"`)
+
+	result := provider.requests[1].Messages[2].Content[0].(*providers.ContentBlockToolResult)
+	assert.Equal(t, "call_1", result.ToolUseID)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content, "alice")
+}
+
+func TestChatCompletionStream(t *testing.T) {
+	ts, provider, _ := newChatServer(t, model.ChatParams{Enabled: true, RawTools: true})
+
+	resp := postChat(t, ts, openai.ChatCompletionRequest{
+		Messages:      []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Who is user 1?"}},
+		Stream:        true,
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	var chunks []openai.ChatCompletionStreamResponse
+	var done bool
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			done = true
+			break
+		}
+		var chunk openai.ChatCompletionStreamResponse
+		require.NoError(t, json.Unmarshal([]byte(data), &chunk))
+		chunks = append(chunks, chunk)
+	}
+	require.True(t, done)
+	require.Len(t, chunks, 4)
+	assert.Equal(t, openai.ChatMessageRoleAssistant, chunks[0].Choices[0].Delta.Role)
+	assert.Equal(t, "The user is alice.", chunks[1].Choices[0].Delta.Content)
+	assert.Equal(t, openai.FinishReasonStop, chunks[2].Choices[0].FinishReason)
+	assert.Empty(t, chunks[3].Choices)
+	assert.Equal(t, 37, chunks[3].Usage.TotalTokens)
+
+	assert.Greater(t, len(provider.requests[0].Tools), 1, "raw tools must be exposed")
+}
+
+func TestChatUnauthorized(t *testing.T) {
+	srv, err := mcpgenerator.New(nil)
+	require.NoError(t, err)
+	srv.Server().AddAuthorizer(func(r *http.Request) (context.Context, bool) {
+		return r.Context(), r.Header.Get("Authorization") == "Bearer secret"
+	})
+	mux := http.NewServeMux()
+	New(srv, &toolProvider{}, "test-model", model.ChatParams{Enabled: true}).RegisterRoutes(mux, "api")
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/api/v1/chat/completions", "application/json", strings.NewReader(`{"messages": []}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	var body errorResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "authentication_error", body.Error.Type)
+}
+
+func TestChatTemperature(t *testing.T) {
+	ts, provider, _ := newChatServer(t, model.ChatParams{Enabled: true})
+
+	resp := postChat(t, ts, openai.ChatCompletionRequest{
+		Messages:    []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Who is user 1?"}},
+		Temperature: 0.5,
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 0.5, provider.requests[0].Temperature)
+	assert.False(t, provider.requests[0].DefaultTemperature)
+
+	// openai omits a zero temperature, an explicit zero is sent as raw JSON
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/v1/chat/completions", strings.NewReader(`{"messages": [{"role": "user", "content": "Hi"}], "temperature": 0}`))
+	require.NoError(t, err)
+	zero, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer zero.Body.Close()
+	require.Equal(t, http.StatusOK, zero.StatusCode)
+	assert.EqualValues(t, 0, provider.requests[2].Temperature)
+	assert.False(t, provider.requests[2].DefaultTemperature)
+}
+
+func TestChatHiddenTool(t *testing.T) {
+	hide := func(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+		var res []mcp.Tool
+		for _, tool := range tools {
+			if tool.Name != "get_user" {
+				res = append(res, tool)
+			}
+		}
+		return res
+	}
+	ts, provider, connector := newChatServer(t, model.ChatParams{Enabled: true}, hide)
+
+	resp := postChat(t, ts, openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Who is user 1?"}},
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, provider.requests[0].Tools, "tools hidden from the caller must not be offered")
+	assert.Empty(t, connector.Queries, "a hidden tool must not run even if the model asks for it")
+	result := provider.requests[1].Messages[2].Content[0].(*providers.ContentBlockToolResult)
+	assert.True(t, result.IsError)
+}
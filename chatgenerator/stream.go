@@ -0,0 +1,137 @@
+package chatgenerator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/centralmind/gateway/providers"
+	"github.com/sashabaranov/go-openai"
+)
+
+// completion holds fields shared by all chunks of a single completion.
+type completion struct {
+	ID      string
+	Created int64
+	Model   string
+}
+
+type streamWriter struct {
+	w          http.ResponseWriter
+	flusher    http.Flusher
+	completion completion
+}
+
+func (sw *streamWriter) event(data any) {
+	raw, _ := json.Marshal(data)
+	_, _ = fmt.Fprintf(sw.w, "data: %s\n\n", raw)
+	sw.flusher.Flush()
+}
+
+func (sw *streamWriter) chunk(choices []openai.ChatCompletionStreamChoice, usage *openai.Usage) {
+	if choices == nil {
+		choices = []openai.ChatCompletionStreamChoice{}
+	}
+	sw.event(openai.ChatCompletionStreamResponse{
+		ID:      sw.completion.ID,
+		Object:  "chat.completion.chunk",
+		Created: sw.completion.Created,
+		Model:   sw.completion.Model,
+		Choices: choices,
+		Usage:   usage,
+	})
+}
+
+func (sw *streamWriter) delta(delta openai.ChatCompletionStreamChoiceDelta) {
+	sw.chunk([]openai.ChatCompletionStreamChoice{{Delta: delta}}, nil)
+}
+
+// stream runs the agent loop over ChatStream: text of the model is forwarded as soon as it arrives,
+// tool calls are executed between rounds and the stream is closed once the model answers without tool calls.
+func (s *Server) stream(ctx context.Context, w http.ResponseWriter, completion completion, conversation *providers.ConversationRequest, includeUsage bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "server_error", "Streaming unsupported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	sw := &streamWriter{w: w, flusher: flusher, completion: completion}
+	sw.delta(openai.ChatCompletionStreamChoiceDelta{Role: openai.ChatMessageRoleAssistant})
+
+	total := &providers.ModelUsage{}
+	stopReason, err := s.streamRounds(ctx, sw, conversation, total)
+	if err != nil {
+		sw.event(errorResponse{Error: errorBody{Message: err.Error(), Type: "server_error"}})
+		return
+	}
+	sw.chunk([]openai.ChatCompletionStreamChoice{{FinishReason: finishReason(stopReason)}}, nil)
+	if includeUsage {
+		u := usage(total)
+		sw.chunk(nil, &u)
+	}
+	_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	flusher.Flush()
+}
+
+func (s *Server) streamRounds(ctx context.Context, sw *streamWriter, conversation *providers.ConversationRequest, total *providers.ModelUsage) (providers.StopReason, error) {
+	for round := 0; round < s.maxRounds(); round++ {
+		output, err := s.provider.ChatStream(ctx, conversation)
+		if err != nil {
+			return "", err
+		}
+		var answer strings.Builder
+		var calls []*providers.ContentBlockToolUse
+		var stopReason providers.StopReason
+		for event := range output.GetStream().Events() {
+			switch e := event.(type) {
+			case *providers.StreamChunkContent:
+				switch block := e.Content.(type) {
+				case *providers.ContentBlockText:
+					answer.WriteString(block.Value)
+					sw.delta(openai.ChatCompletionStreamChoiceDelta{Content: block.Value})
+				case *providers.ContentBlockToolUse:
+					calls = append(calls, block)
+				}
+			case *providers.StreamChunkStop:
+				stopReason = e.StopReason
+			case *providers.StreamChunkUsage:
+				if e.Usage != nil {
+					total.InputTokens += e.Usage.InputTokens
+					total.OutputTokens += e.Usage.OutputTokens
+					total.TotalTokens += e.Usage.TotalTokens
+				}
+			case *providers.StreamChunkError:
+				if e.Err != nil {
+					err = e.Err
+				} else {
+					err = errors.New(e.Error)
+				}
+			}
+		}
+		if err != nil {
+			return "", err
+		}
+		if len(calls) == 0 {
+			return stopReason, nil
+		}
+		var content []providers.ContentBlock
+		if answer.Len() > 0 {
+			content = append(content, &providers.ContentBlockText{Value: answer.String()})
+		}
+		for _, call := range calls {
+			content = append(content, call)
+		}
+		conversation.Messages = append(conversation.Messages,
+			providers.Message{Role: providers.AssistantRole, Content: content},
+			providers.Message{Role: providers.UserRole, Content: providers.ExecuteTools(ctx, calls, s.callTool)},
+		)
+	}
+	return "", providers.ErrToolRoundsExceeded
+}
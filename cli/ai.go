@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"time"
+
+	"github.com/centralmind/gateway/mcpgenerator"
+	gw_model "github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/providers"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+// newServerProvider creates the model provider configured in the ai section, transient failures are retried.
+func newServerProvider(params gw_model.AIParams) (providers.ModelProvider, error) {
+	provider, err := providers.NewModelProvider(providers.ModelProviderConfig{
+		Name:            params.Provider,
+		Endpoint:        params.Endpoint,
+		APIKey:          params.APIKey,
+		BedrockRegion:   params.BedrockRegion,
+		VertexAIRegion:  params.VertexAIRegion,
+		VertexAIProject: params.VertexAIProject,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("unable to init %q ai provider: %w", params.Provider, err)
+	}
+	return providers.WithRetry(provider, providers.RetryConfig{
+		MaxRetries: providers.DefaultMaxRetries,
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			logrus.Warnf("AI call failed: %v, retrying in %s (attempt %d)", err, delay.Round(time.Millisecond), attempt)
+		},
+	}), nil
+}
+
+// enableAI turns on AI features of the MCP server requested in the ai section of the config.
+// It returns the model provider, or nil if no AI feature is enabled.
+func enableAI(srv *mcpgenerator.MCPServer, gw *gw_model.Config) (providers.ModelProvider, error) {
+	if gw.AI == nil || (!gw.AI.Ask && !chatEnabled(gw)) {
+		return nil, nil
+	}
+	provider, err := newServerProvider(*gw.AI)
+	if err != nil {
+		return nil, err
+	}
+	if gw.AI.Ask {
+		if err := srv.EnableAsk(provider, gw.AI.Model); err != nil {
+			return nil, xerrors.Errorf("unable to enable ask: %w", err)
+		}
+	}
+	return provider, nil
+}
+
+func chatEnabled(gw *gw_model.Config) bool {
+	return gw.AI != nil && gw.AI.Chat != nil && gw.AI.Chat.Enabled
+}
@@ -0,0 +1,28 @@
+package cli
+
+import (
+	gw_model "github.com/centralmind/gateway/model"
+	"github.com/sirupsen/logrus"
+)
+
+// approvalPlugin is the tag of the plugin that holds endpoints marked with requires_approval
+const approvalPlugin = "approval"
+
+// requireApproval enables the approval plugin with its defaults when an endpoint requires approval,
+// so that such endpoints never run unattended because the plugin was left out of the config
+func requireApproval(gw *gw_model.Config) {
+	if _, ok := gw.Plugins[approvalPlugin]; ok {
+		return
+	}
+	for _, endpoint := range gw.Database.GetAllEndpoints() {
+		if !endpoint.RequiresApproval {
+			continue
+		}
+		if gw.Plugins == nil {
+			gw.Plugins = map[string]any{}
+		}
+		gw.Plugins[approvalPlugin] = map[string]any{}
+		logrus.Infof("Endpoint %s requires approval, the %s plugin is enabled with its defaults", endpoint.MCPMethod, approvalPlugin)
+		return
+	}
+}
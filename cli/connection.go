@@ -54,7 +54,7 @@ The command performs the following steps:
 				return xerrors.Errorf("Failed to create connector: %w", err)
 			}
 			// Retrieve table data and verify connection
-			tablesData, err := TablesData(splitTables(tables), connector)
+			tablesData, err := TablesData(splitTables(tables), connector, defaultDiscoveryTimeout)
 			if err != nil {
 				return xerrors.Errorf("unable to verify connection: %w", err)
 			}
@@ -100,9 +100,12 @@ type dbType struct {
 	Type string `yaml:"type" json:"type"`
 }
 
-func TablesData(tablesList []string, connector connectors.Connector) ([]prompter.TableData, error) {
+// defaultDiscoveryTimeout limits how long schema discovery and data sampling may take.
+const defaultDiscoveryTimeout = 15 * time.Second
+
+func TablesData(tablesList []string, connector connectors.Connector, timeout time.Duration) ([]prompter.TableData, error) {
 	logrus.Info("Step 1: Read configs")
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	logrus.Info("Step 2: Discover data")
@@ -1,10 +1,10 @@
 package cli
 
 import (
+	"bufio"
 	"context"
 	_ "embed"
 	"github.com/centralmind/gateway/connectors"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,6 +12,7 @@ import (
 
 	"github.com/centralmind/gateway/prompter"
 	"github.com/centralmind/gateway/providers"
+	"github.com/centralmind/gateway/providers/replay"
 
 	"github.com/centralmind/gateway/logger"
 	"golang.org/x/xerrors"
@@ -68,6 +69,22 @@ func Discover() *cobra.Command {
 	var dbDSN string
 	var dbSchema string
 	var typ string
+	var merge bool
+	var assumeYes bool
+	var validate bool
+	var repairRounds int
+	var chunkSize int
+	var stateFile string
+	var discoveryTimeout time.Duration
+	var piiReportEnabled bool
+	var piiPlugin string
+	var piiReportFile string
+	var review bool
+	var aiRecord bool
+	var aiTranscript string
+	var aiMaxRetries int
+	var aiTokenBudget int
+	var aiRequestBudget int
 
 	cmd := &cobra.Command{
 		Use:   "discover",
@@ -83,13 +100,41 @@ The discovery process follows these steps:
 2. Discover table schemas and sample data
 3. Generate an AI prompt based on the discovered schema
 4. Use the specified AI provider to generate a gateway configuration
-5. Save the generated configuration to a file
+5. Validate generated endpoints against the database and ask the AI to repair broken ones
+6. Save the generated configuration to a file
+
+With --chunk-size large schemas are split into chunks of related tables, each chunk is discovered
+with a separate prompt and results are deduplicated. Progress is stored in
+--resume-file, so rerunning the same command continues after a failed chunk.
+
+With --merge the existing configuration file is extended instead of overwritten:
+existing endpoints are sent to the AI as context, only new or changed endpoints
+are generated, and plugins, API settings and hand-edited endpoints are preserved.
+The changes are previewed and written only after confirmation, or right away with --yes.
+
+With --ai-record every AI call is recorded into --llm-log as a replayable transcript
+(raw responses go to a ".raw" log next to it). Use --ai-provider replay with
+--ai-transcript pointing to that file to rerun discovery deterministically and offline.
+
+Rate limits and transient AI failures are retried with exponential backoff (--ai-max-retries),
+honouring Retry-After of the provider. --ai-token-budget and --ai-request-budget stop the run
+once it has spent the given number of tokens or requests, retries included.
+
+With --review every generated endpoint is shown with its SQL and a live sample result
+before anything is saved, and can be accepted, rejected, renamed, edited or regenerated
+by the AI with extra instructions. Only endpoints that read data are sampled, writes never run.
+
+With --pii-report the AI additionally classifies columns that contain personal data,
+marks them with "pii: true" in the tables section, configures a redaction plugin
+(--pii-plugin) and writes a markdown report for compliance review (--pii-report-file).
 
 This approach significantly reduces the time needed to create gateway configurations
 and ensures they follow best practices for AI agent interactions.`,
 		Args: cobra.MatchAll(cobra.ExactArgs(0)),
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			startTime := time.Now()
+			// A single reader for stdin, so prompts don't lose input buffered by each other
+			stdin := bufio.NewReader(os.Stdin)
 
 			logrus.Info("\r\n")
 			logrus.Info("🚀 Verify Discovery Process")
@@ -109,27 +154,49 @@ and ensures they follow best practices for AI agent interactions.`,
 				return xerrors.Errorf("Failed to create connector: %w", err)
 			}
 
-			resolvedTables, err := TablesData(splitTables(tables), connector)
+			resolvedTables, err := TablesData(splitTables(tables), connector, discoveryTimeout)
 			if err != nil {
 				return xerrors.Errorf("unable to verify connection: %w", err)
 			}
 
 			databaseType := connector.Config().Type()
 
-			logrus.Info("Step 4: Prepare the prompt for the AI")
-			discoverPrompt := prompter.DiscoverEndpointsPrompt(connector, extraPrompt, resolvedTables, prompter.SchemaFromConfig(connector.Config()))
-			if err := saveToFile(promptFile, discoverPrompt); err != nil {
-				logrus.Error("failed to save prompt:", err)
+			var existing *gw_model.Config
+			if merge {
+				existing, err = loadExistingConfig(output)
+				if err != nil {
+					return err
+				}
+				if existing == nil {
+					logrus.Infof("No config found at %s, generating a new one", output)
+				}
 			}
 
-			logrus.Infof("Prompt saved locally to %s", promptFile)
-			logrus.Info("✅ Step 4 completed. Done.")
-			logrus.Info("\r\n")
+			schema := prompter.SchemaFromConfig(connector.Config())
+			buildPrompt := func(tables []prompter.TableData) string {
+				if existing != nil {
+					known := existing.Database.Endpoints
+					if len(tables) < len(resolvedTables) {
+						known = relatedEndpoints(known, tables)
+					}
+					return prompter.MergeEndpointsPrompt(connector, extraPrompt, tables, schema, known)
+				}
+				return prompter.DiscoverEndpointsPrompt(connector, extraPrompt, tables, schema)
+			}
+
+			var recordFile string
+			if aiRecord {
+				recordFile = llmLogFile
+				if err := os.Remove(recordFile); err != nil && !os.IsNotExist(err) {
+					return xerrors.Errorf("unable to reset transcript: %w", err)
+				}
+				llmLogFile = logFileWithSuffix(llmLogFile, "raw")
+			}
 
-			// Call API
-			logrus.Info("Step 5: Use AI to design the API")
-			response, err := makeDiscoverQuery(DiscoverQueryParams{
+			params := DiscoverQueryParams{
 				LLMLogFile:    llmLogFile,
+				RecordFile:    recordFile,
+				Transcript:    aiTranscript,
 				Provider:      aiProvider,
 				Endpoint:      aiEndpoint,
 				APIKey:        aiAPIKey,
@@ -140,11 +207,47 @@ and ensures they follow best practices for AI agent interactions.`,
 				BedrockRegion: bedrockRegion,
 				VertexRegion:  vertexAIRegion,
 				VertexProject: vertexAIProject,
-			}, discoverPrompt)
+				Retry: providers.RetryConfig{
+					MaxRetries: aiMaxRetries,
+					Budget:     providers.NewBudget(aiTokenBudget, aiRequestBudget),
+					OnRetry: func(attempt int, delay time.Duration, err error) {
+						logrus.Warnf("AI call failed: %v, retrying in %s (attempt %d of %d)", err, delay.Round(time.Millisecond), attempt, aiMaxRetries)
+					},
+				},
+			}
 
-			if err != nil {
-				logrus.Error("Failed to call the LLM:", err)
-				return err
+			var response DiscoverQueryResponse
+			if chunkSize > 0 && len(resolvedTables) > chunkSize {
+				response, err = discoverInChunks(connector, params, resolvedTables, chunkedDiscovery{
+					ChunkSize:    chunkSize,
+					Scope:        discoverScope(databaseType, dbDSN, dbSchema, aiProvider, aiModel, merge, extraPrompt),
+					StateFile:    stateFile,
+					PromptFile:   promptFile,
+					BuildPrompt:  buildPrompt,
+					Validate:     validate,
+					RepairRounds: repairRounds,
+				})
+				if err != nil {
+					return err
+				}
+			} else {
+				logrus.Info("Step 4: Prepare the prompt for the AI")
+				discoverPrompt := buildPrompt(resolvedTables)
+				if err := saveToFile(promptFile, discoverPrompt); err != nil {
+					logrus.Error("failed to save prompt:", err)
+				}
+
+				logrus.Infof("Prompt saved locally to %s", promptFile)
+				logrus.Info("✅ Step 4 completed. Done.")
+				logrus.Info("\r\n")
+
+				// Call API
+				logrus.Info("Step 5: Use AI to design the API")
+				response, err = discoverEndpoints(connector, params, discoverPrompt, validate, repairRounds)
+				if err != nil {
+					logrus.Error("Failed to call the LLM:", err)
+					return err
+				}
 			}
 
 			var config gw_model.Config
@@ -157,9 +260,66 @@ and ensures they follow best practices for AI agent interactions.`,
 				apiEndpoints++
 			}
 
-			config.Database.Type = databaseType
-			config.Database.Connection = dbDSN
-			config.Database.Endpoints = response.Endpoints
+			// Endpoints are stamped as generated before the review, so changes made there count as manual edits
+			response.Endpoints = stampFingerprints(response.Endpoints)
+			if review {
+				reviewer := newEndpointReview(connector, params, resolvedTables, buildPrompt, stdin, os.Stdout)
+				reviewed, err := reviewer.Run(context.Background(), response.Endpoints)
+				response.addUsage(reviewer.usage)
+				if err != nil {
+					return xerrors.Errorf("endpoint review aborted: %w", err)
+				}
+				logrus.Infof("Endpoints accepted: "+yellow+"%d"+reset+" of "+yellow+"%d"+reset, len(reviewed), len(response.Endpoints))
+				response.Endpoints = reviewed
+				apiEndpoints = len(reviewed)
+			}
+
+			if existing != nil {
+				merged, changes := mergeEndpoints(existing.Database.Endpoints, response.Endpoints)
+				logrus.Info("\r\n")
+				printMergePreview(changes)
+				config = *existing
+				config.Database.Endpoints = merged
+			} else {
+				config.Database.Type = databaseType
+				config.Database.Connection = dbDSN
+				config.Database.Endpoints = response.Endpoints
+			}
+
+			if piiReportEnabled {
+				logrus.Info("\r\n")
+				logrus.Info("Classifying PII columns")
+				piiParams := params
+				piiParams.LLMLogFile = logFileWithSuffix(llmLogFile, "pii")
+				report, usage, err := classifyPII(connector, piiParams, resolvedTables, chunkSize)
+				response.addUsage(usage)
+				if err != nil {
+					return xerrors.Errorf("unable to classify PII: %w", err)
+				}
+				if err := applyPIIReport(&config, report, resolvedTables, piiPlugin); err != nil {
+					return err
+				}
+				logrus.Infof("PII columns found: "+yellow+"%d"+reset, len(report.Columns))
+				for _, col := range report.Columns {
+					logrus.Infof("  - "+cyan+"%s.%s"+reset+": %s", col.Table, col.Column, col.Category)
+				}
+				if err := saveToFile(piiReportFile, piiReportMarkdown(report, databaseType, resolvedTables, piiPlugin)); err != nil {
+					logrus.Error("failed to save PII report:", err)
+				} else {
+					logrus.Infof("PII report saved to: "+cyan+"%s"+reset, piiReportFile)
+				}
+			}
+
+			if existing != nil && !assumeYes {
+				confirmed, err := confirmMerge(stdin, os.Stdout, output)
+				if err != nil {
+					return err
+				}
+				if !confirmed {
+					logrus.Infof("Merge cancelled, %s is not changed. Use --yes to write without confirmation", output)
+					return nil
+				}
+			}
 
 			// Save configuration
 			configData, err := yaml.Marshal(config)
@@ -189,6 +349,14 @@ and ensures they follow best practices for AI agent interactions.`,
 				response.Conversation.Usage.TotalTokens,
 				response.CostEstimate,
 			)
+			stats := params.Retry.Budget.Stats()
+			logrus.Infof(
+				"AI requests: "+yellow+"%d"+reset+" (retries: "+yellow+"%d"+reset+", tokens: "+yellow+"%d"+reset+", estimated cost: "+violet+"$%.4f"+reset+")",
+				stats.Requests,
+				stats.Retries,
+				stats.Usage.TotalTokens,
+				stats.CostEstimate,
+			)
 			logrus.Infof("Tables processed: "+yellow+"%d"+reset, len(resolvedTables))
 			logrus.Infof("API methods created: "+yellow+"%d"+reset, apiEndpoints)
 
@@ -200,22 +368,38 @@ and ensures they follow best practices for AI agent interactions.`,
 	cmd.Flags().StringVar(&dbSchema, "db-schema", "", "Database schema for database connection, optional")
 	cmd.Flags().StringVar(&typ, "type", "", "Type of database to use (for example: postgres os mysql)")
 	cmd.Flags().StringVar(&tables, "tables", "", "Comma-separated list of tables to include (e.g., 'users,products,orders')")
+	cmd.Flags().DurationVar(&discoveryTimeout, "discovery-timeout", defaultDiscoveryTimeout, "Maximum time to discover table schemas and sample data")
 
 	/*
 		AI provider options:
 	*/
-	cmd.Flags().StringVar(&aiProvider, "ai-provider", "openai", "AI provider to use (openai, anthropic, bedrock, vertexai, etc.)")
+	cmd.Flags().StringVar(&aiProvider, "ai-provider", "openai", "AI provider to use (openai, anthropic, bedrock, vertexai, ollama, llamacpp, replay, etc.)")
 	cmd.Flags().StringVar(&aiEndpoint, "ai-endpoint", "", "Custom OpenAI-compatible API endpoint URL for self-hosted models")
 	cmd.Flags().StringVar(&aiAPIKey, "ai-api-key", "", "API key for the selected AI provider")
 	cmd.Flags().StringVar(&bedrockRegion, "bedrock-region", "", "AWS region for Amazon Bedrock (required when using bedrock provider)")
 	cmd.Flags().StringVar(&vertexAIRegion, "vertexai-region", "", "Google Cloud region for Vertex AI (required when using vertexai provider)")
 	cmd.Flags().StringVar(&vertexAIProject, "vertexai-project", "", "Google Cloud project ID for Vertex AI (required when using vertexai provider)")
+	cmd.Flags().StringVar(&aiTranscript, "ai-transcript", "", "Recorded transcript to serve responses from (required when using replay provider)")
+	cmd.Flags().BoolVar(&aiRecord, "ai-record", false, "Record AI calls into the --llm-log file as a transcript that can be replayed with the replay provider")
+	cmd.Flags().IntVar(&aiMaxRetries, "ai-max-retries", providers.DefaultMaxRetries, "Maximum number of retries of a failed AI call (0 to disable)")
+	cmd.Flags().IntVar(&aiTokenBudget, "ai-token-budget", 0, "Maximum number of tokens the whole run may spend, retries included (0 for unlimited)")
+	cmd.Flags().IntVar(&aiRequestBudget, "ai-request-budget", 0, "Maximum number of AI requests the whole run may make, retries included (0 for unlimited)")
 	cmd.Flags().StringVar(&aiModel, "ai-model", "", "Specific AI model to use (e.g., 'gpt-4', 'claude-3-opus', etc.)")
 	cmd.Flags().IntVar(&aiMaxTokens, "ai-max-tokens", 0, "Maximum tokens to generate in the AI response (0 for model default)")
 	cmd.Flags().Float32Var(&aiTemperature, "ai-temperature", -1.0, "AI temperature for response randomness (0.0-1.0, lower is more deterministic)")
 	cmd.Flags().BoolVar(&aiReasoning, "ai-reasoning", true, "Enable AI reasoning in the response for better explanation of design decisions")
 
 	cmd.Flags().StringVar(&output, "output", "gateway.yaml", "Path to save the generated gateway configuration file")
+	cmd.Flags().BoolVar(&merge, "merge", false, "Merge generated endpoints into the existing configuration file instead of overwriting it")
+	cmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Write a merged configuration without asking for confirmation")
+	cmd.Flags().BoolVar(&validate, "validate", true, "Validate generated endpoints against the database and drop the ones that can't be repaired")
+	cmd.Flags().IntVar(&repairRounds, "repair-rounds", 2, "Maximum number of rounds to ask the AI to repair invalid endpoints (0 to only validate)")
+	cmd.Flags().IntVar(&chunkSize, "chunk-size", 0, "Maximum number of tables per AI prompt, larger schemas are discovered in several passes (0 to disable)")
+	cmd.Flags().StringVar(&stateFile, "resume-file", filepath.Join(logger.DefaultLogDir(), "discover_state.json"), "Path to store progress of chunked discovery, used to resume after a failure")
+	cmd.Flags().BoolVar(&review, "review", false, "Interactively review every generated endpoint with a live data sample before saving")
+	cmd.Flags().BoolVar(&piiReportEnabled, "pii-report", false, "Use AI to find columns with personal data, mark them in the config and configure a redaction plugin")
+	cmd.Flags().StringVar(&piiPlugin, "pii-plugin", "pii_remover", "Redaction plugin to configure for PII columns (pii_remover or presidio_anonymizer, empty to skip)")
+	cmd.Flags().StringVar(&piiReportFile, "pii-report-file", filepath.Join(logger.DefaultLogDir(), "pii_report.md"), "Path to save the human-readable PII report")
 	cmd.Flags().StringVar(&extraPrompt, "prompt", "generate reasonable set of APIs for this data", "Custom instructions for the AI to guide API generation")
 	cmd.Flags().StringVar(&promptFile, "prompt-file", filepath.Join(logger.DefaultLogDir(), "prompt_default.txt"), "Path to save the generated AI prompt for inspection")
 	cmd.Flags().StringVar(&llmLogFile, "llm-log", filepath.Join(logger.DefaultLogDir(), "llm_raw_response.log"), "Path to save the raw AI response for debugging")
@@ -235,6 +419,12 @@ type DiscoverQueryParams struct {
 	BedrockRegion string
 	VertexRegion  string
 	VertexProject string
+	// Transcript is a recorded transcript served by the replay provider.
+	Transcript string
+	// RecordFile, if set, records every AI call into a replayable transcript.
+	RecordFile string
+	// Retry configures retries of failed AI calls, its budget is shared by all calls of the run.
+	Retry providers.RetryConfig
 }
 
 type DiscoverQueryResponse struct {
@@ -242,9 +432,69 @@ type DiscoverQueryResponse struct {
 	Conversation *providers.ConversationResponse
 	RawContent   string
 	CostEstimate float64
+	// Provider and Request allow to continue the same conversation, e.g. to repair endpoints.
+	Provider providers.ModelProvider        `yaml:"-"`
+	Request  *providers.ConversationRequest `yaml:"-"`
+}
+
+// addUsage adds tokens and cost of another AI call to the response statistics.
+func (r *DiscoverQueryResponse) addUsage(other DiscoverQueryResponse) {
+	r.CostEstimate += other.CostEstimate
+	if other.Conversation == nil || other.Conversation.Usage == nil {
+		return
+	}
+	if r.Conversation == nil {
+		r.Conversation = &providers.ConversationResponse{}
+	}
+	if r.Conversation.Usage == nil {
+		r.Conversation.Usage = &providers.ModelUsage{}
+	}
+	r.Conversation.Usage.InputTokens += other.Conversation.Usage.InputTokens
+	r.Conversation.Usage.OutputTokens += other.Conversation.Usage.OutputTokens
+	r.Conversation.Usage.TotalTokens += other.Conversation.Usage.TotalTokens
+}
+
+// discoverEndpoints asks the model to design endpoints for a prompt and, if enabled,
+// validates and repairs the result.
+func discoverEndpoints(connector connectors.Connector, params DiscoverQueryParams, prompt string, validate bool, repairRounds int) (DiscoverQueryResponse, error) {
+	response, err := makeDiscoverQuery(params, prompt)
+	if err != nil {
+		return response, err
+	}
+	if validate {
+		logrus.Info("Validating generated endpoints")
+		results := repairEndpoints(context.Background(), connector, params, &response, repairRounds)
+		printRepairReport(results)
+		logrus.Info("\r\n")
+	}
+	return response, nil
 }
 
 func makeDiscoverQuery(params DiscoverQueryParams, prompt string) (DiscoverQueryResponse, error) {
+	response, err := chatJSON(params, prompt, endpointsResponseSchema())
+	if err != nil {
+		return response, err
+	}
+
+	var parsed DiscoverQueryResponse
+	if err := yaml.Unmarshal([]byte(response.RawContent), &parsed); err != nil {
+		return response, xerrors.Errorf("unable to unmarshal response: %w", err)
+	}
+	response.Endpoints = parsed.Endpoints
+	return response, nil
+}
+
+func endpointsResponseSchema() *providers.ResponseSchema {
+	return &providers.ResponseSchema{
+		Name:        "endpoints",
+		Description: "API endpoints generated for the database",
+		Schema:      prompter.EndpointsSchema(),
+	}
+}
+
+// chatJSON sends a single prompt to the configured provider and returns its raw JSON answer with usage statistics.
+// The answer is constrained to the schema by providers that support structured output, others fall back to plain JSON mode.
+func chatJSON(params DiscoverQueryParams, prompt string, schema *providers.ResponseSchema) (DiscoverQueryResponse, error) {
 	provider, err := providers.NewModelProvider(providers.ModelProviderConfig{
 		Name:            params.Provider,
 		APIKey:          params.APIKey,
@@ -252,11 +502,16 @@ func makeDiscoverQuery(params DiscoverQueryParams, prompt string) (DiscoverQuery
 		BedrockRegion:   params.BedrockRegion,
 		VertexAIRegion:  params.VertexRegion,
 		VertexAIProject: params.VertexProject,
+		Transcript:      params.Transcript,
 	})
 
 	if err != nil {
-		logrus.Fatalf("Failed to initialize provider: %v", err)
+		return DiscoverQueryResponse{}, xerrors.Errorf("failed to initialize provider: %w", err)
 	}
+	if params.RecordFile != "" {
+		provider = replay.NewRecorder(provider, params.RecordFile)
+	}
+	provider = providers.WithRetry(provider, params.Retry)
 
 	logrus.Infof("Calling provider: %s", provider.GetName())
 
@@ -264,12 +519,13 @@ func makeDiscoverQuery(params DiscoverQueryParams, prompt string) (DiscoverQuery
 	go startSpinner("Thinking. The process can take a few minutes to finish", done)
 
 	request := &providers.ConversationRequest{
-		ModelId:      params.Model,
-		Reasoning:    params.Reasoning,
-		MaxTokens:    params.MaxTokens,
-		Temperature:  params.Temperature,
-		JsonResponse: true,
-		System:       "You must always respond in pure JSON. No markdown, no comments, no explanations.",
+		ModelId:        params.Model,
+		Reasoning:      params.Reasoning,
+		MaxTokens:      params.MaxTokens,
+		Temperature:    params.Temperature,
+		JsonResponse:   true,
+		ResponseSchema: schema,
+		System:         "You must always respond in pure JSON. No markdown, no comments, no explanations.",
 		Messages: []providers.Message{
 			{
 				Role: providers.UserRole,
@@ -283,20 +539,12 @@ func makeDiscoverQuery(params DiscoverQueryParams, prompt string) (DiscoverQuery
 	}
 
 	llmResponse, err := provider.Chat(context.Background(), request)
-	if err != nil {
-		log.Fatalf("Failed to call LLM: %v", err)
-	}
-
 	done <- true
-
-	var responseContentBuilder strings.Builder
-	for _, contentBlock := range llmResponse.Content {
-		if textBlock, ok := contentBlock.(*providers.ContentBlockText); ok {
-			responseContentBuilder.WriteString(textBlock.Value)
-		}
+	if err != nil {
+		return DiscoverQueryResponse{}, xerrors.Errorf("failed to call LLM: %w", err)
 	}
 
-	rawContent := strings.TrimSpace(responseContentBuilder.String())
+	rawContent := strings.TrimSpace(responseText(llmResponse))
 
 	if err := os.WriteFile(params.LLMLogFile, []byte(rawContent), 0644); err != nil {
 		logrus.Error("Failed to save LLM response:", err)
@@ -310,21 +558,11 @@ func makeDiscoverQuery(params DiscoverQueryParams, prompt string) (DiscoverQuery
 		"Output tokens": llmResponse.Usage.OutputTokens,
 	}).Info("LLM usage:")
 
-	var response DiscoverQueryResponse
-	if err := yaml.Unmarshal([]byte(rawContent), &response); err != nil {
-		return DiscoverQueryResponse{
-			Endpoints:    nil,
-			Conversation: llmResponse,
-			RawContent:   rawContent,
-			CostEstimate: costEstimate,
-		}, xerrors.Errorf("unable to unmarshal response: %w", err)
-	}
-
 	return DiscoverQueryResponse{
-		Endpoints:    response.Endpoints,
 		Conversation: llmResponse,
 		RawContent:   rawContent,
 		CostEstimate: costEstimate,
+		Provider:     provider,
+		Request:      request,
 	}, nil
-
 }
@@ -0,0 +1,340 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/centralmind/gateway/connectors"
+	gw_model "github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/prompter"
+	"github.com/centralmind/gateway/providers"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+// discoverChunk is a group of related tables that is sent to the model in a single prompt.
+type discoverChunk struct {
+	Key    string
+	Tables []prompter.TableData
+}
+
+func (c discoverChunk) tableNames() []string {
+	names := make([]string, len(c.Tables))
+	for i, table := range c.Tables {
+		names[i] = table.Name
+	}
+	return names
+}
+
+// discoverStateVersion is a part of chunk keys, bump it when prompts or the state format change,
+// so that endpoints of a run with older prompts are not resumed.
+const discoverStateVersion = "1"
+
+// discoverScope identifies a discovery run by everything that shapes its endpoints besides the tables:
+// the database, the model and the instructions. The DSN only goes into hashed chunk keys.
+func discoverScope(typ, dsn, dbSchema, provider, model string, merge bool, extraPrompt string) string {
+	return strings.Join([]string{discoverStateVersion, typ, dsn, dbSchema, provider, model, fmt.Sprint(merge), extraPrompt}, "\x00")
+}
+
+// planDiscoverChunks splits tables into chunks of at most chunkSize tables.
+// Related tables are kept together whenever possible, see clusterTables.
+// The chunk key depends on chunk tables and the scope of the run, see discoverScope,
+// so it can be used to resume discovery of the same database with the same model and instructions.
+func planDiscoverChunks(tables []prompter.TableData, chunkSize int, scope string) []discoverChunk {
+	var chunks []discoverChunk
+	var current []prompter.TableData
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		chunks = append(chunks, discoverChunk{Tables: current})
+		current = nil
+	}
+	for _, cluster := range clusterTables(tables) {
+		if len(current)+len(cluster) > chunkSize {
+			flush()
+		}
+		for len(cluster) > chunkSize {
+			chunks = append(chunks, discoverChunk{Tables: cluster[:chunkSize]})
+			cluster = cluster[chunkSize:]
+		}
+		current = append(current, cluster...)
+	}
+	flush()
+
+	for i := range chunks {
+		sum := sha256.Sum256([]byte(strings.Join(chunks[i].tableNames(), ",") + "\n" + scope))
+		chunks[i].Key = hex.EncodeToString(sum[:8])
+	}
+	return chunks
+}
+
+// clusterTables groups tables that reference each other.
+// Databases rarely expose foreign keys through discovery, so references are guessed from column names:
+// a column "customer_id" (or "customerId") links the table with "customer" or "customers" in the same schema.
+// Clusters are returned in order of the first table appearance.
+func clusterTables(tables []prompter.TableData) [][]prompter.TableData {
+	parent := make([]int, len(tables))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(a, b int) {
+		ra, rb := find(a), find(b)
+		if ra != rb {
+			parent[rb] = ra
+		}
+	}
+
+	byName := map[string]int{}
+	for i, table := range tables {
+		schema, name := splitTableName(table.Name)
+		byName[schema+"."+strings.ToLower(name)] = i
+	}
+
+	for i, table := range tables {
+		schema, _ := splitTableName(table.Name)
+		for _, col := range table.Columns {
+			ref := referencedTable(col.Name)
+			if ref == "" {
+				continue
+			}
+			for _, candidate := range []string{ref, ref + "s", ref + "es", strings.TrimSuffix(ref, "y") + "ies"} {
+				if j, ok := byName[schema+"."+candidate]; ok && j != i {
+					union(i, j)
+					break
+				}
+			}
+		}
+	}
+
+	var order []int
+	groups := map[int][]prompter.TableData{}
+	for i, table := range tables {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			order = append(order, root)
+		}
+		groups[root] = append(groups[root], table)
+	}
+	res := make([][]prompter.TableData, 0, len(order))
+	for _, root := range order {
+		res = append(res, groups[root])
+	}
+	return res
+}
+
+func splitTableName(name string) (string, string) {
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		return name[:idx], name[idx+1:]
+	}
+	return "", name
+}
+
+// referencedTable guesses referenced table name from a foreign key like column name.
+func referencedTable(column string) string {
+	switch {
+	case strings.HasSuffix(column, "Id") && len(column) > 2:
+		return strings.ToLower(strings.TrimSuffix(column, "Id"))
+	case strings.HasSuffix(strings.ToLower(column), "_id") && len(column) > 3:
+		return strings.ToLower(column[:len(column)-3])
+	default:
+		return ""
+	}
+}
+
+// discoverState keeps endpoints of already processed chunks, so a failed run can be resumed.
+type discoverState struct {
+	path   string
+	Chunks map[string][]gw_model.Endpoint `json:"chunks"`
+}
+
+func loadDiscoverState(path string) (*discoverState, error) {
+	state := &discoverState{path: path, Chunks: map[string][]gw_model.Endpoint{}}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, xerrors.Errorf("unable to read discovery state: %w", err)
+	}
+	if err := json.Unmarshal(raw, state); err != nil {
+		return nil, xerrors.Errorf("unable to parse discovery state: %w", err)
+	}
+	return state, nil
+}
+
+func (s *discoverState) save(key string, endpoints []gw_model.Endpoint) error {
+	s.Chunks[key] = endpoints
+	raw, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("unable to marshal discovery state: %w", err)
+	}
+	return os.WriteFile(s.path, raw, 0644)
+}
+
+func (s *discoverState) clear() {
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		logrus.Warnf("Unable to remove discovery state %s: %v", s.path, err)
+	}
+}
+
+// dedupeEndpoints merges results of several discovery chunks.
+// Group names that differ only by case are unified, endpoints with an already used HTTP route are dropped,
+// and clashing MCP method names get a numeric suffix.
+func dedupeEndpoints(endpoints []gw_model.Endpoint) []gw_model.Endpoint {
+	groups := map[string]string{}
+	routes := map[string]bool{}
+	names := map[string]int{}
+
+	var res []gw_model.Endpoint
+	for _, endpoint := range endpoints {
+		groupKey := strings.ToLower(strings.TrimSpace(endpoint.Group))
+		if group, ok := groups[groupKey]; ok {
+			endpoint.Group = group
+		} else {
+			groups[groupKey] = endpoint.Group
+		}
+
+		route := strings.ToUpper(endpoint.HTTPMethod) + " " + endpoint.HTTPPath
+		if routes[route] {
+			logrus.Warnf("Dropping duplicate endpoint %s (%s)", endpoint.MCPMethod, route)
+			continue
+		}
+		routes[route] = true
+
+		if n, ok := names[endpoint.MCPMethod]; ok {
+			names[endpoint.MCPMethod] = n + 1
+			renamed := fmt.Sprintf("%s_%d", endpoint.MCPMethod, n+1)
+			logrus.Warnf("Renaming duplicate MCP method %s to %s", endpoint.MCPMethod, renamed)
+			endpoint.MCPMethod = renamed
+		}
+		names[endpoint.MCPMethod] = 1
+		res = append(res, endpoint)
+	}
+	return res
+}
+
+// chunkedDiscovery holds options of a multi-pass discovery.
+type chunkedDiscovery struct {
+	ChunkSize    int
+	Scope        string
+	StateFile    string
+	PromptFile   string
+	BuildPrompt  func(tables []prompter.TableData) string
+	Validate     bool
+	RepairRounds int
+}
+
+// discoverInChunks runs discovery separately for every chunk of related tables and deduplicates the result.
+// Endpoints of every finished chunk are stored in the state file. Failed chunks don't stop the run,
+// but the config is not produced until all chunks succeed, rerunning the command resumes from the state file.
+func discoverInChunks(
+	connector connectors.Connector,
+	params DiscoverQueryParams,
+	tables []prompter.TableData,
+	opts chunkedDiscovery,
+) (DiscoverQueryResponse, error) {
+	chunks := planDiscoverChunks(tables, opts.ChunkSize, opts.Scope)
+	state, err := loadDiscoverState(opts.StateFile)
+	if err != nil {
+		return DiscoverQueryResponse{}, err
+	}
+
+	logrus.Infof("Step 4: Split %d tables into %d chunks of related tables", len(tables), len(chunks))
+	logrus.Info("✅ Step 4 completed. Done.")
+	logrus.Info("\r\n")
+
+	logrus.Info("Step 5: Use AI to design the API")
+	response := DiscoverQueryResponse{
+		Conversation: &providers.ConversationResponse{Usage: &providers.ModelUsage{}},
+	}
+	var endpoints []gw_model.Endpoint
+	var failed int
+	for i, chunk := range chunks {
+		progress := fmt.Sprintf("[%d/%d]", i+1, len(chunks))
+		if cached, ok := state.Chunks[chunk.Key]; ok {
+			logrus.Infof("%s Reusing %d endpoint(s) for %s", progress, len(cached), strings.Join(chunk.tableNames(), ", "))
+			endpoints = append(endpoints, cached...)
+			continue
+		}
+
+		logrus.Infof("%s Discovering %d table(s): %s", progress, len(chunk.Tables), strings.Join(chunk.tableNames(), ", "))
+		prompt := opts.BuildPrompt(chunk.Tables)
+		if err := saveToFile(chunkFilePath(opts.PromptFile, i+1), prompt); err != nil {
+			logrus.Error("failed to save prompt:", err)
+		}
+		chunkParams := params
+		chunkParams.LLMLogFile = chunkFilePath(params.LLMLogFile, i+1)
+		chunkResponse, err := discoverEndpoints(connector, chunkParams, prompt, opts.Validate, opts.RepairRounds)
+		if chunkResponse.Conversation != nil && chunkResponse.Conversation.Usage != nil {
+			response.Conversation.Usage.InputTokens += chunkResponse.Conversation.Usage.InputTokens
+			response.Conversation.Usage.OutputTokens += chunkResponse.Conversation.Usage.OutputTokens
+			response.Conversation.Usage.TotalTokens += chunkResponse.Conversation.Usage.TotalTokens
+		}
+		response.CostEstimate += chunkResponse.CostEstimate
+		if err != nil {
+			failed++
+			logrus.Errorf("%s Chunk failed: %v", progress, err)
+			continue
+		}
+		if err := state.save(chunk.Key, chunkResponse.Endpoints); err != nil {
+			logrus.Warnf("Unable to save discovery progress: %v", err)
+		}
+		endpoints = append(endpoints, chunkResponse.Endpoints...)
+	}
+
+	if failed > 0 {
+		return response, xerrors.Errorf("%d of %d chunks failed, rerun the same command to resume, progress is saved in %s", failed, len(chunks), opts.StateFile)
+	}
+	state.clear()
+
+	response.Endpoints = dedupeEndpoints(endpoints)
+	return response, nil
+}
+
+// chunkFilePath adds chunk number to a file name, e.g. prompt.txt -> prompt.3.txt
+func chunkFilePath(path string, chunk int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(path, ext), chunk, ext)
+}
+
+// relatedEndpoints returns endpoints whose query mentions any of the tables.
+func relatedEndpoints(endpoints []gw_model.Endpoint, tables []prompter.TableData) []gw_model.Endpoint {
+	var res []gw_model.Endpoint
+	for _, endpoint := range endpoints {
+		for _, table := range tables {
+			if mentionsTable(endpoint.Query, table.Name) {
+				res = append(res, endpoint)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// mentionsTable reports whether the query refers to the table by its name as a whole identifier,
+// so "order" doesn't match "order_items". Schema prefix of the table name is ignored.
+func mentionsTable(query, table string) bool {
+	_, name := splitTableName(table)
+	if name == "" {
+		return false
+	}
+	re, err := regexp.Compile(`(?i)(^|[^\w$])` + regexp.QuoteMeta(name) + `($|[^\w$])`)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(query)
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"testing"
+
+	gw_model "github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/prompter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func chunkTable(name string, columns ...string) prompter.TableData {
+	table := prompter.TableData{Name: name}
+	for _, column := range columns {
+		table.Columns = append(table.Columns, gw_model.ColumnSchema{Name: column})
+	}
+	return table
+}
+
+func clusterNames(clusters [][]prompter.TableData) [][]string {
+	res := make([][]string, len(clusters))
+	for i, cluster := range clusters {
+		res[i] = discoverChunk{Tables: cluster}.tableNames()
+	}
+	return res
+}
+
+var chunkTestTables = []prompter.TableData{
+	chunkTable("public.customers", "id", "name"),
+	chunkTable("public.audit_log", "id", "message"),
+	chunkTable("public.orders", "id", "customer_id"),
+	chunkTable("public.order_items", "id", "orderId", "product_id"),
+	chunkTable("public.products", "id", "category_id"),
+	chunkTable("public.categories", "id"),
+	chunkTable("sales.orders", "id"),
+}
+
+func TestClusterTables(t *testing.T) {
+	assert.Equal(t, [][]string{
+		{"public.customers", "public.orders", "public.order_items", "public.products", "public.categories"},
+		{"public.audit_log"},
+		{"sales.orders"},
+	}, clusterNames(clusterTables(chunkTestTables)))
+}
+
+func TestPlanDiscoverChunks(t *testing.T) {
+	chunks := planDiscoverChunks(chunkTestTables, 3, "")
+	var names [][]string
+	for _, chunk := range chunks {
+		names = append(names, chunk.tableNames())
+	}
+	// a cluster larger than a chunk is split, smaller clusters share a chunk
+	assert.Equal(t, [][]string{
+		{"public.customers", "public.orders", "public.order_items"},
+		{"public.products", "public.categories", "public.audit_log"},
+		{"sales.orders"},
+	}, names)
+
+	scope := discoverScope("postgres", "postgres://a/shop", "", "openai", "gpt-4o", false, "")
+	chunks = planDiscoverChunks(chunkTestTables, 3, scope)
+	again := planDiscoverChunks(chunkTestTables, 3, scope)
+	for i := range chunks {
+		assert.Len(t, chunks[i].Key, 16)
+		assert.Equal(t, chunks[i].Key, again[i].Key, "keys are stable between runs")
+	}
+	for reason, other := range map[string]string{
+		"instructions": discoverScope("postgres", "postgres://a/shop", "", "openai", "gpt-4o", false, "only read endpoints"),
+		"database":     discoverScope("postgres", "postgres://b/shop", "", "openai", "gpt-4o", false, ""),
+		"model":        discoverScope("postgres", "postgres://a/shop", "", "anthropic", "claude-3-5-sonnet", false, ""),
+		"merge mode":   discoverScope("postgres", "postgres://a/shop", "", "openai", "gpt-4o", true, ""),
+	} {
+		otherChunks := planDiscoverChunks(chunkTestTables, 3, other)
+		for i := range chunks {
+			assert.NotEqual(t, chunks[i].Key, otherChunks[i].Key, "keys depend on %s", reason)
+		}
+	}
+
+	require.Len(t, planDiscoverChunks(chunkTestTables, 10, ""), 1)
+	assert.Empty(t, planDiscoverChunks(nil, 10, ""))
+}
+
+func TestDedupeEndpoints(t *testing.T) {
+	endpoints := dedupeEndpoints([]gw_model.Endpoint{
+		{Group: "Orders", HTTPMethod: "GET", HTTPPath: "/orders", MCPMethod: "list_orders"},
+		{Group: "orders ", HTTPMethod: "get", HTTPPath: "/orders", MCPMethod: "get_orders"},
+		{Group: "orders", HTTPMethod: "GET", HTTPPath: "/orders/recent", MCPMethod: "list_orders"},
+		{Group: "Sales", HTTPMethod: "GET", HTTPPath: "/sales/orders", MCPMethod: "list_orders"},
+	})
+	require.Len(t, endpoints, 3)
+	assert.Equal(t, []string{"Orders", "Orders", "Sales"}, []string{endpoints[0].Group, endpoints[1].Group, endpoints[2].Group})
+	assert.Equal(t, []string{"list_orders", "list_orders_2", "list_orders_3"}, []string{endpoints[0].MCPMethod, endpoints[1].MCPMethod, endpoints[2].MCPMethod})
+}
+
+func TestRelatedEndpoints(t *testing.T) {
+	endpoints := []gw_model.Endpoint{
+		{MCPMethod: "list_orders", Query: "SELECT * FROM orders"},
+		{MCPMethod: "list_order_items", Query: "SELECT * FROM order_items"},
+		{MCPMethod: "quoted_orders", Query: `SELECT * FROM "public"."Orders" WHERE id = :id`},
+		{MCPMethod: "count_reorders", Query: "SELECT count(*) FROM reorders"},
+	}
+	var names []string
+	for _, endpoint := range relatedEndpoints(endpoints, []prompter.TableData{chunkTable("public.orders")}) {
+		names = append(names, endpoint.MCPMethod)
+	}
+	assert.Equal(t, []string{"list_orders", "quoted_orders"}, names)
+
+	tables := queryTables("SELECT * FROM order_items JOIN products ON products.id = order_items.product_id", chunkTestTables)
+	assert.Equal(t, []string{"public.order_items", "public.products"}, discoverChunk{Tables: tables}.tableNames())
+}
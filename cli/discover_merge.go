@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	gw_model "github.com/centralmind/gateway/model"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v3"
+)
+
+type endpointChangeKind string
+
+const (
+	endpointAdded     endpointChangeKind = "added"
+	endpointUpdated   endpointChangeKind = "updated"
+	endpointUnchanged endpointChangeKind = "unchanged"
+	endpointKept      endpointChangeKind = "kept"
+)
+
+// endpointChange describes what a merge did with a single endpoint.
+type endpointChange struct {
+	Kind     endpointChangeKind
+	Endpoint gw_model.Endpoint
+	Previous *gw_model.Endpoint
+}
+
+// loadExistingConfig reads a gateway config that discovery should extend.
+// Environment variables are intentionally not expanded, so the file can be written back as is.
+// Returns nil config without error if the file does not exist.
+func loadExistingConfig(path string) (*gw_model.Config, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, xerrors.Errorf("unable to read existing config: %w", err)
+	}
+	var config gw_model.Config
+	if err := yaml.Unmarshal(raw, &config); err != nil {
+		return nil, xerrors.Errorf("unable to parse existing config: %w", err)
+	}
+	return &config, nil
+}
+
+// endpointFingerprint returns a short content hash of an endpoint, ignoring its stored fingerprint.
+func endpointFingerprint(endpoint gw_model.Endpoint) string {
+	endpoint.Fingerprint = ""
+	raw, _ := json.Marshal(endpoint)
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:8])
+}
+
+// stampFingerprints marks endpoints as generated, so later merges can detect manual edits.
+func stampFingerprints(endpoints []gw_model.Endpoint) []gw_model.Endpoint {
+	res := make([]gw_model.Endpoint, len(endpoints))
+	for i, endpoint := range endpoints {
+		endpoint.Fingerprint = endpointFingerprint(endpoint)
+		res[i] = endpoint
+	}
+	return res
+}
+
+// isHumanEdited reports whether an endpoint was written or changed by hand after generation.
+func isHumanEdited(endpoint gw_model.Endpoint) bool {
+	return endpoint.Fingerprint == "" || endpoint.Fingerprint != endpointFingerprint(endpoint)
+}
+
+func sameEndpoint(a, b gw_model.Endpoint) bool {
+	if a.MCPMethod != "" && a.MCPMethod == b.MCPMethod {
+		return true
+	}
+	return a.HTTPMethod == b.HTTPMethod && a.HTTPPath == b.HTTPPath
+}
+
+// mergeEndpoints combines existing endpoints with freshly generated ones.
+// Generated endpoints replace existing ones with the same MCP method or HTTP route,
+// unless the existing endpoint was edited by hand, in which case it is kept untouched.
+// Order of existing endpoints is preserved, new endpoints are appended at the end.
+// Generated endpoints are expected to be stamped already, see stampFingerprints.
+func mergeEndpoints(existing, generated []gw_model.Endpoint) ([]gw_model.Endpoint, []endpointChange) {
+	used := make([]bool, len(generated))
+
+	var merged []gw_model.Endpoint
+	var changes []endpointChange
+	for _, current := range existing {
+		matched := -1
+		for i, candidate := range generated {
+			if !used[i] && sameEndpoint(current, candidate) {
+				matched = i
+				break
+			}
+		}
+		if matched < 0 {
+			merged = append(merged, current)
+			changes = append(changes, endpointChange{Kind: endpointUnchanged, Endpoint: current})
+			continue
+		}
+		used[matched] = true
+		candidate := generated[matched]
+		switch {
+		case isHumanEdited(current):
+			merged = append(merged, current)
+			changes = append(changes, endpointChange{Kind: endpointKept, Endpoint: current, Previous: &candidate})
+		case current.Fingerprint == candidate.Fingerprint:
+			merged = append(merged, current)
+			changes = append(changes, endpointChange{Kind: endpointUnchanged, Endpoint: current})
+		default:
+			previous := current
+			merged = append(merged, candidate)
+			changes = append(changes, endpointChange{Kind: endpointUpdated, Endpoint: candidate, Previous: &previous})
+		}
+	}
+
+	for i, candidate := range generated {
+		if used[i] {
+			continue
+		}
+		merged = append(merged, candidate)
+		changes = append(changes, endpointChange{Kind: endpointAdded, Endpoint: candidate})
+	}
+	return merged, changes
+}
+
+// printMergePreview shows which endpoints a merge adds, updates or keeps.
+func printMergePreview(changes []endpointChange) {
+	var unchanged int
+	logrus.Info("Config changes:")
+	for _, change := range changes {
+		endpoint := change.Endpoint
+		switch change.Kind {
+		case endpointAdded:
+			logrus.Infof(green+"  + "+reset+cyan+"%s"+reset+" "+violet+"%s"+reset+" - %s", endpoint.HTTPMethod, endpoint.HTTPPath, endpoint.Summary)
+		case endpointUpdated:
+			logrus.Infof(yellow+"  ~ "+reset+cyan+"%s"+reset+" "+violet+"%s"+reset+" - %s", endpoint.HTTPMethod, endpoint.HTTPPath, endpoint.Summary)
+			for _, field := range changedFields(*change.Previous, endpoint) {
+				logrus.Infof("      %s changed", field)
+			}
+		case endpointKept:
+			logrus.Infof(red+"  = "+reset+cyan+"%s"+reset+" "+violet+"%s"+reset+" - edited by hand, not overwritten", endpoint.HTTPMethod, endpoint.HTTPPath)
+		default:
+			unchanged++
+		}
+	}
+	logrus.Infof("  %d endpoint(s) unchanged", unchanged)
+}
+
+// confirmMerge asks whether a merged config should be written, any answer but yes keeps the file as is.
+// Input without an answer, e.g. a closed stdin, is not a confirmation.
+func confirmMerge(in *bufio.Reader, out io.Writer, path string) (bool, error) {
+	fmt.Fprintf(out, "Write these changes to %s? [y/N]: ", path)
+	line, err := in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, xerrors.Errorf("unable to read answer: %w", err)
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes", nil
+}
+
+func changedFields(before, after gw_model.Endpoint) []string {
+	var fields []string
+	if before.HTTPMethod != after.HTTPMethod || before.HTTPPath != after.HTTPPath {
+		fields = append(fields, "route")
+	}
+	if before.Summary != after.Summary || before.Description != after.Description {
+		fields = append(fields, "description")
+	}
+	if before.Query != after.Query {
+		fields = append(fields, "query")
+	}
+	if before.IsArrayResult != after.IsArrayResult {
+		fields = append(fields, "result type")
+	}
+	if jsonify(before.Params) != jsonify(after.Params) {
+		fields = append(fields, "params")
+	}
+	return fields
+}
+
+func jsonify(data any) string {
+	res, _ := json.Marshal(data)
+	return string(res)
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	gw_model "github.com/centralmind/gateway/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mergeEndpoint(method, query string) gw_model.Endpoint {
+	return gw_model.Endpoint{
+		HTTPMethod: "GET",
+		HTTPPath:   "/" + method,
+		MCPMethod:  method,
+		Summary:    method,
+		Query:      query,
+	}
+}
+
+func TestEndpointFingerprint(t *testing.T) {
+	endpoint := mergeEndpoint("list_users", "SELECT * FROM users")
+	fingerprint := endpointFingerprint(endpoint)
+	assert.Len(t, fingerprint, 16)
+
+	// the stored fingerprint itself is not part of the hash
+	endpoint.Fingerprint = "stale"
+	assert.Equal(t, fingerprint, endpointFingerprint(endpoint))
+
+	endpoint.Query = "SELECT id FROM users"
+	assert.NotEqual(t, fingerprint, endpointFingerprint(endpoint))
+
+	stamped := stampFingerprints([]gw_model.Endpoint{endpoint})[0]
+	assert.False(t, isHumanEdited(stamped))
+	stamped.Summary = "edited"
+	assert.True(t, isHumanEdited(stamped))
+	assert.True(t, isHumanEdited(endpoint), "endpoints without a fingerprint are written by hand")
+}
+
+func TestMergeEndpoints(t *testing.T) {
+	generated := stampFingerprints([]gw_model.Endpoint{
+		mergeEndpoint("list_users", "SELECT * FROM users"),
+		mergeEndpoint("list_orders", "SELECT * FROM orders"),
+		mergeEndpoint("list_items", "SELECT * FROM items"),
+	})
+	edited := generated[2]
+	edited.Query = "SELECT * FROM items WHERE visible"
+	existing := []gw_model.Endpoint{
+		generated[0],
+		generated[1],
+		edited,
+		mergeEndpoint("custom_report", "SELECT 1"),
+	}
+
+	regenerated := stampFingerprints([]gw_model.Endpoint{
+		mergeEndpoint("list_items", "SELECT id FROM items"),
+		mergeEndpoint("list_users", "SELECT * FROM users"),
+		mergeEndpoint("list_orders", "SELECT id, total FROM orders"),
+		mergeEndpoint("list_payments", "SELECT * FROM payments"),
+	})
+	merged, changes := mergeEndpoints(existing, regenerated)
+
+	var names []string
+	for _, endpoint := range merged {
+		names = append(names, endpoint.MCPMethod)
+	}
+	assert.Equal(t, []string{"list_users", "list_orders", "list_items", "custom_report", "list_payments"}, names)
+
+	kinds := map[string]endpointChangeKind{}
+	for _, change := range changes {
+		kinds[change.Endpoint.MCPMethod] = change.Kind
+	}
+	assert.Equal(t, map[string]endpointChangeKind{
+		"list_users":    endpointUnchanged,
+		"list_orders":   endpointUpdated,
+		"list_items":    endpointKept,
+		"custom_report": endpointUnchanged,
+		"list_payments": endpointAdded,
+	}, kinds)
+
+	assert.Equal(t, "SELECT id, total FROM orders", merged[1].Query)
+	assert.False(t, isHumanEdited(merged[1]), "updated endpoints get a fresh fingerprint")
+	assert.Equal(t, edited, merged[2], "hand-edited endpoints are never overwritten")
+	assert.Empty(t, merged[3].Fingerprint)
+	assert.False(t, isHumanEdited(merged[4]))
+}
+
+func TestMergeEndpointsMatchesRoutes(t *testing.T) {
+	existing := stampFingerprints([]gw_model.Endpoint{mergeEndpoint("list_users", "SELECT * FROM users")})
+	renamed := mergeEndpoint("users", "SELECT id FROM users")
+	renamed.HTTPPath = "/list_users"
+
+	merged, changes := mergeEndpoints(existing, stampFingerprints([]gw_model.Endpoint{renamed}))
+	require.Len(t, merged, 1)
+	assert.Equal(t, "users", merged[0].MCPMethod)
+	assert.Equal(t, endpointUpdated, changes[0].Kind)
+	assert.Equal(t, "list_users", changes[0].Previous.MCPMethod)
+}
+
+func TestChangedFields(t *testing.T) {
+	before := mergeEndpoint("list_users", "SELECT * FROM users")
+	assert.Empty(t, changedFields(before, before))
+
+	after := before
+	after.HTTPPath = "/users"
+	after.Description = "All users"
+	after.Query = "SELECT id FROM users"
+	after.IsArrayResult = true
+	after.Params = []gw_model.EndpointParams{{Name: "limit", Type: "integer"}}
+	assert.Equal(t, []string{"route", "description", "query", "result type", "params"}, changedFields(before, after))
+}
+
+func TestConfirmMerge(t *testing.T) {
+	for input, expected := range map[string]bool{
+		"y\n":     true,
+		" YES \n": true,
+		"n\n":     false,
+		"\n":      false,
+		"":        false,
+	} {
+		var out bytes.Buffer
+		confirmed, err := confirmMerge(bufio.NewReader(strings.NewReader(input)), &out, "gateway.yaml")
+		require.NoError(t, err)
+		assert.Equal(t, expected, confirmed, input)
+		assert.Contains(t, out.String(), "gateway.yaml")
+	}
+}
@@ -0,0 +1,272 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/centralmind/gateway/connectors"
+	gw_model "github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/prompter"
+	"github.com/centralmind/gateway/providers"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v3"
+)
+
+// piiColumn is a single column classified by the model as PII or sensitive data.
+type piiColumn struct {
+	Table    string `yaml:"table"`
+	Column   string `yaml:"column"`
+	Category string `yaml:"category"`
+	Reason   string `yaml:"reason"`
+}
+
+type piiReport struct {
+	Columns []piiColumn `yaml:"columns"`
+}
+
+// classifyPII asks the model to find PII columns in discovered tables.
+// Large schemas are classified chunk by chunk, using the same chunking as endpoint discovery.
+func classifyPII(
+	connector connectors.Connector,
+	params DiscoverQueryParams,
+	tables []prompter.TableData,
+	chunkSize int,
+) (piiReport, DiscoverQueryResponse, error) {
+	chunks := []discoverChunk{{Tables: tables}}
+	if chunkSize > 0 && len(tables) > chunkSize {
+		chunks = planDiscoverChunks(tables, chunkSize, "")
+	}
+
+	var report piiReport
+	var usage DiscoverQueryResponse
+	for i, chunk := range chunks {
+		if len(chunks) > 1 {
+			logrus.Infof("[%d/%d] Classifying %d table(s)", i+1, len(chunks), len(chunk.Tables))
+		}
+		prompt := prompter.PIIReportPrompt(connector, chunk.Tables, prompter.SchemaFromConfig(connector.Config()))
+		response, err := chatJSON(params, prompt, &providers.ResponseSchema{
+			Name:        "pii_report",
+			Description: "Columns that contain PII or sensitive data",
+			Schema:      prompter.PIIReportSchema(),
+		})
+		if err != nil {
+			return report, usage, err
+		}
+		usage.addUsage(response)
+
+		var chunkReport piiReport
+		if err := yaml.Unmarshal([]byte(response.RawContent), &chunkReport); err != nil {
+			return report, usage, xerrors.Errorf("unable to unmarshal PII report: %w", err)
+		}
+		report.Columns = append(report.Columns, chunkReport.Columns...)
+	}
+
+	report.Columns = knownPIIColumns(report.Columns, tables)
+	return report, usage, nil
+}
+
+// knownPIIColumns drops columns that don't exist in discovered tables and normalizes table names,
+// so hallucinated or schema qualified names can't break the config.
+func knownPIIColumns(columns []piiColumn, tables []prompter.TableData) []piiColumn {
+	var res []piiColumn
+	for _, col := range columns {
+		table, ok := findTable(tables, col.Table)
+		if !ok {
+			logrus.Warnf("Ignoring PII column %s.%s: table not found", col.Table, col.Column)
+			continue
+		}
+		found := false
+		for _, c := range table.Columns {
+			if c.Name == col.Column {
+				found = true
+				break
+			}
+		}
+		if !found {
+			logrus.Warnf("Ignoring PII column %s.%s: column not found", col.Table, col.Column)
+			continue
+		}
+		col.Table = table.Name
+		res = append(res, col)
+	}
+	return res
+}
+
+func findTable(tables []prompter.TableData, name string) (prompter.TableData, bool) {
+	_, bare := splitTableName(name)
+	for _, table := range tables {
+		if table.Name == name {
+			return table, true
+		}
+	}
+	for _, table := range tables {
+		if _, tableBare := splitTableName(table.Name); tableBare == bare {
+			return table, true
+		}
+	}
+	return prompter.TableData{}, false
+}
+
+// applyPIIReport marks PII columns in the config tables section and configures a redaction plugin.
+// Existing tables and plugin settings are extended, not replaced.
+func applyPIIReport(config *gw_model.Config, report piiReport, tables []prompter.TableData, plugin string) error {
+	pii := map[string]map[string]bool{}
+	for _, col := range report.Columns {
+		if pii[col.Table] == nil {
+			pii[col.Table] = map[string]bool{}
+		}
+		pii[col.Table][col.Column] = true
+	}
+
+	for _, table := range tables {
+		if len(pii[table.Name]) == 0 {
+			continue
+		}
+		idx := -1
+		for i, existing := range config.Database.Tables {
+			if existing.Name == table.Name {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			config.Database.Tables = append(config.Database.Tables, gw_model.TableWithEndpoints{
+				Name:     table.Name,
+				Columns:  append([]gw_model.ColumnSchema(nil), table.Columns...),
+				RowCount: table.RowCount,
+			})
+			idx = len(config.Database.Tables) - 1
+		}
+		for i, col := range config.Database.Tables[idx].Columns {
+			if pii[table.Name][col.Name] {
+				config.Database.Tables[idx].Columns[i].PII = true
+			}
+		}
+	}
+
+	if len(report.Columns) == 0 {
+		return nil
+	}
+	if config.Plugins == nil {
+		config.Plugins = map[string]any{}
+	}
+	switch plugin {
+	case "pii_remover":
+		config.Plugins[plugin] = piiRemoverConfig(config.Plugins[plugin], report)
+	case "presidio_anonymizer":
+		config.Plugins[plugin] = presidioConfig(config.Plugins[plugin], report)
+	case "":
+	default:
+		return xerrors.Errorf("unsupported PII plugin: %s, use pii_remover or presidio_anonymizer", plugin)
+	}
+	return nil
+}
+
+func piiRemoverConfig(existing any, report piiReport) map[string]any {
+	cfg, _ := existing.(map[string]any)
+	if cfg == nil {
+		cfg = map[string]any{"replacement": "[REDACTED]"}
+	}
+	fields := map[string]bool{}
+	if current, ok := cfg["fields"].([]any); ok {
+		for _, f := range current {
+			fields[fmt.Sprint(f)] = true
+		}
+	}
+	for _, col := range report.Columns {
+		fields[col.Column] = true
+	}
+	cfg["fields"] = sortedKeys(fields)
+	return cfg
+}
+
+func presidioConfig(existing any, report piiReport) map[string]any {
+	cfg, _ := existing.(map[string]any)
+	if cfg == nil {
+		cfg = map[string]any{
+			"analyzer_url":  "http://localhost:8080/analyze",
+			"anonymize_url": "http://localhost:8080/anonymize",
+			"language":      "en",
+		}
+	}
+	types := map[string]bool{}
+	var rules []any
+	if current, ok := cfg["anonymizer_rules"].([]any); ok {
+		rules = current
+		for _, r := range current {
+			if rule, ok := r.(map[string]any); ok {
+				types[fmt.Sprint(rule["type"])] = true
+			}
+		}
+	}
+	var categories []string
+	for _, col := range report.Columns {
+		if !types[col.Category] {
+			types[col.Category] = true
+			categories = append(categories, col.Category)
+		}
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		rules = append(rules, map[string]any{
+			"type":      category,
+			"operator":  "replace",
+			"new_value": "<" + category + ">",
+		})
+	}
+	cfg["anonymizer_rules"] = rules
+	return cfg
+}
+
+func sortedKeys(set map[string]bool) []string {
+	res := make([]string, 0, len(set))
+	for k := range set {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// piiReportMarkdown renders PII report for compliance reviewers.
+func piiReportMarkdown(report piiReport, databaseType string, tables []prompter.TableData, plugin string) string {
+	var b strings.Builder
+	b.WriteString("# PII Report\n\n")
+	b.WriteString(fmt.Sprintf("- Generated: %s\n", time.Now().Format(time.RFC1123)))
+	b.WriteString(fmt.Sprintf("- Database: %s\n", databaseType))
+	b.WriteString(fmt.Sprintf("- Tables analyzed: %d\n", len(tables)))
+	b.WriteString(fmt.Sprintf("- Columns with PII: %d\n", len(report.Columns)))
+	if plugin != "" && len(report.Columns) > 0 {
+		b.WriteString(fmt.Sprintf("- Redaction plugin configured: `%s`\n", plugin))
+	}
+	b.WriteString("\nThe classification is done by an AI model based on column names and data samples, ")
+	b.WriteString("it must be reviewed by a human before being relied upon.\n\n")
+
+	if len(report.Columns) == 0 {
+		b.WriteString("No PII detected.\n")
+		return b.String()
+	}
+
+	columns := append([]piiColumn(nil), report.Columns...)
+	sort.SliceStable(columns, func(i, j int) bool {
+		if columns[i].Table != columns[j].Table {
+			return columns[i].Table < columns[j].Table
+		}
+		return columns[i].Column < columns[j].Column
+	})
+	b.WriteString("| Table | Column | Category | Reason |\n")
+	b.WriteString("|-------|--------|----------|--------|\n")
+	for _, col := range columns {
+		b.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", col.Table, col.Column, col.Category, strings.ReplaceAll(col.Reason, "|", "\\|")))
+	}
+	return b.String()
+}
+
+// logFileWithSuffix derives a log file of an extra AI pass from the discovery log, e.g. llm.log -> llm.pii.log
+func logFileWithSuffix(path, suffix string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + suffix + ext
+}
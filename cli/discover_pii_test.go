@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	gw_model "github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/prompter"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var piiTestTables = []prompter.TableData{{
+	Name: "users",
+	Columns: []gw_model.ColumnSchema{
+		{Name: "id", Type: gw_model.TypeNumber, PrimaryKey: true},
+		{Name: "name", Type: gw_model.TypeString},
+		{Name: "phone", Type: gw_model.TypeString},
+	},
+}}
+
+func TestKnownPIIColumns(t *testing.T) {
+	columns := knownPIIColumns([]piiColumn{
+		{Table: "public.users", Column: "name", Category: "PERSON"},
+		{Table: "users", Column: "nickname", Category: "PERSON"},
+		{Table: "accounts", Column: "email", Category: "EMAIL_ADDRESS"},
+	}, piiTestTables)
+	assert.Equal(t, []piiColumn{{Table: "users", Column: "name", Category: "PERSON"}}, columns)
+}
+
+func TestApplyPIIReport(t *testing.T) {
+	report := piiReport{Columns: []piiColumn{
+		{Table: "users", Column: "name", Category: "PERSON"},
+		{Table: "users", Column: "phone", Category: "PHONE_NUMBER"},
+	}}
+
+	config := gw_model.Config{Plugins: map[string]any{
+		"pii_remover": map[string]any{"replacement": "***", "fields": []any{"email"}},
+	}}
+	require.NoError(t, applyPIIReport(&config, report, piiTestTables, "pii_remover"))
+	require.Len(t, config.Database.Tables, 1)
+	var pii []string
+	for _, col := range config.Database.Tables[0].Columns {
+		if col.PII {
+			pii = append(pii, col.Name)
+		}
+	}
+	assert.Equal(t, []string{"name", "phone"}, pii)
+	assert.False(t, piiTestTables[0].Columns[1].PII, "discovered tables are not changed")
+	assert.Equal(t, map[string]any{
+		"replacement": "***",
+		"fields":      []string{"email", "name", "phone"},
+	}, config.Plugins["pii_remover"])
+
+	config = gw_model.Config{Plugins: map[string]any{
+		"presidio_anonymizer": map[string]any{"anonymizer_rules": []any{
+			map[string]any{"type": "PERSON", "operator": "mask"},
+		}},
+	}}
+	require.NoError(t, applyPIIReport(&config, report, piiTestTables, "presidio_anonymizer"))
+	assert.Equal(t, []any{
+		map[string]any{"type": "PERSON", "operator": "mask"},
+		map[string]any{"type": "PHONE_NUMBER", "operator": "replace", "new_value": "<PHONE_NUMBER>"},
+	}, config.Plugins["presidio_anonymizer"].(map[string]any)["anonymizer_rules"])
+
+	config = gw_model.Config{}
+	require.NoError(t, applyPIIReport(&config, report, piiTestTables, ""))
+	assert.Empty(t, config.Plugins)
+	assert.Error(t, applyPIIReport(&config, report, piiTestTables, "unknown"))
+}
+
+func TestPIIReportMarkdown(t *testing.T) {
+	markdown := piiReportMarkdown(piiReport{Columns: []piiColumn{
+		{Table: "users", Column: "phone", Category: "PHONE_NUMBER", Reason: "work | home"},
+		{Table: "users", Column: "name", Category: "PERSON", Reason: "Full name"},
+	}}, "sqlite", piiTestTables, "pii_remover")
+	assert.Contains(t, markdown, "- Columns with PII: 2\n")
+	assert.Contains(t, markdown, "- Redaction plugin configured: `pii_remover`\n")
+	assert.Contains(t, markdown, "| users | name | PERSON | Full name |\n| users | phone | PHONE_NUMBER | work \\| home |\n")
+
+	assert.Contains(t, piiReportMarkdown(piiReport{}, "sqlite", piiTestTables, "pii_remover"), "No PII detected.")
+}
+
+func TestDiscoverPIIRecordReplay(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "test.db")
+	db, err := sqlx.Connect("sqlite", dbPath)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, phone TEXT);
+INSERT INTO users (id, name, phone) VALUES (1, 'alice', '+1 555 0100'), (2, 'bob', '+1 555 0101');`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+	dsn := "sqlite://" + dbPath
+
+	recordDir := t.TempDir()
+	transcript := filepath.Join(recordDir, "llm.log")
+	recorded := runDiscover(t, recordDir,
+		"--connection-string", dsn,
+		"--ai-provider", "test-fake",
+		"--ai-record",
+		"--llm-log", transcript,
+		"--pii-report",
+		"--pii-report-file", filepath.Join(recordDir, "pii_report.md"),
+	)
+
+	replayDir := t.TempDir()
+	replayed := runDiscover(t, replayDir,
+		"--connection-string", dsn,
+		"--ai-provider", "replay",
+		"--ai-transcript", transcript,
+		"--llm-log", filepath.Join(replayDir, "llm.log"),
+		"--pii-report",
+		"--pii-report-file", filepath.Join(replayDir, "pii_report.md"),
+	)
+	assert.Equal(t, recorded, replayed)
+
+	var config gw_model.Config
+	require.NoError(t, yaml.Unmarshal([]byte(replayed), &config))
+	require.Len(t, config.Database.Tables, 1)
+	pii := map[string]bool{}
+	for _, col := range config.Database.Tables[0].Columns {
+		pii[col.Name] = col.PII
+	}
+	assert.Equal(t, map[string]bool{"id": false, "name": true, "phone": true}, pii)
+	assert.Equal(t, []any{"name", "phone"}, config.Plugins["pii_remover"].(map[string]any)["fields"])
+
+	generated := regexp.MustCompile(`- Generated: .*\n`)
+	readReport := func(dir string) string {
+		raw, err := os.ReadFile(filepath.Join(dir, "pii_report.md"))
+		require.NoError(t, err)
+		return generated.ReplaceAllString(string(raw), "")
+	}
+	report := readReport(replayDir)
+	assert.Equal(t, readReport(recordDir), report)
+	assert.Contains(t, report, "- Columns with PII: 2\n")
+	assert.NotContains(t, report, "nickname", "columns missing in the schema are dropped")
+}
@@ -0,0 +1,337 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/centralmind/gateway/connectors"
+	gw_model "github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/providers"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	routeParamRe = regexp.MustCompile(`\{([^{}]+)\}`)
+	// sqlParamRe matches sqlx named parameters (:name) but not postgres casts (::type)
+	sqlParamRe = regexp.MustCompile(`(^|[^:]):([a-zA-Z_][a-zA-Z0-9_]*)`)
+	// templateParamRe matches mustache style parameters ({{name}} or {{.name}})
+	templateParamRe = regexp.MustCompile(`\{\{\s*\.?([a-zA-Z_][a-zA-Z0-9_]*)\s*\}\}`)
+)
+
+type repairStatus string
+
+const (
+	repairValid   repairStatus = "kept"
+	repairFixed   repairStatus = "fixed"
+	repairDropped repairStatus = "dropped"
+)
+
+// repairResult is the final state of a single generated endpoint after validation.
+type repairResult struct {
+	Endpoint gw_model.Endpoint
+	Status   repairStatus
+	Rounds   int
+	Problems []string
+}
+
+// validateEndpoint checks that endpoint is consistent and its query is accepted by the database.
+// Returns list of human-readable problems, empty if endpoint is valid.
+func validateEndpoint(ctx context.Context, connector connectors.Connector, endpoint gw_model.Endpoint) []string {
+	var problems []string
+	if endpoint.MCPMethod == "" {
+		problems = append(problems, "mcp_method is empty")
+	}
+	if endpoint.Query == "" {
+		problems = append(problems, "query is empty")
+		return problems
+	}
+
+	declared := map[string]gw_model.EndpointParams{}
+	for _, param := range endpoint.Params {
+		declared[param.Name] = param
+	}
+
+	inRoute := map[string]bool{}
+	for _, match := range routeParamRe.FindAllStringSubmatch(endpoint.HTTPPath, -1) {
+		name := match[1]
+		inRoute[name] = true
+		param, ok := declared[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("path parameter {%s} in %s is not declared in params", name, endpoint.HTTPPath))
+			continue
+		}
+		if param.Location != "path" {
+			problems = append(problems, fmt.Sprintf("parameter %s is used in route but has location %q instead of \"path\"", name, param.Location))
+		}
+	}
+	for _, param := range endpoint.Params {
+		if param.Location == "path" && !inRoute[param.Name] {
+			problems = append(problems, fmt.Sprintf("path parameter %s is missing in route %s", param.Name, endpoint.HTTPPath))
+		}
+	}
+
+	for _, name := range queryParams(endpoint.Query) {
+		if _, ok := declared[name]; !ok {
+			problems = append(problems, fmt.Sprintf("query parameter :%s is not declared in params", name))
+		}
+	}
+
+	inferCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	if _, err := connector.InferQuery(inferCtx, endpoint.Query); err != nil {
+		problems = append(problems, fmt.Sprintf("query failed on database: %v", err))
+	}
+	return problems
+}
+
+// queryParams returns unique parameter names referenced by a query.
+func queryParams(query string) []string {
+	seen := map[string]bool{}
+	var res []string
+	for _, match := range sqlParamRe.FindAllStringSubmatch(query, -1) {
+		if !seen[match[2]] {
+			seen[match[2]] = true
+			res = append(res, match[2])
+		}
+	}
+	for _, match := range templateParamRe.FindAllStringSubmatch(query, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			res = append(res, match[1])
+		}
+	}
+	return res
+}
+
+// duplicateMethod returns a problem if the mcp_method of the i-th endpoint is used by another one, tools are
+// registered by name so only one of them would be served. Initially only earlier endpoints are checked, so the
+// first endpoint keeps its name, a repaired endpoint must not take the name of any other one.
+func duplicateMethod(results []repairResult, i int, repaired bool) []string {
+	name := results[i].Endpoint.MCPMethod
+	if name == "" {
+		return nil
+	}
+	for j, other := range results {
+		if j == i || (!repaired && j > i) || other.Status == repairDropped {
+			continue
+		}
+		if other.Endpoint.MCPMethod == name {
+			return []string{fmt.Sprintf("mcp_method %s is already used by another endpoint", name)}
+		}
+	}
+	return nil
+}
+
+// repairEndpoints validates generated endpoints and asks the model, within the same conversation,
+// to fix the broken ones. It runs at most rounds repair iterations, endpoints that are still invalid
+// afterwards are dropped. Token usage and cost of repair rounds are added to the response.
+func repairEndpoints(
+	ctx context.Context,
+	connector connectors.Connector,
+	params DiscoverQueryParams,
+	response *DiscoverQueryResponse,
+	rounds int,
+) []repairResult {
+	results := make([]repairResult, len(response.Endpoints))
+	var pending []int
+	for i, endpoint := range response.Endpoints {
+		results[i] = repairResult{Endpoint: endpoint, Status: repairValid}
+		problems := append(validateEndpoint(ctx, connector, endpoint), duplicateMethod(results, i, false)...)
+		if len(problems) > 0 {
+			results[i].Problems = problems
+			pending = append(pending, i)
+		}
+	}
+
+	// The repair conversation is kept apart from the request of the response, so it stays as it was generated
+	messages := slices.Clone(response.Request.Messages)
+	lastContent := response.RawContent
+	for round := 1; round <= rounds && len(pending) > 0; round++ {
+		logrus.Infof("Repair round %d: %d endpoint(s) failed validation", round, len(pending))
+		for _, i := range pending {
+			logrus.Infof("  - "+red+"%s"+reset+": %s", results[i].Endpoint.MCPMethod, strings.Join(results[i].Problems, "; "))
+		}
+
+		repaired, rawContent, conversation, err := askForRepair(ctx, connector, params, response, messages, lastContent, results, pending)
+		if err != nil {
+			logrus.Warnf("Unable to repair endpoints: %v", err)
+			break
+		}
+		messages, lastContent = conversation, rawContent
+
+		fixed := matchRepaired(results, pending, repaired)
+		var stillPending []int
+		for _, i := range pending {
+			candidate, ok := fixed[i]
+			if !ok {
+				stillPending = append(stillPending, i)
+				continue
+			}
+			results[i].Rounds = round
+			results[i].Endpoint = candidate
+			problems := append(validateEndpoint(ctx, connector, candidate), duplicateMethod(results, i, true)...)
+			if len(problems) > 0 {
+				results[i].Problems = problems
+				stillPending = append(stillPending, i)
+				continue
+			}
+			results[i].Problems = nil
+			results[i].Status = repairFixed
+		}
+		pending = stillPending
+	}
+
+	for _, i := range pending {
+		results[i].Status = repairDropped
+	}
+
+	var endpoints []gw_model.Endpoint
+	for _, result := range results {
+		if result.Status != repairDropped {
+			endpoints = append(endpoints, result.Endpoint)
+		}
+	}
+	response.Endpoints = endpoints
+	return results
+}
+
+// matchRepaired pairs endpoints returned by the model with the pending ones they repair, by index of the result.
+// The model is asked for one endpoint per pending one in the same order, so a full answer is matched by position.
+// Otherwise endpoints are matched by mcp_method, which only works for pending endpoints with a unique name.
+func matchRepaired(results []repairResult, pending []int, repaired []gw_model.Endpoint) map[int]gw_model.Endpoint {
+	res := map[int]gw_model.Endpoint{}
+	if len(repaired) == len(pending) {
+		for k, i := range pending {
+			res[i] = repaired[k]
+		}
+		return res
+	}
+	byName := map[string][]int{}
+	for _, i := range pending {
+		name := results[i].Endpoint.MCPMethod
+		byName[name] = append(byName[name], i)
+	}
+	for _, endpoint := range repaired {
+		if candidates := byName[endpoint.MCPMethod]; endpoint.MCPMethod != "" && len(candidates) == 1 {
+			res[candidates[0]] = endpoint
+		}
+	}
+	return res
+}
+
+// askForRepair continues discovery conversation with validation errors and returns the corrected endpoints,
+// the raw answer and the conversation that the answer continues.
+func askForRepair(
+	ctx context.Context,
+	connector connectors.Connector,
+	params DiscoverQueryParams,
+	response *DiscoverQueryResponse,
+	messages []providers.Message,
+	lastContent string,
+	results []repairResult,
+	pending []int,
+) ([]gw_model.Endpoint, string, []providers.Message, error) {
+	var feedback strings.Builder
+	feedback.WriteString(fmt.Sprintf("Some endpoints failed validation against the %s database:\n", connector.Config().Type()))
+	for k, i := range pending {
+		name := results[i].Endpoint.MCPMethod
+		if name == "" {
+			name = fmt.Sprintf("%s %s", results[i].Endpoint.HTTPMethod, results[i].Endpoint.HTTPPath)
+		}
+		feedback.WriteString(fmt.Sprintf("\n%d. %s:\n", k+1, name))
+		for _, problem := range results[i].Problems {
+			feedback.WriteString(fmt.Sprintf("  - %s\n", problem))
+		}
+	}
+	feedback.WriteString(fmt.Sprintf(`
+Return corrected versions of ONLY these %d endpoints, one per endpoint in the same order, using the same JSON schema: {"endpoints": [...]}.
+Keep mcp_method of each endpoint unchanged, unless it is empty or already used, then choose a new unique one.
+Return an endpoint that cannot be fixed unchanged.`, len(pending)))
+
+	messages = append(messages,
+		providers.Message{
+			Role:    providers.AssistantRole,
+			Content: []providers.ContentBlock{&providers.ContentBlockText{Value: lastContent}},
+		},
+		providers.Message{
+			Role:    providers.UserRole,
+			Content: []providers.ContentBlock{&providers.ContentBlockText{Value: feedback.String()}},
+		},
+	)
+	request := *response.Request
+	request.Messages = messages
+
+	done := make(chan bool)
+	go startSpinner("Repairing endpoints", done)
+	llmResponse, err := response.Provider.Chat(ctx, &request)
+	done <- true
+	if err != nil {
+		return nil, "", nil, xerrors.Errorf("unable to call LLM: %w", err)
+	}
+
+	rawContent := strings.TrimSpace(responseText(llmResponse))
+	appendToLog(params.LLMLogFile, rawContent)
+
+	if llmResponse.Usage != nil {
+		response.CostEstimate += response.Provider.CostEstimate(llmResponse.ModelId, *llmResponse.Usage)
+		if response.Conversation != nil && response.Conversation.Usage != nil {
+			response.Conversation.Usage.InputTokens += llmResponse.Usage.InputTokens
+			response.Conversation.Usage.OutputTokens += llmResponse.Usage.OutputTokens
+			response.Conversation.Usage.TotalTokens += llmResponse.Usage.TotalTokens
+		}
+	}
+
+	var repaired DiscoverQueryResponse
+	if err := yaml.Unmarshal([]byte(rawContent), &repaired); err != nil {
+		return nil, rawContent, nil, xerrors.Errorf("unable to unmarshal response: %w", err)
+	}
+	return repaired.Endpoints, rawContent, messages, nil
+}
+
+// printRepairReport shows final validation status of every generated endpoint.
+func printRepairReport(results []repairResult) {
+	var kept, fixed, dropped int
+	logrus.Info("Endpoint validation report:")
+	for _, result := range results {
+		switch result.Status {
+		case repairFixed:
+			fixed++
+			logrus.Infof("  - "+green+"fixed"+reset+"   %s (after %d round(s))", result.Endpoint.MCPMethod, result.Rounds)
+		case repairDropped:
+			dropped++
+			logrus.Infof("  - "+red+"dropped"+reset+" %s: %s", result.Endpoint.MCPMethod, strings.Join(result.Problems, "; "))
+		default:
+			kept++
+		}
+	}
+	logrus.Infof("Valid: "+yellow+"%d"+reset+", fixed: "+yellow+"%d"+reset+", dropped: "+yellow+"%d"+reset, kept, fixed, dropped)
+}
+
+func responseText(response *providers.ConversationResponse) string {
+	var builder strings.Builder
+	for _, contentBlock := range response.Content {
+		if textBlock, ok := contentBlock.(*providers.ContentBlockText); ok {
+			builder.WriteString(textBlock.Value)
+		}
+	}
+	return builder.String()
+}
+
+func appendToLog(path string, content string) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logrus.Error("Failed to save LLM response:", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.WriteString("\n\n" + content); err != nil {
+		logrus.Error("Failed to save LLM response:", err)
+	}
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/centralmind/gateway/connectors"
+	gw_model "github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testConfig struct{}
+
+func (testConfig) Type() string          { return "sqlite" }
+func (testConfig) Doc() string           { return "" }
+func (testConfig) ExtraPrompt() []string { return nil }
+func (testConfig) Readonly() bool        { return true }
+
+// inferConnector accepts queries that only select from its tables.
+type inferConnector struct {
+	connectors.Connector
+	tables []string
+}
+
+func (c inferConnector) InferQuery(ctx context.Context, query string) ([]gw_model.ColumnSchema, error) {
+	for _, table := range queryTableNames(query) {
+		if !slices.Contains(c.tables, table) {
+			return nil, fmt.Errorf("no such table: %s", table)
+		}
+	}
+	return []gw_model.ColumnSchema{{Name: "id", Type: gw_model.TypeInteger}}, nil
+}
+
+func (c inferConnector) Query(ctx context.Context, endpoint gw_model.Endpoint, params map[string]any) ([]map[string]any, error) {
+	return []map[string]any{{"id": 1}}, nil
+}
+
+func (c inferConnector) Config() connectors.Config { return testConfig{} }
+
+func queryTableNames(query string) []string {
+	var res []string
+	fields := strings.Fields(query)
+	for i, field := range fields {
+		if strings.EqualFold(field, "FROM") && i+1 < len(fields) {
+			res = append(res, fields[i+1])
+		}
+	}
+	return res
+}
+
+// scriptedDiscoverProvider answers calls with the given responses in order and keeps the requests.
+type scriptedDiscoverProvider struct {
+	fakeDiscoverProvider
+	answers  []string
+	requests []*providers.ConversationRequest
+}
+
+func (p *scriptedDiscoverProvider) Chat(ctx context.Context, req *providers.ConversationRequest) (*providers.ConversationResponse, error) {
+	p.requests = append(p.requests, req)
+	answer := p.answers[0]
+	p.answers = p.answers[1:]
+	return &providers.ConversationResponse{
+		ModelId: "fake",
+		Content: []providers.ContentBlock{&providers.ContentBlockText{Value: answer}},
+		Usage:   &providers.ModelUsage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+	}, nil
+}
+
+func TestQueryParams(t *testing.T) {
+	assert.Equal(t, []string{"id", "name"}, queryParams("SELECT * FROM users WHERE id = :id AND name = :name OR id = :id"))
+	assert.Empty(t, queryParams("SELECT created_at::date FROM users"))
+	assert.Equal(t, []string{"limit", "tenant"}, queryParams("SELECT * FROM users LIMIT :limit -- {{.tenant}}"))
+}
+
+func TestValidateEndpoint(t *testing.T) {
+	connector := inferConnector{tables: []string{"users"}}
+	ctx := context.Background()
+
+	valid := gw_model.Endpoint{
+		MCPMethod: "list_users",
+		HTTPPath:  "/users",
+		Query:     "SELECT id, name FROM users",
+	}
+	assert.Empty(t, validateEndpoint(ctx, connector, valid))
+
+	assert.Equal(t, []string{"mcp_method is empty", "query is empty"}, validateEndpoint(ctx, connector, gw_model.Endpoint{}))
+
+	broken := valid
+	broken.HTTPPath = "/users/{user_id}"
+	broken.Params = []gw_model.EndpointParams{{Name: "id", Type: "integer", Location: "path"}}
+	broken.Query = "SELECT id FROM missing_table WHERE id = :id AND name = :name"
+	problems := validateEndpoint(ctx, connector, broken)
+	require.Len(t, problems, 4)
+	assert.Contains(t, problems[0], "path parameter {user_id}")
+	assert.Contains(t, problems[1], "path parameter id is missing in route")
+	assert.Contains(t, problems[2], "query parameter :name")
+	assert.Contains(t, problems[3], "query failed on database")
+}
+
+func TestRepairEndpoints(t *testing.T) {
+	connector := inferConnector{tables: []string{"users"}}
+	provider := &scriptedDiscoverProvider{answers: []string{
+		// unnamed and duplicated endpoints can only be told apart by their position
+		`{"endpoints": [
+			{"mcp_method": "count_users", "query": "SELECT count(*) AS total FROM users"},
+			{"mcp_method": "list_user_names", "query": "SELECT name FROM users"},
+			{"mcp_method": "broken", "query": "SELECT * FROM still_missing"}
+		]}`,
+		`{"endpoints": [{"mcp_method": "broken", "query": "SELECT * FROM never_there"}]}`,
+	}}
+	request := &providers.ConversationRequest{Messages: []providers.Message{{Role: providers.UserRole}}}
+	response := DiscoverQueryResponse{
+		Endpoints: []gw_model.Endpoint{
+			{MCPMethod: "list_users", Query: "SELECT id, name FROM users"},
+			{Query: "SELECT count(*) AS total FROM users"},
+			{MCPMethod: "list_users", Query: "SELECT name FROM users"},
+			{MCPMethod: "broken", Query: "SELECT * FROM missing"},
+		},
+		Conversation: &providers.ConversationResponse{Usage: &providers.ModelUsage{}},
+		Provider:     provider,
+		Request:      request,
+	}
+
+	results := repairEndpoints(context.Background(), connector, DiscoverQueryParams{LLMLogFile: filepath.Join(t.TempDir(), "llm.log")}, &response, 2)
+
+	statuses := make([]repairStatus, len(results))
+	for i, result := range results {
+		statuses[i] = result.Status
+	}
+	assert.Equal(t, []repairStatus{repairValid, repairFixed, repairFixed, repairDropped}, statuses)
+	var names []string
+	for _, endpoint := range response.Endpoints {
+		names = append(names, endpoint.MCPMethod)
+	}
+	assert.Equal(t, []string{"list_users", "count_users", "list_user_names"}, names)
+
+	require.Len(t, provider.requests, 2)
+	assert.Len(t, request.Messages, 1, "repair rounds must not change the request of the response")
+	assert.Len(t, provider.requests[0].Messages, 3)
+	assert.Len(t, provider.requests[1].Messages, 5, "each round continues the conversation of the previous one")
+	assert.Equal(t, 30, response.Conversation.Usage.TotalTokens)
+}
@@ -0,0 +1,326 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/centralmind/gateway/connectors"
+	"github.com/centralmind/gateway/mcpgenerator"
+	gw_model "github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/prompter"
+	"github.com/centralmind/gateway/xcontext"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v3"
+)
+
+// reviewSampleRows limits how many rows of a live sample are shown for an endpoint.
+const reviewSampleRows = 5
+
+// endpointReview is an interactive terminal session that lets a user curate generated endpoints.
+type endpointReview struct {
+	connector connectors.Connector
+	params    DiscoverQueryParams
+	tables    []prompter.TableData
+	// buildPrompt builds discovery prompt for given tables, used to regenerate a single endpoint.
+	buildPrompt func(tables []prompter.TableData) string
+	reader      *bufio.Reader
+	out         io.Writer
+	// usage accumulates tokens and cost spent on regenerations.
+	usage DiscoverQueryResponse
+}
+
+func newEndpointReview(
+	connector connectors.Connector,
+	params DiscoverQueryParams,
+	tables []prompter.TableData,
+	buildPrompt func(tables []prompter.TableData) string,
+	in *bufio.Reader,
+	out io.Writer,
+) *endpointReview {
+	return &endpointReview{
+		connector:   connector,
+		params:      params,
+		tables:      tables,
+		buildPrompt: buildPrompt,
+		reader:      in,
+		out:         out,
+	}
+}
+
+// Run walks through endpoints one by one and returns the accepted ones.
+// Fingerprints are left as they are, so renamed, edited or regenerated endpoints no longer
+// match theirs and later merges keep them as written by hand.
+func (r *endpointReview) Run(ctx context.Context, endpoints []gw_model.Endpoint) ([]gw_model.Endpoint, error) {
+	var accepted []gw_model.Endpoint
+	for i := 0; i < len(endpoints); i++ {
+		endpoint := endpoints[i]
+		r.show(ctx, endpoint, i+1, len(endpoints))
+	actions:
+		for {
+			answer, err := r.ask("[a]ccept, [r]eject, re[n]ame, [e]dit, re[g]enerate, [s]ample, accept a[l]l remaining: ")
+			if err != nil {
+				return nil, err
+			}
+			switch answer {
+			case "a", "":
+				accepted = append(accepted, endpoint)
+				break actions
+			case "r":
+				logrus.Infof("Rejected %s", endpoint.MCPMethod)
+				break actions
+			case "n":
+				name, err := r.ask(fmt.Sprintf("New MCP method name for %s: ", endpoint.MCPMethod))
+				if err != nil {
+					return nil, err
+				}
+				if name != "" {
+					endpoint.MCPMethod = name
+				}
+			case "e":
+				edited, err := r.edit(endpoint)
+				if err != nil {
+					logrus.Errorf("Unable to edit endpoint: %v", err)
+					continue
+				}
+				endpoint = edited
+				r.show(ctx, endpoint, i+1, len(endpoints))
+			case "g":
+				instructions, err := r.ask("Instructions for the AI: ")
+				if err != nil {
+					return nil, err
+				}
+				regenerated, err := r.regenerate(endpoint, instructions)
+				if err != nil {
+					logrus.Errorf("Unable to regenerate endpoint: %v", err)
+					continue
+				}
+				endpoint = regenerated
+				r.show(ctx, endpoint, i+1, len(endpoints))
+			case "s":
+				r.sample(ctx, endpoint, true)
+			case "l":
+				accepted = append(accepted, endpoint)
+				accepted = append(accepted, endpoints[i+1:]...)
+				return accepted, nil
+			default:
+				fmt.Fprintf(r.out, "Unknown action %q\n", answer)
+			}
+		}
+	}
+	return accepted, nil
+}
+
+func (r *endpointReview) ask(question string) (string, error) {
+	fmt.Fprint(r.out, question)
+	line, err := r.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", xerrors.Errorf("unable to read answer: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func (r *endpointReview) show(ctx context.Context, endpoint gw_model.Endpoint, idx, total int) {
+	fmt.Fprintf(r.out, "\n[%d/%d] "+cyan+"%s"+reset+" "+violet+"%s"+reset+" (%s)\n", idx, total, endpoint.HTTPMethod, endpoint.HTTPPath, endpoint.MCPMethod)
+	fmt.Fprintf(r.out, "%s\n", endpoint.Summary)
+	for _, param := range endpoint.Params {
+		fmt.Fprintf(r.out, "  param %s (%s, %s, required: %v)\n", param.Name, param.Type, param.Location, param.Required)
+	}
+	fmt.Fprintf(r.out, "\n%s\n\n", strings.TrimSpace(endpoint.Query))
+	if problems := validateEndpoint(ctx, r.connector, endpoint); len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Fprintf(r.out, red+"  ! %s"+reset+"\n", problem)
+		}
+		return
+	}
+	r.sample(ctx, endpoint, false)
+}
+
+// sample runs endpoint against the database and prints first rows of the result.
+// Parameter defaults are used, missing required values are asked for only if interactive is set.
+// Only endpoints that read data are sampled, in a read-only transaction where the connector supports it,
+// so paging through the review never changes the database.
+func (r *endpointReview) sample(ctx context.Context, endpoint gw_model.Endpoint, interactive bool) {
+	if !mcpgenerator.ReadsOnly(endpoint) {
+		fmt.Fprintln(r.out, "Write endpoint, not sampled")
+		return
+	}
+	params := map[string]any{}
+	for _, param := range endpoint.Params {
+		if param.Default != nil && !interactive {
+			params[param.Name] = param.Default
+			continue
+		}
+		if !param.Required && !interactive {
+			continue
+		}
+		if !interactive {
+			fmt.Fprintf(r.out, "Sample skipped: parameter %s has no default, use [s]ample to provide values\n", param.Name)
+			return
+		}
+		raw, err := r.ask(fmt.Sprintf("Value for %s (%s, default %v): ", param.Name, param.Type, param.Default))
+		if err != nil {
+			return
+		}
+		if raw == "" {
+			if param.Default != nil {
+				params[param.Name] = param.Default
+			}
+			continue
+		}
+		params[param.Name] = parseParamValue(param.Type, raw)
+	}
+
+	queryCtx, cancel := context.WithTimeout(xcontext.WithReadOnly(ctx), 15*time.Second)
+	defer cancel()
+	rows, err := r.connector.Query(queryCtx, endpoint, params)
+	if err != nil {
+		fmt.Fprintf(r.out, red+"Sample failed: %v"+reset+"\n", err)
+		return
+	}
+	fmt.Fprintf(r.out, "Sample result: "+yellow+"%d"+reset+" row(s)\n", len(rows))
+	if len(rows) == 0 {
+		return
+	}
+	if len(rows) > reviewSampleRows {
+		rows = rows[:reviewSampleRows]
+	}
+	printTableSample(sampleColumns(rows), rows)
+}
+
+func parseParamValue(typ string, raw string) any {
+	switch typ {
+	case "integer":
+		if v, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return v
+		}
+	case "number":
+		if v, err := strconv.ParseFloat(raw, 64); err == nil {
+			return v
+		}
+	case "boolean":
+		if v, err := strconv.ParseBool(raw); err == nil {
+			return v
+		}
+	}
+	return raw
+}
+
+func sampleColumns(rows []map[string]any) []gw_model.ColumnSchema {
+	seen := map[string]bool{}
+	var names []string
+	for _, row := range rows {
+		for name := range row {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	columns := make([]gw_model.ColumnSchema, len(names))
+	for i, name := range names {
+		columns[i] = gw_model.ColumnSchema{Name: name}
+	}
+	return columns
+}
+
+// edit opens endpoint as YAML in $EDITOR and returns the edited version.
+func (r *endpointReview) edit(endpoint gw_model.Endpoint) (gw_model.Endpoint, error) {
+	raw, err := yaml.Marshal(endpoint)
+	if err != nil {
+		return endpoint, xerrors.Errorf("unable to marshal endpoint: %w", err)
+	}
+	f, err := os.CreateTemp("", "endpoint-*.yaml")
+	if err != nil {
+		return endpoint, xerrors.Errorf("unable to create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(raw); err != nil {
+		f.Close()
+		return endpoint, xerrors.Errorf("unable to write temp file: %w", err)
+	}
+	f.Close()
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	// stdin is buffered by the review reader, the editor gets the terminal itself
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return endpoint, xerrors.Errorf("unable to open terminal for editor: %w", err)
+	}
+	defer tty.Close()
+	cmd := exec.Command(editor, f.Name())
+	cmd.Stdin = tty
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return endpoint, xerrors.Errorf("editor %s failed: %w", editor, err)
+	}
+
+	raw, err = os.ReadFile(f.Name())
+	if err != nil {
+		return endpoint, xerrors.Errorf("unable to read edited endpoint: %w", err)
+	}
+	var edited gw_model.Endpoint
+	if err := yaml.Unmarshal(raw, &edited); err != nil {
+		return endpoint, xerrors.Errorf("unable to parse edited endpoint: %w", err)
+	}
+	return edited, nil
+}
+
+// regenerate asks the model to redesign a single endpoint following user instructions.
+// Only tables used by the endpoint query are sent as context.
+func (r *endpointReview) regenerate(endpoint gw_model.Endpoint, instructions string) (gw_model.Endpoint, error) {
+	tables := queryTables(endpoint.Query, r.tables)
+	if len(tables) == 0 {
+		tables = r.tables
+	}
+	current, _ := json.MarshalIndent(endpoint, "", "  ")
+	prompt := fmt.Sprintf(`%s
+
+Regenerate ONLY the following endpoint:
+%s
+
+Instructions: %s
+
+Return it using the same JSON schema: {"endpoints": [...]} with exactly one endpoint.`, r.buildPrompt(tables), current, instructions)
+
+	params := r.params
+	params.LLMLogFile = logFileWithSuffix(r.params.LLMLogFile, "review")
+	response, err := chatJSON(params, prompt, endpointsResponseSchema())
+	r.usage.addUsage(response)
+	if err != nil {
+		return endpoint, err
+	}
+	var parsed DiscoverQueryResponse
+	if err := yaml.Unmarshal([]byte(response.RawContent), &parsed); err != nil {
+		return endpoint, xerrors.Errorf("unable to unmarshal response: %w", err)
+	}
+	if len(parsed.Endpoints) == 0 {
+		return endpoint, xerrors.New("AI returned no endpoints")
+	}
+	return parsed.Endpoints[0], nil
+}
+
+// queryTables returns tables mentioned in a query.
+func queryTables(query string, tables []prompter.TableData) []prompter.TableData {
+	var res []prompter.TableData
+	for _, table := range tables {
+		if mentionsTable(query, table.Name) {
+			res = append(res, table)
+		}
+	}
+	return res
+}
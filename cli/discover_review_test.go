@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	gw_model "github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/xcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointReview(t *testing.T) {
+	endpoints := stampFingerprints([]gw_model.Endpoint{
+		mergeEndpoint("list_users", "SELECT id FROM users"),
+		mergeEndpoint("get_user", "SELECT id FROM users WHERE id = 1"),
+		mergeEndpoint("drop_me", "SELECT id FROM users"),
+		mergeEndpoint("count_users", "SELECT count(*) FROM users"),
+		mergeEndpoint("last_user", "SELECT max(id) FROM users"),
+	})
+	// accept, rename and accept, reject, unknown action then accept all remaining
+	in := bufio.NewReader(strings.NewReader("a\nn\nuser_by_id\na\nr\nx\nl\n"))
+	var out bytes.Buffer
+	review := newEndpointReview(inferConnector{tables: []string{"users"}}, DiscoverQueryParams{}, nil, nil, in, &out)
+
+	reviewed, err := review.Run(context.Background(), endpoints)
+	require.NoError(t, err)
+	var names []string
+	for _, endpoint := range reviewed {
+		names = append(names, endpoint.MCPMethod)
+	}
+	assert.Equal(t, []string{"list_users", "user_by_id", "count_users", "last_user"}, names)
+	assert.Contains(t, out.String(), `Unknown action "x"`)
+	assert.Contains(t, out.String(), "Sample result:")
+
+	assert.False(t, isHumanEdited(reviewed[0]))
+	assert.True(t, isHumanEdited(reviewed[1]), "endpoints changed in review count as manual edits")
+	assert.False(t, isHumanEdited(reviewed[2]))
+
+	// a later merge keeps the renamed endpoint as it was reviewed
+	regenerated := stampFingerprints([]gw_model.Endpoint{mergeEndpoint("get_user", "SELECT * FROM users WHERE id = 1")})
+	regenerated[0].HTTPPath = reviewed[1].HTTPPath
+	merged, changes := mergeEndpoints(reviewed, regenerated)
+	assert.Equal(t, reviewed[1], merged[1])
+	assert.Equal(t, endpointKept, changes[1].Kind)
+
+	_, err = newEndpointReview(inferConnector{}, DiscoverQueryParams{}, nil, nil, bufio.NewReader(strings.NewReader("")), &out).Run(context.Background(), endpoints)
+	assert.Error(t, err, "review is aborted when input ends")
+}
+
+// sampleConnector records queries it ran and whether they were read-only.
+type sampleConnector struct {
+	inferConnector
+	queries  []string
+	readOnly []bool
+}
+
+func (c *sampleConnector) Query(ctx context.Context, endpoint gw_model.Endpoint, params map[string]any) ([]map[string]any, error) {
+	c.queries = append(c.queries, endpoint.Query)
+	c.readOnly = append(c.readOnly, xcontext.ReadOnly(ctx))
+	return []map[string]any{{"id": 1}}, nil
+}
+
+func TestEndpointReviewSamplesOnlyReads(t *testing.T) {
+	endpoints := []gw_model.Endpoint{
+		mergeEndpoint("list_users", "SELECT id FROM users"),
+		mergeEndpoint("delete_users", "DELETE FROM users"),
+	}
+	// accept the read, ask for a sample of the write explicitly, then accept it
+	in := bufio.NewReader(strings.NewReader("a\ns\na\n"))
+	var out bytes.Buffer
+	connector := &sampleConnector{inferConnector: inferConnector{tables: []string{"users"}}}
+	_, err := newEndpointReview(connector, DiscoverQueryParams{}, nil, nil, in, &out).Run(context.Background(), endpoints)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"SELECT id FROM users"}, connector.queries)
+	assert.Equal(t, []bool{true}, connector.readOnly, "samples run in a read-only transaction")
+	assert.Equal(t, 2, strings.Count(out.String(), "Write endpoint, not sampled"))
+}
+
+func TestParseParamValue(t *testing.T) {
+	assert.Equal(t, int64(42), parseParamValue("integer", "42"))
+	assert.Equal(t, 1.5, parseParamValue("number", "1.5"))
+	assert.Equal(t, true, parseParamValue("boolean", "true"))
+	assert.Equal(t, "abc", parseParamValue("integer", "abc"))
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/centralmind/gateway/connectors/sqlite"
+	"github.com/centralmind/gateway/providers"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fakeDiscoverResponse = `{"endpoints": [{
+	"group": "Users",
+	"http_method": "GET",
+	"http_path": "/users/{id}",
+	"mcp_method": "get_user",
+	"summary": "Get user by id",
+	"query": "SELECT id, name FROM users WHERE id = :id",
+	"params": [{"name": "id", "type": "integer", "location": "path", "required": true}]
+}]}`
+
+const fakePIIResponse = `{"columns": [
+	{"table": "users", "column": "name", "category": "PERSON", "reason": "Full name of a user"},
+	{"table": "users", "column": "phone", "category": "PHONE_NUMBER", "reason": "Contact phone"},
+	{"table": "users", "column": "nickname", "category": "PERSON", "reason": "Not in the schema"}
+]}`
+
+// fakeDiscoverProvider answers every discovery prompt with the same endpoint and PII prompts with the same report.
+type fakeDiscoverProvider struct{}
+
+func (fakeDiscoverProvider) GetName() string { return "Fake" }
+
+func (fakeDiscoverProvider) CostEstimate(modelId string, usage providers.ModelUsage) float64 {
+	return 0
+}
+
+func (fakeDiscoverProvider) Chat(ctx context.Context, req *providers.ConversationRequest) (*providers.ConversationResponse, error) {
+	answer := fakeDiscoverResponse
+	if req.ResponseSchema != nil && req.ResponseSchema.Name == "pii_report" {
+		answer = fakePIIResponse
+	}
+	return &providers.ConversationResponse{
+		ModelId:    "fake",
+		Content:    []providers.ContentBlock{&providers.ContentBlockText{Value: answer}},
+		StopReason: providers.StopReasonStop,
+		Usage:      &providers.ModelUsage{InputTokens: 100, OutputTokens: 50, TotalTokens: 150},
+	}, nil
+}
+
+func (fakeDiscoverProvider) ChatStream(ctx context.Context, req *providers.ConversationRequest) (providers.ChatStreamOutput, error) {
+	return nil, providers.ErrUnknownProvider
+}
+
+func init() {
+	providers.RegisterModelProvider("test-fake", func(providers.ModelProviderConfig) (providers.ModelProvider, error) {
+		return fakeDiscoverProvider{}, nil
+	})
+}
+
+func runDiscover(t *testing.T, dir string, args ...string) string {
+	output := filepath.Join(dir, "gateway.yaml")
+	cmd := Discover()
+	cmd.SetArgs(append([]string{
+		"--output", output,
+		"--prompt-file", filepath.Join(dir, "prompt.txt"),
+		"--resume-file", filepath.Join(dir, "state.json"),
+		"--validate=false",
+	}, args...))
+	require.NoError(t, cmd.Execute())
+	raw, err := os.ReadFile(output)
+	require.NoError(t, err)
+	return string(raw)
+}
+
+func TestDiscoverRecordReplay(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "test.db")
+	db, err := sqlx.Connect("sqlite", dbPath)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+INSERT INTO users (id, name) VALUES (1, 'alice'), (2, 'bob');`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+	dsn := "sqlite://" + dbPath
+
+	recordDir := t.TempDir()
+	transcript := filepath.Join(recordDir, "llm.log")
+	recorded := runDiscover(t, recordDir,
+		"--connection-string", dsn,
+		"--ai-provider", "test-fake",
+		"--ai-record",
+		"--llm-log", transcript,
+	)
+	assert.Contains(t, recorded, "get_user")
+	assert.FileExists(t, filepath.Join(recordDir, "llm.raw.log"))
+
+	replayDir := t.TempDir()
+	replayed := runDiscover(t, replayDir,
+		"--connection-string", dsn,
+		"--ai-provider", "replay",
+		"--ai-transcript", transcript,
+		"--llm-log", filepath.Join(replayDir, "llm.log"),
+	)
+	assert.Equal(t, recorded, replayed)
+}
@@ -3,12 +3,16 @@ package cli
 import (
 	"context"
 	"github.com/centralmind/gateway/connectors"
+	"net/http"
 	"os"
 	"path/filepath"
 
 	"github.com/centralmind/gateway/logger"
 	"github.com/centralmind/gateway/mcpgenerator"
 	gw_model "github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/plugins"
+	"github.com/centralmind/gateway/xcontext"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"golang.org/x/xerrors"
 )
@@ -17,6 +21,8 @@ func Stdio(configPath *string) *cobra.Command {
 	var logFile string
 	var rawMode bool
 	var dbDSN string
+	var toolGroups []string
+	var httpAddr string
 	res := &cobra.Command{
 		Use:   "stdio",
 		Short: "MCP gateway via std-io",
@@ -46,6 +52,20 @@ optimized for AI agent interactions.`,
 				}
 			}
 
+			requireApproval(gw)
+			if httpAddr != "" {
+				// Plugin pages, such as approval links, need an HTTP server that stdio doesn't have otherwise
+				mux := http.NewServeMux()
+				if err := plugins.Routes(gw.Plugins, mux); err != nil {
+					return xerrors.Errorf("unable to register plugin routes: %w", err)
+				}
+				go func() {
+					if err := http.ListenAndServe(httpAddr, mux); err != nil {
+						logrus.Errorf("Plugin HTTP server stopped: %v", err)
+					}
+				}()
+			}
+
 			srv, err := mcpgenerator.New(gw.Plugins)
 			if err != nil {
 				return xerrors.Errorf("unable to init mcp generator: %w", err)
@@ -57,6 +77,9 @@ optimized for AI agent interactions.`,
 			if err := srv.SetConnector(connector); err != nil {
 				return xerrors.Errorf("unable to set connector: %w", err)
 			}
+			if err := srv.SetResultFormat(gw.ResultFormat); err != nil {
+				return xerrors.Errorf("unable to set result format: %w", err)
+			}
 			if rawMode {
 				srv.EnableRawProtocol()
 			}
@@ -64,13 +87,28 @@ optimized for AI agent interactions.`,
 			if len(allEndpoints) > 0 {
 				srv.SetTools(allEndpoints)
 			}
+			if err := srv.EnableResources(context.Background(), gw.Resources); err != nil {
+				logrus.Warnf("Tables are not exposed as MCP resources: %v", err)
+			}
+			if err := srv.SetPrompts(gw.Prompts); err != nil {
+				return xerrors.Errorf("unable to init prompts: %w", err)
+			}
+			if _, err := enableAI(srv, gw); err != nil {
+				return err
+			}
 
-			return srv.ServeStdio().Listen(context.Background(), os.Stdin, os.Stdout)
+			ctx := context.Background()
+			if len(toolGroups) > 0 {
+				ctx = xcontext.WithToolGroups(ctx, toolGroups)
+			}
+			return srv.ServeStdio().Listen(ctx, os.Stdin, os.Stdout)
 		},
 	}
 
 	res.Flags().BoolVar(&rawMode, "raw", true, "Enable raw protocol mode optimized for AI agents")
 	res.Flags().StringVar(&logFile, "log-file", filepath.Join(logger.DefaultLogDir(), "mcp.log"), "Path to log file for MCP gateway operations")
 	res.Flags().StringVarP(&dbDSN, "connection-string", "C", "", "Database connection string (DSN) for direct database connection")
+	res.Flags().StringVar(&httpAddr, "http-addr", "", "Address to serve plugin pages such as approval links, e.g. ':9090'. Stdio runs no HTTP server by default")
+	res.Flags().StringSliceVar(&toolGroups, "tools", nil, "Groups of tools to offer, e.g. orders,raw. All tools are offered by default")
 	return res
 }
@@ -8,6 +8,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/centralmind/gateway/chatgenerator"
 	"github.com/centralmind/gateway/connectors"
 	"github.com/centralmind/gateway/plugins"
 
@@ -91,6 +92,7 @@ Upon successful startup, the terminal will display URLs for both services.`,
 				return xerrors.Errorf("unable to parse config file: %w", err)
 			}
 		}
+		requireApproval(gw)
 		mux := http.NewServeMux()
 		a, err := restgenerator.New(*gw, prefix)
 
@@ -140,6 +142,9 @@ Upon successful startup, the terminal will display URLs for both services.`,
 		if err := srv.SetConnector(connector); err != nil {
 			return xerrors.Errorf("unable to set connector: %w", err)
 		}
+		if err := srv.SetResultFormat(gw.ResultFormat); err != nil {
+			return xerrors.Errorf("unable to set result format: %w", err)
+		}
 		// Enable raw protocol mode for AI agent communication if specified
 		if rawMode {
 			srv.EnableRawProtocol()
@@ -148,19 +153,30 @@ Upon successful startup, the terminal will display URLs for both services.`,
 		if len(allEndpoints) > 0 {
 			srv.SetTools(allEndpoints)
 		}
-		if !enableRestAPI && !enableMCP {
+		if err := srv.EnableResources(cmd.Context(), gw.Resources); err != nil {
+			logrus.Warnf("Tables are not exposed as MCP resources: %v", err)
+		}
+		if err := srv.SetPrompts(gw.Prompts); err != nil {
+			return xerrors.Errorf("unable to init prompts: %w", err)
+		}
+		provider, err := enableAI(srv, gw)
+		if err != nil {
+			return err
+		}
+		if !enableRestAPI && !enableMCP && !chatEnabled(gw) {
 			logrus.Fatal("At least one of protocol must be enabled, nothing to start")
 		}
 
 		logrus.Infof("Gateway server started successfully!")
+		// Plugins are applied even without MCP, the chat endpoint calls tools through the same server
+		plugs, err := plugins.Plugins[plugins.MCPToolEnricher](gw.Plugins)
+		if err != nil {
+			return xerrors.Errorf("unable to load plugins: %w", err)
+		}
+		for _, plug := range plugs {
+			plug.EnrichMCP(srv)
+		}
 		if enableMCP {
-			plugs, err := plugins.Plugins[plugins.MCPToolEnricher](gw.Plugins)
-			if err != nil {
-				return xerrors.Errorf("unable to load plugins: %w", err)
-			}
-			for _, plug := range plugs {
-				plug.EnrichMCP(srv)
-			}
 			sse := srv.ServeSSE(serverAddresses[0], prefix)
 			mux.Handle(path.Join("/", prefix, "sse"), sse)
 			mux.Handle(path.Join("/", prefix, "message"), sse)
@@ -169,6 +185,12 @@ Upon successful startup, the terminal will display URLs for both services.`,
 			logrus.Infof("MCP SSE server for AI agents is running at: %s", resURL)
 		}
 
+		if chatEnabled(gw) {
+			chatgenerator.New(srv, provider, gw.AI.Model, *gw.AI.Chat).RegisterRoutes(mux, prefix)
+			chatURL, _ := url.JoinPath(serverAddresses[0], "/", prefix, "v1")
+			logrus.Infof("OpenAI-compatible chat completions API is running at: %s", chatURL)
+		}
+
 		if enableRestAPI {
 			if !disableSwagger {
 				swaggerURL := fmt.Sprintf("%s/%s", serverAddresses[0], prefix)
@@ -34,6 +34,8 @@ func (b *BaseConnector) InferResultColumns(ctx context.Context, query string, gu
 	if err != nil {
 		return nil, xerrors.Errorf("BeginTx failed with error: %w", err)
 	}
+	// nothing is written, the transaction only returns its connection to the pool
+	defer tx.Rollback()
 	stmt, err := tx.PrepareNamedContext(ctx, query)
 	if err != nil {
 		return nil, xerrors.Errorf("unable to prepare statement: %w", err)
@@ -8,12 +8,14 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"cloud.google.com/go/bigquery"
 	"github.com/centralmind/gateway/castx"
 	"github.com/centralmind/gateway/connectors"
 	"github.com/centralmind/gateway/logger"
 	"github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/xcontext"
 	"golang.org/x/xerrors"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
@@ -23,6 +25,9 @@ import (
 //go:embed readme.md
 var docString string
 
+// jobPollInterval is how often the status of a running query job is checked
+const jobPollInterval = time.Second
+
 func init() {
 	connectors.Register(func(cfg Config) (connectors.Connector, error) {
 		var opts []option.ClientOption
@@ -289,10 +294,17 @@ func (c *Connector) Query(ctx context.Context, endpoint model.Endpoint, params m
 	}
 
 	// Run query
-	it, err := q.Read(ctx)
+	job, err := q.Run(ctx)
 	if err != nil {
 		return nil, xerrors.Errorf("error executing query: %w", err)
 	}
+	if err := waitJob(ctx, job); err != nil {
+		return nil, xerrors.Errorf("error executing query: %w", err)
+	}
+	it, err := job.Read(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("error reading query results: %w", err)
+	}
 
 	var results []map[string]any
 	for {
@@ -316,6 +328,46 @@ func (c *Connector) Query(ctx context.Context, endpoint model.Endpoint, params m
 	return results, nil
 }
 
+// waitJob polls the query job and reports its progress in units of work from the query timeline.
+// A cancelled context cancels the job as well, otherwise it keeps running and is billed.
+func waitJob(ctx context.Context, job *bigquery.Job) error {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+	for {
+		status, err := job.Status(ctx)
+		if err != nil && ctx.Err() == nil {
+			return xerrors.Errorf("unable to get job status: %w", err)
+		}
+		if err == nil {
+			if status.Done() {
+				return status.Err()
+			}
+			reportJobProgress(ctx, status)
+		}
+		select {
+		case <-ctx.Done():
+			if err := job.Cancel(context.Background()); err != nil {
+				return xerrors.Errorf("unable to cancel job %s: %w", job.ID(), err)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func reportJobProgress(ctx context.Context, status *bigquery.JobStatus) {
+	if status.Statistics == nil {
+		return
+	}
+	stats, ok := status.Statistics.Details.(*bigquery.QueryStatistics)
+	if !ok || len(stats.Timeline) == 0 {
+		return
+	}
+	sample := stats.Timeline[len(stats.Timeline)-1]
+	total := sample.CompletedUnits + sample.PendingUnits + sample.ActiveUnits
+	xcontext.ReportProgress(ctx, float64(sample.CompletedUnits), float64(total))
+}
+
 func (c *Connector) GuessColumnType(sqlType string) model.ColumnType {
 	switch sqlType {
 	case "STRING", "BYTES":
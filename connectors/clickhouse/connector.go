@@ -230,7 +230,7 @@ func (c Connector) Query(ctx context.Context, endpoint model.Endpoint, params ma
 		return nil, xerrors.Errorf("unable to process params: %w", err)
 	}
 
-	rows, err := c.db.NamedQuery(endpoint.Query, processed)
+	rows, err := c.db.NamedQueryContext(ctx, endpoint.Query, processed)
 	if err != nil {
 		return nil, xerrors.Errorf("unable to query db: %w", err)
 	}
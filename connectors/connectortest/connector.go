@@ -0,0 +1,78 @@
+// Package connectortest provides an in-memory connector for tests of packages that run queries through connectors.
+package connectortest
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/centralmind/gateway/connectors"
+	"github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/xcontext"
+)
+
+// Config is a read-only postgres config unless set otherwise.
+type Config struct {
+	DBType    string
+	ReadWrite bool
+}
+
+func (c Config) Type() string {
+	if c.DBType == "" {
+		return "postgres"
+	}
+	return c.DBType
+}
+
+func (Config) Doc() string           { return "" }
+func (Config) ExtraPrompt() []string { return nil }
+func (c Config) Readonly() bool      { return !c.ReadWrite }
+
+// Connector answers every query with Rows and remembers queries, params and headers of the callers.
+// It knows a single users table unless Tables are set, and rejects queries that reference a "missing" column.
+type Connector struct {
+	DBConfig Config
+	Tables   []model.Table
+	// Rows is the result of every query, a single row with count 42 if not set.
+	Rows []map[string]any
+
+	Discoveries int
+	Queries     []string
+	Params      []map[string]any
+	Headers     []map[string][]string
+}
+
+var _ connectors.Connector = (*Connector)(nil)
+
+func (c *Connector) Ping(ctx context.Context) error { return nil }
+
+func (c *Connector) Query(ctx context.Context, endpoint model.Endpoint, params map[string]any) ([]map[string]any, error) {
+	c.Queries = append(c.Queries, endpoint.Query)
+	c.Params = append(c.Params, params)
+	c.Headers = append(c.Headers, xcontext.Headers(ctx))
+	if c.Rows != nil {
+		return c.Rows, nil
+	}
+	return []map[string]any{{"count": 42}}, nil
+}
+
+func (c *Connector) Discovery(ctx context.Context, tablesList []string) ([]model.Table, error) {
+	c.Discoveries++
+	if c.Tables != nil {
+		return c.Tables, nil
+	}
+	return []model.Table{{Name: "users", Columns: []model.ColumnSchema{{Name: "id", Type: model.TypeInteger, PrimaryKey: true}}}}, nil
+}
+
+func (c *Connector) Sample(ctx context.Context, table model.Table) ([]map[string]any, error) {
+	return nil, errors.New("samples are not available")
+}
+
+func (c *Connector) InferQuery(ctx context.Context, query string) ([]model.ColumnSchema, error) {
+	if strings.Contains(query, "missing") {
+		return nil, errors.New(`column "missing" does not exist`)
+	}
+	return []model.ColumnSchema{{Name: "count", Type: model.TypeInteger}}, nil
+}
+
+func (c *Connector) Config() connectors.Config { return c.DBConfig }
@@ -278,7 +278,7 @@ func (c Connector) Query(ctx context.Context, endpoint model.Endpoint, params ma
 	}
 	defer tx.Commit()
 
-	rows, err := tx.NamedQuery(endpoint.Query, processed)
+	rows, err := sqlx.NamedQueryContext(ctx, tx, endpoint.Query, processed)
 	if err != nil {
 		return nil, xerrors.Errorf("unable to execute query: %w", err)
 	}
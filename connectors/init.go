@@ -24,6 +24,13 @@ type Connector interface {
 	Config() Config
 }
 
+// ChangeListener is implemented by connectors that push change events, e.g. postgres with LISTEN/NOTIFY,
+// so subscribed MCP resources don't have to be polled.
+type ChangeListener interface {
+	// Listen calls onChange for every event on the channel until the context is cancelled.
+	Listen(ctx context.Context, channel string, onChange func()) error
+}
+
 var interceptors = map[string]func(any) (Connector, error){}
 var configs = map[string]Config{}
 
@@ -11,6 +11,7 @@ import (
 
 	"github.com/centralmind/gateway/castx"
 	"github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/xcontext"
 	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	"golang.org/x/xerrors"
@@ -226,13 +227,13 @@ func (c Connector) Query(ctx context.Context, endpoint model.Endpoint, params ma
 		return nil, xerrors.Errorf("unable to process params: %w", err)
 	}
 	tx, err := c.base.DB.BeginTxx(ctx, &sql.TxOptions{
-		ReadOnly: c.Config().Readonly(),
+		ReadOnly: c.Config().Readonly() || xcontext.ReadOnly(ctx),
 	})
 	if err != nil {
 		return nil, xerrors.Errorf("BeginTx failed with error: %w", err)
 	}
 	defer tx.Commit()
-	rows, err := tx.NamedQuery(endpoint.Query, processed)
+	rows, err := sqlx.NamedQueryContext(ctx, tx, endpoint.Query, processed)
 	if err != nil {
 		return nil, xerrors.Errorf("unable to query db: %w", err)
 	}
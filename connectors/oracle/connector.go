@@ -247,7 +247,7 @@ func (c Connector) Query(ctx context.Context, endpoint model.Endpoint, params ma
 	}
 
 	// Execute query with numbered parameters
-	rows, err := c.db.Queryx(query, paramValues...)
+	rows, err := c.db.QueryxContext(ctx, query, paramValues...)
 	if err != nil {
 		return nil, xerrors.Errorf("unable to execute query: %w", err)
 	}
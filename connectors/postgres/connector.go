@@ -11,6 +11,7 @@ import (
 
 	"github.com/centralmind/gateway/castx"
 	"github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/xcontext"
 	"github.com/jackc/pgx/v4/stdlib"
 	"github.com/jmoiron/sqlx"
 	"golang.org/x/xerrors"
@@ -24,9 +25,10 @@ func init() {
 		}
 		db := sqlx.NewDb(stdlib.OpenDB(*c), "pgx")
 		return &Connector{
-			config: cfg,
-			db:     db,
-			base:   &connectors.BaseConnector{DB: db},
+			config:    cfg,
+			db:        db,
+			base:      &connectors.BaseConnector{DB: db},
+			listeners: &listeners{},
 		}, nil
 	})
 	connectors.RegisterAlias("postgres", "postgresql")
@@ -34,9 +36,10 @@ func init() {
 
 // Connector implements the connectors.Connector interface for PostgreSQL
 type Connector struct {
-	config Config
-	db     *sqlx.DB
-	base   *connectors.BaseConnector
+	config    Config
+	db        *sqlx.DB
+	base      *connectors.BaseConnector
+	listeners *listeners
 }
 
 func (c Connector) Config() connectors.Config {
@@ -234,14 +237,14 @@ func (c Connector) Query(ctx context.Context, endpoint model.Endpoint, params ma
 	}
 
 	tx, err := c.db.BeginTxx(ctx, &sql.TxOptions{
-		ReadOnly: c.Config().Readonly(),
+		ReadOnly: c.Config().Readonly() || xcontext.ReadOnly(ctx),
 	})
 	if err != nil {
 		return nil, xerrors.Errorf("BeginTx failed with error: %w", err)
 	}
 	defer tx.Commit()
 
-	rows, err := tx.NamedQuery(endpoint.Query, processed)
+	rows, err := sqlx.NamedQueryContext(ctx, tx, endpoint.Query, processed)
 	if err != nil {
 		return nil, xerrors.Errorf("unable to query db: %w", err)
 	}
@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
+	"golang.org/x/xerrors"
+)
+
+// unlistenTimeout limits how long a LISTEN connection is cleaned up before it's closed instead.
+const unlistenTimeout = 5 * time.Second
+
+// listeners share a single LISTEN connection per channel between all watchers of the channel,
+// so subscriptions don't take a pool connection each.
+type listeners struct {
+	mu       sync.Mutex
+	channels map[string]*channelListener
+}
+
+type channelListener struct {
+	cancel    context.CancelFunc
+	callbacks map[int]func()
+	next      int
+	// done is closed when the connection stops listening, err tells why
+	done chan struct{}
+	err  error
+}
+
+// Listen implements connectors.ChangeListener, it registers onChange with the LISTEN connection of the channel
+// and starts one if there is none yet. The connection is released when the last listener of the channel leaves.
+// Changes are expected to be sent by a trigger, e.g. PERFORM pg_notify('orders_changed', NEW.id::text).
+func (c *Connector) Listen(ctx context.Context, channel string, onChange func()) error {
+	l, id := c.listeners.add(c, channel, onChange)
+	defer c.listeners.remove(channel, l, id)
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-l.done:
+		return l.err
+	}
+}
+
+func (ls *listeners) add(c *Connector, channel string, onChange func()) (*channelListener, int) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if ls.channels == nil {
+		ls.channels = make(map[string]*channelListener)
+	}
+	l, ok := ls.channels[channel]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		l = &channelListener{
+			cancel:    cancel,
+			callbacks: make(map[int]func()),
+			done:      make(chan struct{}),
+		}
+		ls.channels[channel] = l
+		go func() {
+			l.err = c.listen(ctx, channel, func() { ls.notify(l) })
+			ls.mu.Lock()
+			// a failed connection is replaced by the next listener of the channel
+			if ls.channels[channel] == l {
+				delete(ls.channels, channel)
+			}
+			ls.mu.Unlock()
+			close(l.done)
+		}()
+	}
+	l.next++
+	l.callbacks[l.next] = onChange
+	return l, l.next
+}
+
+func (ls *listeners) remove(channel string, l *channelListener, id int) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	delete(l.callbacks, id)
+	if len(l.callbacks) == 0 {
+		l.cancel()
+		if ls.channels[channel] == l {
+			delete(ls.channels, channel)
+		}
+	}
+}
+
+func (ls *listeners) notify(l *channelListener) {
+	ls.mu.Lock()
+	callbacks := make([]func(), 0, len(l.callbacks))
+	for _, onChange := range l.callbacks {
+		callbacks = append(callbacks, onChange)
+	}
+	ls.mu.Unlock()
+	for _, onChange := range callbacks {
+		onChange()
+	}
+}
+
+// listen holds a dedicated connection with LISTEN on the channel until the context is cancelled.
+// The connection stops listening before it returns to the pool, or is closed if that fails,
+// so later queries on it don't receive notifications of the channel.
+func (c *Connector) listen(ctx context.Context, channel string, onChange func()) error {
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return xerrors.Errorf("unable to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	var listenErr error
+	_ = conn.Raw(func(driverConn any) error {
+		pgConn := driverConn.(*stdlib.Conn).Conn()
+		listenErr = waitForNotifications(ctx, pgConn, channel, onChange)
+
+		unlistenCtx, cancel := context.WithTimeout(context.Background(), unlistenTimeout)
+		defer cancel()
+		if _, err := pgConn.Exec(unlistenCtx, "UNLISTEN *"); err != nil {
+			// database/sql closes a connection reported as bad instead of pooling it
+			return driver.ErrBadConn
+		}
+		return nil
+	})
+	return listenErr
+}
+
+func waitForNotifications(ctx context.Context, pgConn *pgx.Conn, channel string, onChange func()) error {
+	if _, err := pgConn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return xerrors.Errorf("unable to listen %s: %w", channel, err)
+	}
+	for {
+		if _, err := pgConn.WaitForNotification(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return xerrors.Errorf("unable to wait for notification on %s: %w", channel, err)
+		}
+		onChange()
+	}
+}
@@ -17,6 +17,7 @@ import (
 	_ "github.com/centralmind/gateway/connectors/snowflake"
 	_ "github.com/centralmind/gateway/connectors/sqlite"
 	_ "github.com/centralmind/gateway/plugins/api_keys"
+	_ "github.com/centralmind/gateway/plugins/approval"
 	_ "github.com/centralmind/gateway/plugins/lru_cache"
 	_ "github.com/centralmind/gateway/plugins/lua_rls"
 	_ "github.com/centralmind/gateway/plugins/oauth"
@@ -24,6 +25,8 @@ import (
 	_ "github.com/centralmind/gateway/plugins/pii_remover"
 	_ "github.com/centralmind/gateway/providers/anthropic"
 	_ "github.com/centralmind/gateway/providers/bedrock"
+	_ "github.com/centralmind/gateway/providers/llamacpp"
+	_ "github.com/centralmind/gateway/providers/ollama"
 	_ "github.com/centralmind/gateway/providers/openai"
 )
 
@@ -4,7 +4,7 @@ package mcp
 type CompleteRequest struct {
 	Request
 	Params struct {
-		Ref      interface{} `json:"ref"` // Can be PromptReference or ResourceReference
+		Ref      interface{} `json:"ref"` // Can be PromptReference, ResourceReference or ToolReference
 		Argument struct {
 			// The name of the argument
 			Name string `json:"name"`
@@ -36,6 +36,14 @@ type ResourceReference struct {
 	URI string `json:"uri"`
 }
 
+// ToolReference is a reference to a tool. It is not a part of the protocol but an extension of the gateway,
+// clients may use it to complete arguments of tools the same way as of prompts.
+type ToolReference struct {
+	Type string `json:"type"`
+	// The name of the tool
+	Name string `json:"name"`
+}
+
 // PromptReference is a reference to a prompt.
 type PromptReference struct {
 	Type string `json:"type"`
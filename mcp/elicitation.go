@@ -0,0 +1,36 @@
+package mcp
+
+// ElicitationAction is how the user answered an elicitation request.
+type ElicitationAction string
+
+const (
+	// ElicitationAccept means the user submitted the requested content.
+	ElicitationAccept ElicitationAction = "accept"
+	// ElicitationDecline means the user explicitly refused the request.
+	ElicitationDecline ElicitationAction = "decline"
+	// ElicitationCancel means the user dismissed the request without a choice.
+	ElicitationCancel ElicitationAction = "cancel"
+)
+
+// ElicitRequest is sent from the server to the client to ask the user for input during a request.
+type ElicitRequest struct {
+	Request
+	Params ElicitParams `json:"params"`
+}
+
+// ElicitParams is the message shown to the user and the schema of the content they fill in.
+type ElicitParams struct {
+	// The message to present to the user.
+	Message string `json:"message"`
+	// A restricted JSON schema, an object with properties of primitive types only.
+	RequestedSchema map[string]interface{} `json:"requestedSchema"`
+}
+
+// ElicitResult is the client's response to an elicitation request.
+type ElicitResult struct {
+	Result
+	// The user's action in response to the elicitation.
+	Action ElicitationAction `json:"action"`
+	// The submitted content, only present when the action is accept.
+	Content map[string]interface{} `json:"content,omitempty"`
+}
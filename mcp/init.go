@@ -1,7 +1,7 @@
 package mcp
 
 // LATEST_PROTOCOL_VERSION is the most recent version of the MCP protocol.
-const LATEST_PROTOCOL_VERSION = "2024-11-05"
+const LATEST_PROTOCOL_VERSION = PROTOCOL_VERSION_2025_06_18
 
 // InitializeRequest is sent from the client to the server when it first
 // connects, asking it to begin initialization.
@@ -53,6 +53,8 @@ type ClientCapabilities struct {
 	} `json:"roots,omitempty"`
 	// Present if the client supports sampling from an LLM.
 	Sampling *struct{} `json:"sampling,omitempty"`
+	// Present if the client supports elicitation of user input, since 2025-06-18.
+	Elicitation *struct{} `json:"elicitation,omitempty"`
 }
 
 // ServerCapabilities represents capabilities a server may support.
@@ -61,6 +63,8 @@ type ServerCapabilities struct {
 	Experimental map[string]interface{} `json:"experimental,omitempty"`
 	// Present if the server supports sending log messages to the client.
 	Logging *struct{} `json:"logging,omitempty"`
+	// Present if the server supports argument autocompletion suggestions.
+	Completions *struct{} `json:"completions,omitempty"`
 	// Present if the server offers any prompt templates.
 	Prompts *struct {
 		// Whether this server supports notifications for changes to the prompt list.
@@ -3,19 +3,55 @@ package mcp
 // LoggingLevel represents the severity level of a log message.
 type LoggingLevel string
 
+// Levels follow syslog severities as defined by the protocol, from the least to the most severe.
 const (
-	// LoggingLevelTrace is for very detailed debugging information.
-	LoggingLevelTrace LoggingLevel = "trace"
 	// LoggingLevelDebug is for debugging information.
 	LoggingLevelDebug LoggingLevel = "debug"
 	// LoggingLevelInfo is for general information.
 	LoggingLevelInfo LoggingLevel = "info"
-	// LoggingLevelWarn is for warnings.
-	LoggingLevelWarn LoggingLevel = "warn"
+	// LoggingLevelNotice is for normal but significant events.
+	LoggingLevelNotice LoggingLevel = "notice"
+	// LoggingLevelWarning is for warnings.
+	LoggingLevelWarning LoggingLevel = "warning"
 	// LoggingLevelError is for errors.
 	LoggingLevelError LoggingLevel = "error"
+	// LoggingLevelCritical is for critical conditions.
+	LoggingLevelCritical LoggingLevel = "critical"
+	// LoggingLevelAlert is for conditions that must be fixed immediately.
+	LoggingLevelAlert LoggingLevel = "alert"
+	// LoggingLevelEmergency is for an unusable system.
+	LoggingLevelEmergency LoggingLevel = "emergency"
+
+	// Deprecated: not a protocol level, use LoggingLevelDebug.
+	LoggingLevelTrace LoggingLevel = "trace"
+	// Deprecated: not a protocol level, use LoggingLevelWarning.
+	LoggingLevelWarn LoggingLevel = "warn"
 )
 
+var loggingSeverity = map[LoggingLevel]int{
+	LoggingLevelTrace:     0,
+	LoggingLevelDebug:     0,
+	LoggingLevelInfo:      1,
+	LoggingLevelNotice:    2,
+	LoggingLevelWarn:      3,
+	LoggingLevelWarning:   3,
+	LoggingLevelError:     4,
+	LoggingLevelCritical:  5,
+	LoggingLevelAlert:     6,
+	LoggingLevelEmergency: 7,
+}
+
+// Valid reports whether the level is known.
+func (l LoggingLevel) Valid() bool {
+	_, ok := loggingSeverity[l]
+	return ok
+}
+
+// Enabled reports whether a message of the level passes the minimum level.
+func (l LoggingLevel) Enabled(minimum LoggingLevel) bool {
+	return l.Valid() && minimum.Valid() && loggingSeverity[l] >= loggingSeverity[minimum]
+}
+
 // SetLevelRequest is a request to set the logging level.
 type SetLevelRequest struct {
 	Request
@@ -34,6 +34,9 @@ type ListToolsResult struct {
 type CallToolResult struct {
 	Result
 	Content []Content `json:"content"` // Can be TextContent, ImageContent, or      EmbeddedResource
+	// An optional JSON object that represents the structured result of the tool call.
+	// It conforms to the output schema of the tool if one is defined.
+	StructuredContent map[string]interface{} `json:"structuredContent,omitempty"`
 	// Whether the tool call ended in an error.
 	//
 	// If not set, this is assumed to be false (the call was successful).
@@ -75,6 +78,10 @@ type Tool struct {
 	InputSchema ToolInputSchema `json:"inputSchema"`
 	// Alternative to InputSchema - allows arbitrary JSON Schema to be provided
 	RawInputSchema json.RawMessage `json:"-"` // Hide this from JSON marshaling
+	// An optional JSON Schema object defining the structure of the tool's structuredContent.
+	OutputSchema *ToolOutputSchema `json:"outputSchema,omitempty"`
+	// Optional hints describing tool behavior, clients must treat them as untrusted.
+	Annotations *ToolAnnotation `json:"annotations,omitempty"`
 }
 
 // MarshalJSON implements the json.Marshaler interface for Tool.
@@ -100,6 +107,14 @@ func (t Tool) MarshalJSON() ([]byte, error) {
 		m["inputSchema"] = t.InputSchema
 	}
 
+	if t.OutputSchema != nil {
+		m["outputSchema"] = t.OutputSchema
+	}
+
+	if t.Annotations != nil {
+		m["annotations"] = t.Annotations
+	}
+
 	return json.Marshal(m)
 }
 
@@ -109,6 +124,28 @@ type ToolInputSchema struct {
 	Required   []string               `json:"required,omitempty"`
 }
 
+// ToolOutputSchema describes structuredContent of a tool result, its type is always "object".
+type ToolOutputSchema struct {
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+	Required   []string               `json:"required,omitempty"`
+}
+
+// ToolAnnotation holds hints about side effects of a tool. Unset hints take defaults of the protocol:
+// the tool is assumed to be a destructive, non-idempotent write that interacts with an open world.
+type ToolAnnotation struct {
+	// A human-readable title for the tool.
+	Title string `json:"title,omitempty"`
+	// If true, the tool does not modify its environment.
+	ReadOnlyHint *bool `json:"readOnlyHint,omitempty"`
+	// If true, the tool may perform destructive updates, meaningful only when ReadOnlyHint is false.
+	DestructiveHint *bool `json:"destructiveHint,omitempty"`
+	// If true, calling the tool repeatedly with the same arguments has no additional effect.
+	IdempotentHint *bool `json:"idempotentHint,omitempty"`
+	// If true, the tool may interact with external entities beyond a closed domain.
+	OpenWorldHint *bool `json:"openWorldHint,omitempty"`
+}
+
 // ToolOption is a function that configures a Tool.
 // It provides a flexible way to set various properties of a Tool using the functional options pattern.
 type ToolOption func(*Tool)
@@ -166,6 +203,24 @@ func WithDescription(description string) ToolOption {
 	}
 }
 
+// WithOutputSchema sets the schema of structuredContent returned by the Tool.
+func WithOutputSchema(properties map[string]interface{}, required ...string) ToolOption {
+	return func(t *Tool) {
+		t.OutputSchema = &ToolOutputSchema{
+			Type:       "object",
+			Properties: properties,
+			Required:   required,
+		}
+	}
+}
+
+// WithToolAnnotation sets behavior hints of the Tool.
+func WithToolAnnotation(annotation ToolAnnotation) ToolOption {
+	return func(t *Tool) {
+		t.Annotations = &annotation
+	}
+}
+
 //
 // Common Property Options
 //
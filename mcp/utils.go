@@ -505,6 +505,10 @@ func ParseCallToolResult(rawMessage *json.RawMessage) (*CallToolResult, error) {
 		}
 	}
 
+	if structured, ok := jsonContent["structuredContent"].(map[string]any); ok {
+		result.StructuredContent = structured
+	}
+
 	contents, ok := jsonContent["content"]
 	if !ok {
 		return nil, fmt.Errorf("content is missing")
@@ -0,0 +1,82 @@
+package mcp
+
+import "time"
+
+// Revisions of the MCP specification supported by the server.
+const (
+	PROTOCOL_VERSION_2024_11_05 = "2024-11-05"
+	PROTOCOL_VERSION_2025_03_26 = "2025-03-26"
+	PROTOCOL_VERSION_2025_06_18 = "2025-06-18"
+)
+
+// SupportedProtocolVersions lists supported revisions from the newest one.
+var SupportedProtocolVersions = []string{
+	PROTOCOL_VERSION_2025_06_18,
+	PROTOCOL_VERSION_2025_03_26,
+	PROTOCOL_VERSION_2024_11_05,
+}
+
+// ProtocolFeature is a part of the protocol that only exists since some revision.
+type ProtocolFeature string
+
+const (
+	// FeatureAuthorization is the OAuth 2.1 authorization of HTTP transports.
+	FeatureAuthorization ProtocolFeature = "authorization"
+	// FeatureToolAnnotations are behaviour hints of tools, such as readOnlyHint.
+	FeatureToolAnnotations ProtocolFeature = "tool_annotations"
+	// FeatureCompletions is the completions server capability.
+	FeatureCompletions ProtocolFeature = "completions"
+	// FeatureStructuredOutput is outputSchema of tools and structuredContent of their results.
+	FeatureStructuredOutput ProtocolFeature = "structured_output"
+	// FeatureElicitation lets the server ask the user for input during a request.
+	FeatureElicitation ProtocolFeature = "elicitation"
+)
+
+var featureVersions = map[ProtocolFeature]string{
+	FeatureAuthorization:    PROTOCOL_VERSION_2025_03_26,
+	FeatureToolAnnotations:  PROTOCOL_VERSION_2025_03_26,
+	FeatureCompletions:      PROTOCOL_VERSION_2025_03_26,
+	FeatureStructuredOutput: PROTOCOL_VERSION_2025_06_18,
+	FeatureElicitation:      PROTOCOL_VERSION_2025_06_18,
+}
+
+// NegotiateProtocolVersion picks the revision to use with a client: the requested one if it's supported,
+// otherwise the latest one, which the client may reject by disconnecting.
+func NegotiateProtocolVersion(requested string) string {
+	for _, version := range SupportedProtocolVersions {
+		if version == requested {
+			return version
+		}
+	}
+	return LATEST_PROTOCOL_VERSION
+}
+
+// NearestProtocolVersion maps a revision to a supported one: the newest supported revision that is not newer,
+// the oldest one for earlier dates and the latest one for a version that is not a date.
+func NearestProtocolVersion(version string) string {
+	v, err := time.Parse(time.DateOnly, version)
+	if err != nil {
+		return LATEST_PROTOCOL_VERSION
+	}
+	for _, supported := range SupportedProtocolVersions {
+		if s, _ := time.Parse(time.DateOnly, supported); !v.Before(s) {
+			return supported
+		}
+	}
+	return SupportedProtocolVersions[len(SupportedProtocolVersions)-1]
+}
+
+// ProtocolSupports reports whether a revision of the protocol has the feature. Revisions are dates,
+// so they are compared as dates, a version that is not a date has no optional features.
+func ProtocolSupports(version string, feature ProtocolFeature) bool {
+	since, ok := featureVersions[feature]
+	if !ok {
+		return false
+	}
+	v, err := time.Parse(time.DateOnly, version)
+	if err != nil {
+		return false
+	}
+	s, _ := time.Parse(time.DateOnly, since)
+	return !v.Before(s)
+}
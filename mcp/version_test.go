@@ -0,0 +1,33 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateProtocolVersion(t *testing.T) {
+	assert.Equal(t, PROTOCOL_VERSION_2024_11_05, NegotiateProtocolVersion("2024-11-05"))
+	assert.Equal(t, PROTOCOL_VERSION_2025_03_26, NegotiateProtocolVersion("2025-03-26"))
+	assert.Equal(t, LATEST_PROTOCOL_VERSION, NegotiateProtocolVersion("2099-01-01"))
+	assert.Equal(t, LATEST_PROTOCOL_VERSION, NegotiateProtocolVersion(""))
+}
+
+func TestNearestProtocolVersion(t *testing.T) {
+	assert.Equal(t, PROTOCOL_VERSION_2025_03_26, NearestProtocolVersion("2025-03-26"))
+	assert.Equal(t, PROTOCOL_VERSION_2025_03_26, NearestProtocolVersion("2025-05-01"))
+	assert.Equal(t, PROTOCOL_VERSION_2025_06_18, NearestProtocolVersion("2099-01-01"))
+	assert.Equal(t, PROTOCOL_VERSION_2024_11_05, NearestProtocolVersion("2024-01-01"))
+	assert.Equal(t, LATEST_PROTOCOL_VERSION, NearestProtocolVersion("latest"))
+}
+
+func TestProtocolSupports(t *testing.T) {
+	assert.False(t, ProtocolSupports("2024-11-05", FeatureToolAnnotations))
+	assert.True(t, ProtocolSupports("2025-03-26", FeatureToolAnnotations))
+	assert.False(t, ProtocolSupports("2025-03-26", FeatureStructuredOutput))
+	assert.True(t, ProtocolSupports("2025-06-18", FeatureElicitation))
+	assert.True(t, ProtocolSupports("2099-01-01", FeatureAuthorization))
+	// not a date, e.g. a typo in a config
+	assert.False(t, ProtocolSupports("2025-3-26", FeatureAuthorization))
+	assert.False(t, ProtocolSupports("", FeatureAuthorization))
+}
@@ -1,44 +1,85 @@
 package mcpgenerator
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"github.com/centralmind/gateway/connectors"
+	gerrors "github.com/centralmind/gateway/errors"
+	"github.com/centralmind/gateway/mcp"
 	"github.com/centralmind/gateway/model"
 	"github.com/centralmind/gateway/plugins"
 	"github.com/centralmind/gateway/server"
+	"github.com/centralmind/gateway/xcontext"
 	"golang.org/x/xerrors"
+	"slices"
 	"sync"
+	"time"
 )
 
+// batchConcurrency is how many tool calls of a JSON-RPC batch query the database at the same time
+const batchConcurrency = 4
+
 type MCPServer struct {
 	server       *server.MCPServer
 	connector    connectors.Connector
 	tools        []model.Endpoint
 	interceptors []plugins.Interceptor
+	// groups of tools by their names, clients may select the groups they are offered
+	groups map[string]string
+	// format of tool results, unless an endpoint or a call sets its own
+	format ResultFormat
 
 	mu    sync.Mutex
 	plugs map[string]any
+
+	prompts    []model.Prompt
+	completion completionState
+
+	ask askState
+	// raw is set once the raw protocol exposes the whole database
+	raw bool
+
+	// tableResources are discovered tables once resources are enabled, only some of them may be exposed
+	tableResources []tableResource
+
+	// listener is the connector before plugins wrap it, if it can push change events
+	listener connectors.ChangeListener
+	watch    watchState
 }
 
 func New(
 	plugs map[string]any,
 ) (*MCPServer, error) {
-	srv := server.NewMCPServer("mcp-data-gateway", "0.0.1")
+	srv := server.NewMCPServer(
+		"mcp-data-gateway",
+		"0.0.1",
+		server.WithResourceCapabilities(true, true),
+		server.WithPromptCapabilities(true),
+		server.WithLogging(),
+		server.WithBatchConcurrency(batchConcurrency),
+	)
 	interceptors, err := plugins.Plugins[plugins.Interceptor](plugs)
 	if err != nil {
 		return nil, xerrors.Errorf("unable to init interceptors: %w", err)
 	}
-	return &MCPServer{
+	s := &MCPServer{
 		server:       srv,
 		connector:    nil,
 		plugs:        plugs,
 		interceptors: interceptors,
-	}, nil
+		groups:       map[string]string{},
+	}
+	srv.SetCompletionHandler(s.complete)
+	srv.SetResourceSubscriptionHandler(s.watchResource)
+	srv.AddToolFilter(s.selectedGroups)
+	return s, nil
 }
 
 func (s *MCPServer) SetConnector(connector connectors.Connector) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.listener, _ = connector.(connectors.ChangeListener)
 	var err error
 	connector, err = plugins.Wrap(s.plugs, connector)
 	if err != nil {
@@ -60,6 +101,77 @@ func (s *MCPServer) Server() *server.MCPServer {
 	return s.server
 }
 
+// selectedGroups hides tools of groups the client didn't select, clients that select nothing are offered all tools
+func (s *MCPServer) selectedGroups(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	selected := xcontext.ToolGroups(ctx)
+	if selected == nil {
+		return tools
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.DeleteFunc(tools, func(tool mcp.Tool) bool {
+		return !slices.Contains(selected, s.groups[tool.Name])
+	})
+}
+
+// run queries the connector and applies interceptors, source names what asked for the query in logs of the MCP client.
+func (s *MCPServer) run(ctx context.Context, source string, endpoint model.Endpoint, params map[string]any) ([]map[string]any, error) {
+	logEvent(ctx, mcp.LoggingLevelDebug, "executing query", map[string]any{
+		"source": source,
+		"query":  endpoint.Query,
+		"params": params,
+	})
+	start := time.Now()
+	rows, err := s.connector.Query(ctx, endpoint, params)
+	if err != nil {
+		if errors.Is(err, gerrors.ErrNotAuthorized) {
+			logEvent(ctx, mcp.LoggingLevelWarning, "authorization failed", map[string]any{"source": source, "error": err.Error()})
+		} else {
+			logEvent(ctx, mcp.LoggingLevelError, "query failed", map[string]any{"source": source, "error": err.Error()})
+		}
+		return nil, err
+	}
+	res := s.intercept(ctx, rows)
+	logEvent(ctx, mcp.LoggingLevelInfo, "query completed", map[string]any{
+		"source":      source,
+		"rows":        len(res),
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+	return res, nil
+}
+
+// intercept applies interceptor plugins to query results, rows skipped by an interceptor are dropped.
+func (s *MCPServer) intercept(ctx context.Context, rows []map[string]any) []map[string]any {
+	var res []map[string]any
+	defer func() {
+		if dropped := len(rows) - len(res); dropped > 0 {
+			logEvent(ctx, mcp.LoggingLevelInfo, "rows dropped by interceptors", map[string]any{"dropped": dropped})
+		}
+	}()
+MAIN:
+	for _, row := range rows {
+		for _, interceptor := range s.interceptors {
+			r, skip := interceptor.Process(row, xcontext.Headers(ctx))
+			if skip {
+				continue MAIN
+			}
+			row = r
+		}
+		res = append(res, row)
+	}
+	return res
+}
+
+// logEvent sends a gateway event to the MCP client of the request, if it enabled logging at the level.
+func logEvent(ctx context.Context, level mcp.LoggingLevel, message string, fields map[string]any) {
+	data := make(map[string]any, len(fields)+1)
+	for k, v := range fields {
+		data[k] = v
+	}
+	data["message"] = message
+	server.Log(ctx, level, "gateway", data)
+}
+
 func jsonify(data any) string {
 	res, _ := json.Marshal(data)
 	return string(res)
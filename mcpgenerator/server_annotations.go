@@ -0,0 +1,134 @@
+package mcpgenerator
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/model"
+)
+
+// statementKind orders statements by the harm they can do, a query with several statements takes the worst one.
+type statementKind int
+
+const (
+	statementUnknown statementKind = iota
+	statementRead
+	statementInsert
+	statementUpdate
+	statementDelete
+)
+
+// classifyStatement tells what an endpoint query does to the data. Queries that are not SQL, e.g. for mongodb,
+// are unknown, and their hints are taken from the HTTP method.
+func classifyStatement(query string) statementKind {
+	fields := strings.Fields(strings.ToUpper(query))
+	if len(fields) == 0 {
+		return statementUnknown
+	}
+	var kind statementKind
+	switch strings.Trim(fields[0], "(") {
+	case "SELECT", "WITH", "SHOW", "DESCRIBE", "EXPLAIN", "VALUES":
+		kind = statementRead
+	case "INSERT", "UPDATE", "MERGE", "UPSERT", "REPLACE", "DELETE", "DROP", "TRUNCATE", "ALTER", "CREATE", "GRANT", "REVOKE":
+		kind = statementInsert
+	default:
+		return statementUnknown
+	}
+	for i, field := range fields {
+		switch strings.Trim(field, "(),;") {
+		case "INSERT", "CREATE":
+			kind = max(kind, statementInsert)
+		case "UPDATE":
+			// SELECT ... FOR UPDATE only locks rows
+			if i == 0 || fields[i-1] != "FOR" {
+				kind = max(kind, statementUpdate)
+			}
+		case "MERGE", "UPSERT", "REPLACE":
+			kind = max(kind, statementUpdate)
+		case "DELETE", "DROP", "TRUNCATE", "ALTER", "GRANT", "REVOKE":
+			kind = max(kind, statementDelete)
+		}
+	}
+	return kind
+}
+
+// ReadsOnly reports whether the query of an endpoint only reads data, falling back to the HTTP method for queries
+// that are not SQL. Config overrides of tool hints are ignored, e.g. discover runs only such endpoints as samples.
+func ReadsOnly(endpoint model.Endpoint) bool {
+	readOnly, _, _ := inferHints(endpoint)
+	return readOnly
+}
+
+// inferHints infers tool hints of an endpoint from its query, falling back to the HTTP method.
+func inferHints(endpoint model.Endpoint) (readOnly, destructive, idempotent bool) {
+	switch classifyStatement(endpoint.Query) {
+	case statementRead:
+		readOnly, destructive, idempotent = true, false, true
+	case statementInsert:
+		readOnly, destructive, idempotent = false, false, false
+	case statementUpdate:
+		readOnly, destructive, idempotent = false, true, false
+	case statementDelete:
+		readOnly, destructive, idempotent = false, true, true
+	default:
+		switch strings.ToUpper(endpoint.HTTPMethod) {
+		case "", http.MethodGet, http.MethodHead:
+			readOnly, destructive, idempotent = true, false, true
+		case http.MethodPost:
+			readOnly, destructive, idempotent = false, false, false
+		case http.MethodPut, http.MethodDelete:
+			readOnly, destructive, idempotent = false, true, true
+		default:
+			readOnly, destructive, idempotent = false, true, false
+		}
+	}
+	return readOnly, destructive, idempotent
+}
+
+// endpointAnnotation infers tool hints of an endpoint from its query, falling back to the HTTP method.
+// A read-only connector can't change data whatever the query is. Overrides from the config are applied last.
+func (s *MCPServer) endpointAnnotation(endpoint model.Endpoint) mcp.ToolAnnotation {
+	readOnly, destructive, idempotent := inferHints(endpoint)
+	if s.connector != nil && s.connector.Config().Readonly() {
+		readOnly, destructive, idempotent = true, false, true
+	}
+	// endpoints only reach the configured database
+	openWorld := false
+
+	if o := endpoint.Annotations; o != nil {
+		if o.ReadOnly != nil {
+			readOnly = *o.ReadOnly
+		}
+		if o.Destructive != nil {
+			destructive = *o.Destructive
+		}
+		if o.Idempotent != nil {
+			idempotent = *o.Idempotent
+		}
+		if o.OpenWorld != nil {
+			openWorld = *o.OpenWorld
+		}
+	}
+	return mcp.ToolAnnotation{
+		Title:           endpoint.Summary,
+		ReadOnlyHint:    &readOnly,
+		DestructiveHint: &destructive,
+		IdempotentHint:  &idempotent,
+		OpenWorldHint:   &openWorld,
+	}
+}
+
+// readOnlyAnnotation describes tools that only read the database, such as discovery tools of the raw protocol.
+func readOnlyAnnotation() mcp.ToolAnnotation {
+	return mcp.ToolAnnotation{
+		ReadOnlyHint:    hint(true),
+		DestructiveHint: hint(false),
+		IdempotentHint:  hint(true),
+		OpenWorldHint:   hint(false),
+	}
+}
+
+func hint(value bool) *bool {
+	return &value
+}
@@ -0,0 +1,61 @@
+package mcpgenerator
+
+import (
+	"testing"
+
+	"github.com/centralmind/gateway/connectors/connectortest"
+	"github.com/centralmind/gateway/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyStatement(t *testing.T) {
+	for query, expected := range map[string]statementKind{
+		"SELECT * FROM users WHERE id = :id":                     statementRead,
+		"WITH t AS (SELECT 1) SELECT * FROM t":                   statementRead,
+		"SELECT * FROM users WHERE id = :id FOR UPDATE":          statementRead,
+		"INSERT INTO users (name) VALUES (:name)":                statementInsert,
+		"UPDATE users SET name = :name WHERE id = :id":           statementUpdate,
+		"DELETE FROM users WHERE id = :id":                       statementDelete,
+		"WITH gone AS (DELETE FROM users RETURNING *) SELECT 1":  statementDelete,
+		"INSERT INTO audit VALUES (1); DELETE FROM users":        statementDelete,
+		`{"collection": "users", "filter": {"id": "{{ .id }}"}}`: statementUnknown,
+		"": statementUnknown,
+	} {
+		assert.Equal(t, expected, classifyStatement(query), query)
+	}
+}
+
+func TestEndpointAnnotation(t *testing.T) {
+	s := &MCPServer{}
+
+	read := s.endpointAnnotation(model.Endpoint{Summary: "Get user", Query: "SELECT * FROM users"})
+	assert.Equal(t, "Get user", read.Title)
+	assert.True(t, *read.ReadOnlyHint)
+	assert.False(t, *read.DestructiveHint)
+	assert.True(t, *read.IdempotentHint)
+	assert.False(t, *read.OpenWorldHint)
+
+	del := s.endpointAnnotation(model.Endpoint{HTTPMethod: "DELETE", Query: "DELETE FROM users WHERE id = :id"})
+	assert.False(t, *del.ReadOnlyHint)
+	assert.True(t, *del.DestructiveHint)
+	assert.True(t, *del.IdempotentHint)
+
+	post := s.endpointAnnotation(model.Endpoint{HTTPMethod: "POST", Query: `{"collection": "users"}`})
+	assert.False(t, *post.ReadOnlyHint)
+	assert.False(t, *post.DestructiveHint)
+	assert.False(t, *post.IdempotentHint)
+
+	overridden := s.endpointAnnotation(model.Endpoint{
+		Query:       "DELETE FROM sessions WHERE expires_at < now()",
+		Annotations: &model.EndpointAnnotations{Destructive: hint(false), OpenWorld: hint(true)},
+	})
+	assert.False(t, *overridden.ReadOnlyHint)
+	assert.False(t, *overridden.DestructiveHint)
+	assert.True(t, *overridden.OpenWorldHint)
+
+	// the test connector is read-only, so even a delete can't change data
+	s.connector = &connectortest.Connector{}
+	del = s.endpointAnnotation(model.Endpoint{Query: "DELETE FROM users"})
+	assert.True(t, *del.ReadOnlyHint)
+	assert.False(t, *del.DestructiveHint)
+}
@@ -0,0 +1,375 @@
+package mcpgenerator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/prompter"
+	"github.com/centralmind/gateway/providers"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// askCatalogTTL is how long the table catalog used by ask is cached before it is discovered again.
+	askCatalogTTL = 10 * time.Minute
+	// askRepairRounds is how many times the model may fix a query the database rejected.
+	askRepairRounds = 2
+	askMaxRows      = 100
+)
+
+var (
+	ErrAskNotReadOnly = errors.New("only a single read-only SELECT query is allowed")
+	// ErrAskReadWrite is returned by EnableAsk for a connection that can write, a generated query is only safe
+	// in a read-only transaction: SELECT ... INTO or a function with side effects passes any query check.
+	ErrAskReadWrite = errors.New("the ask tool requires a read-only database connection")
+	// ErrAskNotSQL is returned by EnableAsk for connectors that don't run SQL, the generated query can't be wrapped there.
+	ErrAskNotSQL = errors.New("the ask tool requires a SQL database")
+	// ErrAskTableNotExposed rejects a query that reads a table no configured endpoint exposes, outside raw mode.
+	ErrAskTableNotExposed = errors.New("the query uses a table that is not exposed")
+)
+
+type askState struct {
+	provider providers.ModelProvider
+	modelId  string
+
+	mu       sync.Mutex
+	catalog  []prompter.TableData
+	loadedAt time.Time
+}
+
+type askAnswer struct {
+	SQL         string `json:"sql"`
+	Explanation string `json:"explanation"`
+}
+
+// EnableAsk adds the ask tool: it answers questions in natural language by letting the model write SQL,
+// which is verified with InferQuery and executed through the connector with all plugins and interceptors.
+// The connector must be read-only, so the database itself rejects any write.
+func (s *MCPServer) EnableAsk(provider providers.ModelProvider, modelId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !runsSQL(s.connector.Config().Type()) {
+		return ErrAskNotSQL
+	}
+	if !s.connector.Config().Readonly() {
+		return ErrAskReadWrite
+	}
+	s.ask.provider = provider
+	s.ask.modelId = modelId
+	s.server.DeleteTools("ask")
+	s.groups["ask"] = "ask"
+	s.server.AddTool(mcp.NewTool(
+		"ask",
+		mcp.WithDescription(fmt.Sprintf(`Answer a question about data in %s database.
+The question is turned into a SQL query, the tool returns the query it ran together with resulting rows.
+Use it when no other tool fits the question.
+`, s.connector.Config().Type())),
+		mcp.WithString("question", mcp.Required(), mcp.Description("Question in natural language, e.g. 'How many orders were placed last week?'")),
+		formatOption(),
+		// only SELECT queries are run, but the generated query may differ between calls
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			ReadOnlyHint:    hint(true),
+			DestructiveHint: hint(false),
+			IdempotentHint:  hint(false),
+			OpenWorldHint:   hint(false),
+		}),
+	), s.askQuestion)
+	return nil
+}
+
+func (s *MCPServer) askQuestion(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	question, _ := request.Params.Arguments["question"].(string)
+	if strings.TrimSpace(question) == "" {
+		return errorResult("question is required"), nil
+	}
+	format, err := s.resultFormat(request.Params.Arguments, "", FormatYAML)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, hidden, err := s.schemaCatalog(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to discover tables: %w", err)
+	}
+
+	answer, err := s.writeQuery(ctx, question, tables, hidden)
+	if err != nil {
+		var queryErr *askQueryError
+		if errors.As(err, &queryErr) {
+			return errorResult(fmt.Sprintf("Unable to write a valid query: %s\n\nLast attempt:\n%s", queryErr.Err, queryErr.SQL)), nil
+		}
+		return nil, err
+	}
+
+	// one more row than shown tells whether the result was cut
+	query := limitQuery(s.connector.Config().Type(), answer.SQL, askMaxRows+1)
+	res, err := s.run(ctx, "ask", model.Endpoint{Query: query}, make(map[string]any))
+	if err != nil {
+		return errorResult(fmt.Sprintf("Unable to query: %s\n\nSQL:\n%s", err, answer.SQL)), nil
+	}
+	found := fmt.Sprintf("Found %v records-(s).", len(res))
+	if len(res) > askMaxRows {
+		res = res[:askMaxRows]
+		found = fmt.Sprintf("Found more than %d records, showing the first %d.", askMaxRows, askMaxRows)
+	}
+
+	var content []mcp.Content
+	content = append(content, mcp.TextContent{
+		Type: "text",
+		Text: fmt.Sprintf("SQL:\n%s\n\n%s", answer.SQL, answer.Explanation),
+	})
+	content = append(content, mcp.TextContent{
+		Type: "text",
+		Text: found,
+	})
+	rows, meta := formatRows(format, nil, res)
+	meta["rows"] = len(res)
+	return &mcp.CallToolResult{
+		Result:  mcp.Result{Meta: meta},
+		Content: append(content, rows...),
+	}, nil
+}
+
+// askQueryError is returned when the model could not write a query the database accepts.
+type askQueryError struct {
+	SQL string
+	Err error
+}
+
+func (e *askQueryError) Error() string {
+	return fmt.Sprintf("invalid query %q: %v", e.SQL, e.Err)
+}
+
+func (e *askQueryError) Unwrap() error {
+	return e.Err
+}
+
+// writeQuery asks the model for a query and lets it fix queries rejected by the database or using hidden tables.
+func (s *MCPServer) writeQuery(ctx context.Context, question string, tables []prompter.TableData, hidden []hiddenTable) (askAnswer, error) {
+	prompt := prompter.AskPrompt(s.connector, question, tables, prompter.SchemaFromConfig(s.connector.Config()), askMaxRows)
+	req := &providers.ConversationRequest{
+		ModelId:      s.ask.modelId,
+		JsonResponse: true,
+		ResponseSchema: &providers.ResponseSchema{
+			Name:        "sql_query",
+			Description: "SQL query that answers the question",
+			Schema:      prompter.AskSchema(),
+		},
+		System: "You must always respond in pure JSON. No markdown, no comments, no explanations.",
+		Messages: []providers.Message{
+			{Role: providers.UserRole, Content: []providers.ContentBlock{&providers.ContentBlockText{Value: prompt}}},
+		},
+	}
+
+	var answer askAnswer
+	var lastErr error
+	for round := 0; round <= askRepairRounds; round++ {
+		resp, err := s.ask.provider.Chat(ctx, req)
+		if err != nil {
+			return answer, xerrors.Errorf("unable to call %s: %w", s.ask.provider.GetName(), err)
+		}
+		var text strings.Builder
+		for _, block := range resp.Content {
+			if t, ok := block.(*providers.ContentBlockText); ok {
+				text.WriteString(t.Value)
+			}
+		}
+
+		answer = askAnswer{}
+		if err := json.Unmarshal([]byte(providers.ExtractJSON(text.String())), &answer); err != nil {
+			lastErr = xerrors.Errorf("unable to parse answer: %w", err)
+		} else {
+			answer.SQL = strings.TrimSuffix(strings.TrimSpace(answer.SQL), ";")
+			lastErr = s.verifyQuery(ctx, answer.SQL, hidden)
+		}
+		if lastErr == nil {
+			return answer, nil
+		}
+
+		req.Messages = append(req.Messages,
+			providers.Message{Role: providers.AssistantRole, Content: resp.Content},
+			providers.Message{Role: providers.UserRole, Content: []providers.ContentBlock{&providers.ContentBlockText{
+				Value: fmt.Sprintf("The query is invalid: %s\nFix it and answer with the same JSON schema.", lastErr),
+			}}},
+		)
+	}
+	return answer, &askQueryError{SQL: answer.SQL, Err: lastErr}
+}
+
+// verifyQuery accepts a single read-only query that the database can run and that doesn't use hidden tables.
+func (s *MCPServer) verifyQuery(ctx context.Context, query string, hidden []hiddenTable) error {
+	if !isReadOnlyQuery(query) {
+		return ErrAskNotReadOnly
+	}
+	for _, table := range hidden {
+		if table.usedBy(query) {
+			return xerrors.Errorf("%w: %s, use only the listed tables", ErrAskTableNotExposed, table.name)
+		}
+	}
+	if _, err := s.connector.InferQuery(ctx, query); err != nil {
+		return err
+	}
+	return nil
+}
+
+// isReadOnlyQuery accepts a single SELECT statement, optionally starting with CTEs.
+// It only rejects obvious writes and row locks early with a clear error, the read-only connection enforces the rest.
+func isReadOnlyQuery(query string) bool {
+	query = strings.TrimSpace(query)
+	if query == "" || strings.Contains(query, ";") {
+		return false
+	}
+	fields := strings.Fields(strings.ToUpper(query))
+	if fields[0] != "SELECT" && fields[0] != "WITH" {
+		return false
+	}
+	for i, field := range fields {
+		switch strings.Trim(field, "(),") {
+		case "INSERT", "UPDATE", "DELETE", "MERGE", "DROP", "ALTER", "CREATE", "TRUNCATE", "GRANT", "REVOKE", "INTO":
+			return false
+		case "FOR":
+			// SELECT ... FOR UPDATE / FOR SHARE / FOR KEY SHARE lock rows
+			if i+1 < len(fields) && (fields[i+1] == "SHARE" || fields[i+1] == "KEY") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// limitQuery caps the number of rows a query returns in the database.
+func limitQuery(typ, query string, limit int) string {
+	switch typ {
+	case "mssql":
+		return fmt.Sprintf("SELECT TOP %d * FROM (%s) ask_result", limit, query)
+	case "oracle":
+		return fmt.Sprintf("SELECT * FROM (%s) ask_result FETCH FIRST %d ROWS ONLY", query, limit)
+	default:
+		return fmt.Sprintf("SELECT * FROM (%s) AS ask_result LIMIT %d", query, limit)
+	}
+}
+
+// schemaCatalog returns tables and columns the ask tool may use, without data samples, and tables that generated
+// queries must not use. Unless the raw protocol exposes the whole database, only tables queried by configured
+// endpoints are included.
+func (s *MCPServer) schemaCatalog(ctx context.Context) ([]prompter.TableData, []hiddenTable, error) {
+	catalog, err := s.databaseCatalog(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.mu.Lock()
+	raw, endpoints := s.raw, s.tools
+	s.mu.Unlock()
+	if raw {
+		return catalog, nil, nil
+	}
+	var res []prompter.TableData
+	var hidden []hiddenTable
+	for _, table := range catalog {
+		exposed := false
+		for _, endpoint := range endpoints {
+			if mentionsTable(endpoint.Query, table.Name) {
+				exposed = true
+				break
+			}
+		}
+		if exposed {
+			res = append(res, table)
+		} else {
+			hidden = append(hidden, hiddenTable{name: table.Name})
+		}
+	}
+	if len(res) == 0 {
+		return nil, nil, errors.New("no tables are exposed by configured endpoints")
+	}
+	for i, table := range hidden {
+		for _, exposed := range res {
+			if strings.EqualFold(tableName(table.name), tableName(exposed.Name)) {
+				hidden[i].qualified = true
+				break
+			}
+		}
+	}
+	return res, hidden, nil
+}
+
+// hiddenTable is a table that no configured endpoint exposes. A hidden table with the name of an exposed table
+// in another schema is told apart only by its schema qualified name.
+type hiddenTable struct {
+	name      string
+	qualified bool
+}
+
+func (t hiddenTable) usedBy(query string) bool {
+	if !t.qualified {
+		return mentionsTable(query, t.name)
+	}
+	parts := identifierParts(t.name)
+	if len(parts) < 2 {
+		return false
+	}
+	// schema and name may be quoted separately, e.g. "sales"."orders" or [sales].[orders]
+	quote := "[\"`\\[\\]]?"
+	pattern := regexp.QuoteMeta(parts[len(parts)-2]) + quote + `\s*\.\s*` + quote + regexp.QuoteMeta(parts[len(parts)-1])
+	return regexp.MustCompile(`(?i)(^|[^\w$])` + pattern + `($|[^\w$])`).MatchString(query)
+}
+
+// tableName strips the schema prefix and quotes of a table name.
+func tableName(table string) string {
+	parts := identifierParts(table)
+	return parts[len(parts)-1]
+}
+
+// mentionsTable reports whether the query refers to the table by its name as a whole identifier.
+// Schema prefix of the table name is ignored.
+func mentionsTable(query, table string) bool {
+	name := tableName(table)
+	if name == "" {
+		return false
+	}
+	return regexp.MustCompile(`(?i)(^|[^\w$])` + regexp.QuoteMeta(name) + `($|[^\w$])`).MatchString(query)
+}
+
+// databaseCatalog returns tables and columns of the database, without data samples, cached for askCatalogTTL.
+func (s *MCPServer) databaseCatalog(ctx context.Context) ([]prompter.TableData, error) {
+	s.ask.mu.Lock()
+	defer s.ask.mu.Unlock()
+	if s.ask.catalog != nil && time.Since(s.ask.loadedAt) < askCatalogTTL {
+		return s.ask.catalog, nil
+	}
+	tables, err := s.connector.Discovery(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	catalog := make([]prompter.TableData, 0, len(tables))
+	for _, table := range tables {
+		catalog = append(catalog, prompter.TableData{
+			Columns:  table.Columns,
+			Name:     table.Name,
+			RowCount: table.RowCount,
+		})
+	}
+	s.ask.catalog = catalog
+	s.ask.loadedAt = time.Now()
+	return catalog, nil
+}
+
+func errorResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: text,
+			},
+		},
+		IsError: true,
+	}
+}
@@ -0,0 +1,224 @@
+package mcpgenerator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/centralmind/gateway/connectors/connectortest"
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// askProvider answers with the given SQL queries in order, the last one is repeated.
+type askProvider struct {
+	answers  []string
+	requests []*providers.ConversationRequest
+}
+
+func (p *askProvider) GetName() string { return "Fake" }
+
+func (p *askProvider) CostEstimate(modelId string, usage providers.ModelUsage) float64 { return 0 }
+
+func (p *askProvider) Chat(ctx context.Context, req *providers.ConversationRequest) (*providers.ConversationResponse, error) {
+	p.requests = append(p.requests, req)
+	answer := p.answers[0]
+	if len(p.answers) > 1 {
+		p.answers = p.answers[1:]
+	}
+	return &providers.ConversationResponse{
+		Content:    []providers.ContentBlock{&providers.ContentBlockText{Value: answer}},
+		StopReason: providers.StopReasonStop,
+		Usage:      &providers.ModelUsage{},
+	}, nil
+}
+
+func (p *askProvider) ChatStream(ctx context.Context, req *providers.ConversationRequest) (providers.ChatStreamOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newAskServer(t *testing.T, provider providers.ModelProvider) (*MCPServer, *connectortest.Connector) {
+	srv, err := New(nil)
+	require.NoError(t, err)
+	connector := &connectortest.Connector{Tables: []model.Table{
+		{Name: "users", Columns: []model.ColumnSchema{{Name: "id", Type: model.TypeInteger, PrimaryKey: true}}},
+		{Name: "secrets", Columns: []model.ColumnSchema{{Name: "token", Type: model.TypeString}}},
+	}}
+	require.NoError(t, srv.SetConnector(connector))
+	srv.SetTools([]model.Endpoint{{MCPMethod: "list_users", Query: `SELECT * FROM "users"`}})
+	require.NoError(t, srv.EnableAsk(provider, "test-model"))
+	return srv, connector
+}
+
+func callAsk(t *testing.T, srv *MCPServer, question string) *mcp.CallToolResult {
+	var request mcp.CallToolRequest
+	request.Params.Name = "ask"
+	request.Params.Arguments = map[string]any{"question": question}
+	res, err := srv.askQuestion(context.Background(), request)
+	require.NoError(t, err)
+	return res
+}
+
+func TestAskRepairsQuery(t *testing.T) {
+	provider := &askProvider{answers: []string{
+		`{"sql": "SELECT missing FROM users", "explanation": "first try"}`,
+		`{"sql": "SELECT count(*) AS count FROM \"users\";", "explanation": "counts all users"}`,
+	}}
+	srv, connector := newAskServer(t, provider)
+
+	res := callAsk(t, srv, "How many users do we have?")
+	require.False(t, res.IsError)
+	assert.Contains(t, res.Content[0].(mcp.TextContent).Text, `SELECT count(*) AS count FROM "users"`)
+	assert.Contains(t, res.Content[0].(mcp.TextContent).Text, "counts all users")
+	assert.Equal(t, "Found 1 records-(s).", res.Content[1].(mcp.TextContent).Text)
+	assert.Equal(t, []string{`SELECT * FROM (SELECT count(*) AS count FROM "users") AS ask_result LIMIT 101`}, connector.Queries)
+
+	require.Len(t, provider.requests, 2)
+	assert.Equal(t, "test-model", provider.requests[0].ModelId)
+	assert.NotNil(t, provider.requests[0].ResponseSchema)
+	prompt := provider.requests[0].Messages[0].Content[0].(*providers.ContentBlockText).Value
+	assert.Contains(t, prompt, "How many users do we have?")
+	assert.Contains(t, prompt, "<users")
+	assert.NotContains(t, prompt, "secrets", "only tables of configured endpoints are offered outside raw mode")
+	repair := provider.requests[1].Messages[2].Content[0].(*providers.ContentBlockText).Value
+	assert.Contains(t, repair, `column "missing" does not exist`)
+
+	callAsk(t, srv, "How many users do we have?")
+	assert.Equal(t, 1, connector.Discoveries, "catalog must be cached")
+}
+
+func TestAskRejectsWrites(t *testing.T) {
+	provider := &askProvider{answers: []string{
+		`{"sql": "DELETE FROM users", "explanation": "oops"}`,
+		`{"sql": "SELECT 1; DROP TABLE users", "explanation": "oops"}`,
+		`{"sql": "WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d", "explanation": "oops"}`,
+	}}
+	srv, connector := newAskServer(t, provider)
+
+	res := callAsk(t, srv, "Remove all users")
+	assert.True(t, res.IsError)
+	assert.Contains(t, res.Content[0].(mcp.TextContent).Text, ErrAskNotReadOnly.Error())
+	assert.Empty(t, connector.Queries)
+}
+
+func TestAskRejectsHiddenTables(t *testing.T) {
+	provider := &askProvider{answers: []string{
+		`{"sql": "SELECT token FROM secrets", "explanation": "guessed"}`,
+		`{"sql": "SELECT count(*) FROM users", "explanation": "counts all users"}`,
+	}}
+	srv, connector := newAskServer(t, provider)
+
+	res := callAsk(t, srv, "What are the secret tokens?")
+	require.False(t, res.IsError)
+	require.Len(t, provider.requests, 2)
+	repair := provider.requests[1].Messages[2].Content[0].(*providers.ContentBlockText).Value
+	assert.Contains(t, repair, ErrAskTableNotExposed.Error()+": secrets")
+	assert.Equal(t, []string{`SELECT * FROM (SELECT count(*) FROM users) AS ask_result LIMIT 101`}, connector.Queries)
+
+	// raw mode exposes the whole database
+	srv.EnableRawProtocol()
+	provider.answers = []string{`{"sql": "SELECT token FROM secrets", "explanation": "all tokens"}`}
+	res = callAsk(t, srv, "What are the secret tokens?")
+	require.False(t, res.IsError)
+}
+
+func TestHiddenTableUsedBy(t *testing.T) {
+	hidden := hiddenTable{name: "sales.orders"}
+	assert.True(t, hidden.usedBy("SELECT * FROM orders"))
+	assert.False(t, hidden.usedBy("SELECT * FROM order_items"))
+
+	// public.orders is exposed, so only the qualified name refers to the hidden table
+	hidden.qualified = true
+	assert.False(t, hidden.usedBy("SELECT * FROM orders"))
+	assert.False(t, hidden.usedBy("SELECT * FROM public.orders"))
+	assert.True(t, hidden.usedBy("SELECT * FROM sales.orders"))
+	assert.True(t, hidden.usedBy(`SELECT * FROM "sales"."orders"`))
+	assert.True(t, hidden.usedBy("SELECT * FROM [sales] . [orders]"))
+}
+
+func TestAskRequiresReadonly(t *testing.T) {
+	srv, err := New(nil)
+	require.NoError(t, err)
+	require.NoError(t, srv.SetConnector(&connectortest.Connector{DBConfig: connectortest.Config{ReadWrite: true}}))
+	assert.ErrorIs(t, srv.EnableAsk(&askProvider{}, "test-model"), ErrAskReadWrite)
+}
+
+func TestAskLimitsRows(t *testing.T) {
+	provider := &askProvider{answers: []string{`{"sql": "SELECT id FROM users", "explanation": "all users"}`}}
+	srv, connector := newAskServer(t, provider)
+	for i := 0; i <= askMaxRows; i++ {
+		connector.Rows = append(connector.Rows, map[string]any{"id": i})
+	}
+
+	res := callAsk(t, srv, "List all users")
+	require.False(t, res.IsError)
+	assert.Equal(t, "Found more than 100 records, showing the first 100.", res.Content[1].(mcp.TextContent).Text)
+	assert.Len(t, res.Content, 2+askMaxRows)
+	assert.Equal(t, askMaxRows, res.Meta["rows"])
+	assert.Equal(t, "yaml", res.Meta["format"])
+
+	srv.EnableRawProtocol()
+	callAsk(t, srv, "List all users")
+	prompt := provider.requests[1].Messages[0].Content[0].(*providers.ContentBlockText).Value
+	assert.Contains(t, prompt, "secrets", "raw mode exposes the whole database")
+}
+
+func TestAskResultFormat(t *testing.T) {
+	provider := &askProvider{answers: []string{
+		`{"sql": "SELECT id FROM users", "explanation": "all users"}`,
+		`{"sql": "SELECT id FROM users", "explanation": "all users"}`,
+	}}
+	srv, connector := newAskServer(t, provider)
+	connector.Rows = []map[string]any{{"id": 1}, {"id": 2}}
+
+	var request mcp.CallToolRequest
+	request.Params.Name = "ask"
+	request.Params.Arguments = map[string]any{"question": "List all users", "format": "csv"}
+	res, err := srv.askQuestion(context.Background(), request)
+	require.NoError(t, err)
+	require.Len(t, res.Content, 3)
+	assert.Equal(t, "id\n1\n2\n", res.Content[2].(mcp.TextContent).Text)
+	assert.Equal(t, "csv", res.Meta["format"])
+	assert.Positive(t, res.Meta["estimated_tokens"])
+
+	// the configured result format applies without the argument
+	require.NoError(t, srv.SetResultFormat("markdown"))
+	res = callAsk(t, srv, "List all users")
+	assert.Equal(t, "markdown", res.Meta["format"])
+	assert.Contains(t, res.Content[2].(mcp.TextContent).Text, "| id |")
+
+	request.Params.Arguments["format"] = "xml"
+	_, err = srv.askQuestion(context.Background(), request)
+	assert.Error(t, err)
+}
+
+func TestIsReadOnlyQuery(t *testing.T) {
+	for query, expected := range map[string]bool{
+		"SELECT * FROM users":                       true,
+		"WITH u AS (SELECT 1) SELECT * FROM u":      true,
+		"SELECT * FROM users FOR UPDATE":            false,
+		"SELECT * FROM users FOR SHARE":             false,
+		"SELECT * INTO backup FROM users":           false,
+		"SELECT 1; SELECT 2":                        false,
+		"UPDATE users SET name = 'x'":               false,
+		"SELECT * FROM orders WHERE status = 'for'": true,
+	} {
+		assert.Equal(t, expected, isReadOnlyQuery(query), query)
+	}
+}
+
+func TestAskRequiresSQL(t *testing.T) {
+	srv, err := New(nil)
+	require.NoError(t, err)
+	require.NoError(t, srv.SetConnector(&connectortest.Connector{DBConfig: connectortest.Config{DBType: "mongodb"}}))
+	assert.ErrorIs(t, srv.EnableAsk(&askProvider{}, "test-model"), ErrAskNotSQL)
+}
+
+func TestLimitQuery(t *testing.T) {
+	assert.Equal(t, "SELECT TOP 11 * FROM (SELECT id FROM users) ask_result", limitQuery("mssql", "SELECT id FROM users", 11))
+	assert.Equal(t, "SELECT * FROM (SELECT id FROM users) ask_result FETCH FIRST 11 ROWS ONLY", limitQuery("oracle", "SELECT id FROM users", 11))
+	assert.Equal(t, "SELECT * FROM (SELECT id FROM users) AS ask_result LIMIT 11", limitQuery("mysql", "SELECT id FROM users", 11))
+}
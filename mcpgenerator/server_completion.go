@@ -0,0 +1,321 @@
+package mcpgenerator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/xcontext"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// completionTTL is how long distinct column values are cached for a prefix.
+	completionTTL = 5 * time.Minute
+	// completionMaxValues is the limit of values in a completion result set by the protocol.
+	completionMaxValues = 100
+)
+
+var (
+	queryTable = regexp.MustCompile(`(?i)\bFROM\s+([\w."\x60\[\]]+)`)
+	identifier = regexp.MustCompile(`^[\w."\x60\[\]]+$`)
+)
+
+type completionState struct {
+	mu    sync.Mutex
+	cache map[string]completionEntry
+}
+
+type completionEntry struct {
+	values   []string
+	loadedAt time.Time
+}
+
+// complete answers completion/complete requests for prompt arguments and params of endpoint tools.
+// Suggestions come from a static list of the config, or from distinct values of the column the param filters.
+func (s *MCPServer) complete(ctx context.Context, request mcp.CompleteRequest) (*mcp.CompleteResult, error) {
+	var ref struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	}
+	raw, err := json.Marshal(request.Params.Ref)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid ref: %w", err)
+	}
+	if err := json.Unmarshal(raw, &ref); err != nil {
+		return nil, xerrors.Errorf("invalid ref: %w", err)
+	}
+
+	// tool is the endpoint whose authorization applies to the distinct values query
+	var completion *model.Completion
+	var tool string
+	switch ref.Type {
+	case "ref/prompt":
+		completion, tool = s.promptCompletion(ref.Name, request.Params.Argument.Name)
+	case "ref/tool":
+		if !s.offered(ctx, ref.Name) {
+			return nil, xerrors.Errorf("unknown tool: %s", ref.Name)
+		}
+		if endpoint, ok := s.endpointByMethod(ref.Name); ok {
+			completion, tool = paramCompletion(endpoint, request.Params.Argument.Name), ref.Name
+		}
+	}
+
+	result := &mcp.CompleteResult{}
+	result.Completion.Values = []string{}
+	if completion == nil {
+		return result, nil
+	}
+	values, err := s.completionValues(ctx, tool, completion, request.Params.Argument.Value)
+	if err != nil {
+		// Suggestions are optional, a client must not fail because a column can't be queried
+		logrus.Warnf("unable to complete %s of %s: %v", request.Params.Argument.Name, ref.Name, err)
+		return result, nil
+	}
+	if len(values) > completionMaxValues {
+		values = values[:completionMaxValues]
+		result.Completion.HasMore = true
+	} else {
+		result.Completion.Total = len(values)
+	}
+	result.Completion.Values = values
+	return result, nil
+}
+
+// offered reports whether tools/list shows the tool to the caller, tools hidden by filters can't be completed.
+func (s *MCPServer) offered(ctx context.Context, name string) bool {
+	for _, tool := range s.server.ListToolsFor(ctx) {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MCPServer) endpointByMethod(mcpMethod string) (model.Endpoint, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, endpoint := range s.tools {
+		if endpoint.MCPMethod == mcpMethod {
+			return endpoint, true
+		}
+	}
+	return model.Endpoint{}, false
+}
+
+// promptCompletion returns completion of a prompt argument, configured explicitly or taken from
+// the param of a data endpoint the argument is passed to, together with the data endpoint that authorizes it.
+// Explicit completions are authorized as the first data endpoint, without data endpoints only static values are used.
+func (s *MCPServer) promptCompletion(promptName, argName string) (*model.Completion, string) {
+	s.mu.Lock()
+	var prompt model.Prompt
+	var found bool
+	for _, p := range s.prompts {
+		if p.Name == promptName {
+			prompt, found = p, true
+			break
+		}
+	}
+	s.mu.Unlock()
+	if !found {
+		return nil, ""
+	}
+	for _, arg := range prompt.Arguments {
+		if arg.Name != argName || arg.Completion == nil {
+			continue
+		}
+		if len(prompt.Data) > 0 {
+			return arg.Completion, prompt.Data[0].Endpoint
+		}
+		if len(arg.Completion.Values) > 0 {
+			return arg.Completion, ""
+		}
+		return nil, ""
+	}
+	for _, data := range prompt.Data {
+		endpoint, ok := s.endpointByMethod(data.Endpoint)
+		if !ok {
+			continue
+		}
+		param := argName
+		if len(data.Params) > 0 {
+			param = ""
+			for name, value := range data.Params {
+				if strings.ReplaceAll(value, " ", "") == fmt.Sprintf("{{.%s}}", argName) {
+					param = name
+					break
+				}
+			}
+		}
+		if completion := paramCompletion(endpoint, param); completion != nil {
+			return completion, data.Endpoint
+		}
+	}
+	return nil, ""
+}
+
+// paramCompletion returns completion of an endpoint param, configured explicitly or inferred from the query:
+// for `FROM orders WHERE status = :status` distinct values of orders.status are suggested.
+// Only string params are inferred, prefix matching of other types is not portable between databases.
+func paramCompletion(endpoint model.Endpoint, paramName string) *model.Completion {
+	for _, param := range endpoint.Params {
+		if param.Name != paramName {
+			continue
+		}
+		completion := model.Completion{}
+		if param.Completion != nil {
+			completion = *param.Completion
+		}
+		if completion.Disabled {
+			return nil
+		}
+		if len(completion.Values) > 0 {
+			return &completion
+		}
+		if completion.Column == "" && param.Type != "string" {
+			return nil
+		}
+		if completion.Table == "" {
+			if match := queryTable.FindStringSubmatch(endpoint.Query); match != nil {
+				completion.Table = match[1]
+			}
+		}
+		if completion.Column == "" {
+			filter := regexp.MustCompile(`(?i)([\w."\x60\[\]]+)\s*(?:=|<>|!=|\bLIKE|\bILIKE|\bIN)\s*\(?\s*:` + regexp.QuoteMeta(param.Name) + `\b`)
+			if match := filter.FindStringSubmatch(endpoint.Query); match != nil {
+				column := match[1]
+				// drop the table alias, the query may join several tables but only the first one is used
+				if i := strings.LastIndex(column, "."); i >= 0 {
+					column = column[i+1:]
+				}
+				completion.Column = column
+			}
+		}
+		if completion.Table == "" || completion.Column == "" {
+			return nil
+		}
+		return &completion
+	}
+	return nil
+}
+
+// completionValues returns static values or distinct column values starting with the prefix, cached for completionTTL.
+// The distinct query runs as the tool, so connector plugins authorize it like a call of the tool.
+// Values are cached per session, a caller never gets values queried with authorization of another one.
+func (s *MCPServer) completionValues(ctx context.Context, tool string, completion *model.Completion, prefix string) ([]string, error) {
+	if len(completion.Values) > 0 {
+		var res []string
+		for _, value := range completion.Values {
+			if strings.HasPrefix(strings.ToLower(value), strings.ToLower(prefix)) {
+				res = append(res, value)
+			}
+		}
+		return res, nil
+	}
+	if !runsSQL(s.connector.Config().Type()) {
+		return nil, xerrors.Errorf("%s connector does not support distinct value suggestions", s.connector.Config().Type())
+	}
+	if !identifier.MatchString(completion.Table) || !identifier.MatchString(completion.Column) {
+		return nil, xerrors.Errorf("invalid completion column: %s.%s", completion.Table, completion.Column)
+	}
+
+	session := xcontext.Session(ctx)
+	key := strings.Join([]string{session, tool, completion.Table, completion.Column, prefix}, "\x00")
+	s.completion.mu.Lock()
+	entry, ok := s.completion.cache[key]
+	s.completion.mu.Unlock()
+	if ok && session != "" && time.Since(entry.loadedAt) < completionTTL {
+		return entry.values, nil
+	}
+
+	typ := s.connector.Config().Type()
+	rows, err := s.run(ctx, "completion", model.Endpoint{
+		MCPMethod: tool,
+		Query:     distinctQuery(typ, completion.Table, completion.Column),
+		Params:    []model.EndpointParams{{Name: "prefix", Type: "string"}},
+	}, map[string]any{"prefix": likePrefix(typ, prefix)})
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, 0, len(rows))
+	for _, row := range rows {
+		// the row has a single column, its name may be upper cased by the database
+		for _, value := range row {
+			if value != nil {
+				values = append(values, fmt.Sprint(value))
+			}
+		}
+	}
+
+	if session == "" {
+		return values, nil
+	}
+	s.completion.store(key, values, time.Now())
+	return values, nil
+}
+
+// store caches values of a key and evicts expired entries, so the cache holds only values of the last completionTTL.
+func (c *completionState) store(key string, values []string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cache == nil {
+		c.cache = map[string]completionEntry{}
+	}
+	for k, entry := range c.cache {
+		if now.Sub(entry.loadedAt) >= completionTTL {
+			delete(c.cache, k)
+		}
+	}
+	c.cache[key] = completionEntry{values: values, loadedAt: now}
+}
+
+// distinctQuery selects one more value than the protocol allows, so the result tells if there are more values.
+func distinctQuery(typ, table, column string) string {
+	limit := completionMaxValues + 1
+	like := fmt.Sprintf("%s LIKE :prefix", column)
+	if !likeBackslash(typ) {
+		like += " ESCAPE '!'"
+	}
+	switch typ {
+	case "mssql":
+		return fmt.Sprintf("SELECT DISTINCT TOP %d %s AS value FROM %s WHERE %s ORDER BY value", limit, column, table, like)
+	case "oracle":
+		return fmt.Sprintf("SELECT DISTINCT %s AS value FROM %s WHERE %s ORDER BY 1 FETCH FIRST %d ROWS ONLY", column, table, like, limit)
+	default:
+		return fmt.Sprintf("SELECT DISTINCT %s AS value FROM %s WHERE %s ORDER BY 1 LIMIT %d", column, table, like, limit)
+	}
+}
+
+// likePrefix turns the typed prefix into a LIKE pattern, wildcards typed by the user match only themselves.
+// The escape character is declared with ESCAPE '!' in distinctQuery, a backslash would need escaping in MySQL literals.
+func likePrefix(typ, prefix string) string {
+	escape, special := "!", "!%_"
+	switch {
+	case likeBackslash(typ):
+		escape, special = `\`, `\%_`
+	case typ == "mssql":
+		// SQL Server also treats [ as the start of a character range
+		special += "["
+	}
+	var res strings.Builder
+	for _, r := range prefix {
+		if strings.ContainsRune(special, r) {
+			res.WriteString(escape)
+		}
+		res.WriteRune(r)
+	}
+	res.WriteString("%")
+	return res.String()
+}
+
+// likeBackslash reports whether the database has no ESCAPE clause and escapes LIKE wildcards with a backslash.
+func likeBackslash(typ string) bool {
+	return typ == "clickhouse" || typ == "bigquery"
+}
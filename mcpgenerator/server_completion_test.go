@@ -0,0 +1,104 @@
+package mcpgenerator
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/centralmind/gateway/connectors/connectortest"
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/xcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func complete(t *testing.T, srv *MCPServer, ref map[string]any, name, value string) mcp.JSONRPCMessage {
+	raw, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "completion/complete", "params": map[string]any{
+		"ref":      ref,
+		"argument": map[string]any{"name": name, "value": value},
+	}})
+	require.NoError(t, err)
+	// values are cached per session
+	return srv.Server().HandleMessage(xcontext.WithSession(context.Background(), "test"), raw)
+}
+
+func completeArgument(t *testing.T, srv *MCPServer, ref map[string]any, name, value string) *mcp.CompleteResult {
+	resp, ok := complete(t, srv, ref, name, value).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	return resp.Result.(*mcp.CompleteResult)
+}
+
+func TestComplete(t *testing.T) {
+	srv, err := New(nil)
+	require.NoError(t, err)
+	connector := &connectortest.Connector{}
+	require.NoError(t, srv.SetConnector(connector))
+	srv.SetTools([]model.Endpoint{{
+		MCPMethod: "list_orders",
+		Query:     "SELECT * FROM orders o WHERE o.status = :status AND o.id > :id",
+		Params:    []model.EndpointParams{{Name: "status", Type: "string"}, {Name: "id", Type: "number"}},
+	}})
+	require.NoError(t, srv.SetPrompts([]model.Prompt{{
+		Name: "orders_report",
+		Arguments: []model.PromptArgument{
+			{Name: "order_status"},
+			{Name: "country", Completion: &model.Completion{Values: []string{"Germany", "Georgia", "France"}}},
+		},
+		Data:     []model.PromptData{{Endpoint: "list_orders", Params: map[string]string{"status": "{{ .order_status }}"}}},
+		Template: "{{yaml .list_orders}}",
+	}}))
+
+	tool := map[string]any{"type": "ref/tool", "name": "list_orders"}
+	res := completeArgument(t, srv, tool, "status", "sh")
+	assert.Equal(t, []string{"42"}, res.Completion.Values)
+	require.Len(t, connector.Queries, 1)
+	assert.Equal(t, "SELECT DISTINCT status AS value FROM orders WHERE status LIKE :prefix ESCAPE '!' ORDER BY 1 LIMIT 101", connector.Queries[0])
+	assert.Equal(t, map[string]any{"prefix": "sh%"}, connector.Params[0])
+
+	completeArgument(t, srv, tool, "status", "sh")
+	assert.Len(t, connector.Queries, 1, "values must be cached")
+
+	assert.Empty(t, completeArgument(t, srv, tool, "id", "1").Completion.Values, "only string params are inferred")
+
+	prompt := map[string]any{"type": "ref/prompt", "name": "orders_report"}
+	assert.Equal(t, []string{"42"}, completeArgument(t, srv, prompt, "order_status", "sh").Completion.Values)
+	assert.Equal(t, []string{"Germany", "Georgia"}, completeArgument(t, srv, prompt, "country", "ge").Completion.Values)
+}
+
+func TestCompleteHiddenTool(t *testing.T) {
+	srv, err := New(nil)
+	require.NoError(t, err)
+	connector := &connectortest.Connector{}
+	require.NoError(t, srv.SetConnector(connector))
+	srv.SetTools([]model.Endpoint{{
+		MCPMethod: "list_orders",
+		Query:     "SELECT * FROM orders WHERE status = :status",
+		Params:    []model.EndpointParams{{Name: "status", Type: "string"}},
+	}})
+	srv.Server().AddToolFilter(func(ctx context.Context, tools []mcp.Tool) []mcp.Tool { return nil })
+
+	_, isErr := complete(t, srv, map[string]any{"type": "ref/tool", "name": "list_orders"}, "status", "sh").(mcp.JSONRPCError)
+	assert.True(t, isErr, "tools hidden from the caller must not be completed")
+	assert.Empty(t, connector.Queries)
+}
+
+func TestCompletionCacheEviction(t *testing.T) {
+	var state completionState
+	now := time.Now()
+	state.store("old", []string{"a"}, now.Add(-completionTTL))
+	state.store("fresh", []string{"b"}, now.Add(-time.Minute))
+	state.store("new", []string{"c"}, now)
+	assert.NotContains(t, state.cache, "old", "expired values must be evicted")
+	assert.Contains(t, state.cache, "fresh")
+	assert.Contains(t, state.cache, "new")
+}
+
+func TestLikePrefix(t *testing.T) {
+	assert.Equal(t, "50!%!_off%", likePrefix("postgres", "50%_off"))
+	assert.Equal(t, "a!!b%", likePrefix("mysql", "a!b"))
+	assert.Equal(t, "![x]%", likePrefix("mssql", "[x]"))
+	assert.Equal(t, `50\%\\%`, likePrefix("clickhouse", `50%\`))
+	assert.Contains(t, distinctQuery("clickhouse", "orders", "status"), "LIKE :prefix ORDER BY")
+}
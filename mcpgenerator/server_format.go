@@ -0,0 +1,223 @@
+package mcpgenerator
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/prompter"
+	"golang.org/x/xerrors"
+)
+
+// ResultFormat is how rows of a query are rendered as text content of a tool result.
+type ResultFormat string
+
+const (
+	// FormatJSON renders every row as its own JSON object, the default of endpoint tools.
+	FormatJSON ResultFormat = "json"
+	// FormatYAML renders every row as its own YAML document, the default of the raw query tool.
+	FormatYAML ResultFormat = "yaml"
+	// FormatCSV renders a table with a header line.
+	FormatCSV ResultFormat = "csv"
+	// FormatTSV renders a table with a header line and tab separated values.
+	FormatTSV ResultFormat = "tsv"
+	// FormatMarkdown renders a markdown table.
+	FormatMarkdown ResultFormat = "markdown"
+	// FormatColumnar renders a JSON object with an array of values per column.
+	FormatColumnar ResultFormat = "columnar"
+)
+
+var resultFormats = []ResultFormat{FormatJSON, FormatYAML, FormatCSV, FormatTSV, FormatMarkdown, FormatColumnar}
+
+// formatArgument is the optional tool argument that overrides the result format for a call.
+const formatArgument = "format"
+
+// ParseResultFormat validates a format from the config or a tool argument, an empty one is returned as is.
+func ParseResultFormat(format string) (ResultFormat, error) {
+	f := ResultFormat(strings.ToLower(strings.TrimSpace(format)))
+	if f == "" || slices.Contains(resultFormats, f) {
+		return f, nil
+	}
+	return "", xerrors.Errorf("unknown result format %q, expected one of %v", format, resultFormats)
+}
+
+// SetResultFormat sets the format of tool results for endpoints and the raw query tool that don't configure their own.
+func (s *MCPServer) SetResultFormat(format string) error {
+	f, err := ParseResultFormat(format)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.format = f
+	return nil
+}
+
+// formatOption adds the format argument to a tool, unless the tool has its own argument with this name.
+func formatOption() mcp.ToolOption {
+	names := make([]string, len(resultFormats))
+	for i, f := range resultFormats {
+		names[i] = string(f)
+	}
+	return mcp.WithString(
+		formatArgument,
+		mcp.Description("Format of result rows, csv or markdown take the least tokens for wide results"),
+		mcp.Enum(names...),
+	)
+}
+
+// resultFormat picks the format of a call: the argument, then the endpoint config, then the global one.
+func (s *MCPServer) resultFormat(arguments map[string]interface{}, configured ResultFormat, fallback ResultFormat) (ResultFormat, error) {
+	if arg, ok := arguments[formatArgument].(string); ok && arg != "" {
+		return ParseResultFormat(arg)
+	}
+	if configured != "" {
+		return configured, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.format != "" {
+		return s.format, nil
+	}
+	return fallback, nil
+}
+
+// formatRows renders rows as text content, columns keep their order and columns missing from it are added sorted.
+// The result metadata tells the format and an estimate of tokens the rows take.
+func formatRows(format ResultFormat, columns []string, rows []map[string]any) ([]mcp.Content, map[string]interface{}) {
+	columns = rowColumns(columns, rows)
+	var texts []string
+	switch format {
+	case FormatCSV, FormatTSV:
+		texts = []string{formatDelimited(format, columns, rows)}
+	case FormatMarkdown:
+		texts = []string{formatMarkdown(columns, rows)}
+	case FormatColumnar:
+		texts = []string{formatColumnar(columns, rows)}
+	case FormatYAML:
+		for _, row := range rows {
+			texts = append(texts, prompter.Yamlify(row))
+		}
+	default:
+		format = FormatJSON
+		for _, row := range rows {
+			texts = append(texts, jsonify(row))
+		}
+	}
+
+	content := make([]mcp.Content, 0, len(texts))
+	size := 0
+	for _, text := range texts {
+		content = append(content, mcp.TextContent{Type: "text", Text: text})
+		size += len(text)
+	}
+	return content, map[string]interface{}{
+		"format":           string(format),
+		"estimated_tokens": estimateTokens(size),
+	}
+}
+
+// estimateTokens approximates the number of tokens of a text by its size, tokenizers average about 4 bytes per token.
+func estimateTokens(size int) int {
+	return (size + 3) / 4
+}
+
+func rowColumns(columns []string, rows []map[string]any) []string {
+	known := make(map[string]bool, len(columns))
+	res := make([]string, 0, len(columns))
+	for _, col := range columns {
+		if !known[col] {
+			known[col] = true
+			res = append(res, col)
+		}
+	}
+	var extra []string
+	for _, row := range rows {
+		for col := range row {
+			if !known[col] {
+				known[col] = true
+				extra = append(extra, col)
+			}
+		}
+	}
+	sort.Strings(extra)
+	return append(res, extra...)
+}
+
+func formatDelimited(format ResultFormat, columns []string, rows []map[string]any) string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if format == FormatTSV {
+		w.Comma = '\t'
+	}
+	_ = w.Write(columns)
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, col := range columns {
+			record[i] = cellText(row[col])
+		}
+		_ = w.Write(record)
+	}
+	w.Flush()
+	return buf.String()
+}
+
+func formatMarkdown(columns []string, rows []map[string]any) string {
+	var b strings.Builder
+	b.WriteString("| " + strings.Join(columns, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(columns)) + "\n")
+	cells := make([]string, len(columns))
+	for _, row := range rows {
+		for i, col := range columns {
+			cell := strings.ReplaceAll(cellText(row[col]), "|", `\|`)
+			cells[i] = strings.ReplaceAll(cell, "\n", "<br>")
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	return b.String()
+}
+
+func formatColumnar(columns []string, rows []map[string]any) string {
+	// json.Marshal sorts keys of a map, columns keep their order in a raw message
+	var b strings.Builder
+	b.WriteString("{")
+	for i, col := range columns {
+		values := make([]any, len(rows))
+		for j, row := range rows {
+			values[j] = row[col]
+		}
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(jsonify(col) + ":" + jsonify(values))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// cellText renders a value for a table cell, values that are not scalars are rendered as JSON.
+func cellText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	case map[string]any, []any:
+		raw, _ := json.Marshal(v)
+		return string(raw)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,113 @@
+package mcpgenerator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/centralmind/gateway/connectors/connectortest"
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatRows(t *testing.T) {
+	rows := []map[string]any{
+		{"id": 1, "name": "Alice, Jr.", "created_at": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"id": 2, "name": "Bob | Co", "note": "vip"},
+	}
+	text := func(format ResultFormat) string {
+		content, meta := formatRows(format, []string{"id", "name"}, rows)
+		require.Len(t, content, 1)
+		assert.Equal(t, string(format), meta["format"])
+		return content[0].(mcp.TextContent).Text
+	}
+
+	assert.Equal(t, "id,name,created_at,note\n"+
+		"1,\"Alice, Jr.\",2024-01-02T03:04:05Z,\n"+
+		"2,Bob | Co,,vip\n", text(FormatCSV))
+	assert.Equal(t, "id\tname\tcreated_at\tnote\n"+
+		"1\tAlice, Jr.\t2024-01-02T03:04:05Z\t\n"+
+		"2\tBob | Co\t\tvip\n", text(FormatTSV))
+	assert.Equal(t, "| id | name | created_at | note |\n"+
+		"| --- | --- | --- | --- |\n"+
+		"| 1 | Alice, Jr. | 2024-01-02T03:04:05Z |  |\n"+
+		"| 2 | Bob \\| Co |  | vip |\n", text(FormatMarkdown))
+	assert.JSONEq(t, `{
+		"id": [1, 2],
+		"name": ["Alice, Jr.", "Bob | Co"],
+		"created_at": ["2024-01-02T03:04:05Z", null],
+		"note": [null, "vip"]
+	}`, text(FormatColumnar))
+
+	content, meta := formatRows(FormatJSON, nil, rows)
+	assert.Len(t, content, 2)
+	size := 0
+	for _, c := range content {
+		size += len(c.(mcp.TextContent).Text)
+	}
+	assert.Equal(t, estimateTokens(size), meta["estimated_tokens"])
+
+	// a table names columns once, so it takes fewer tokens than a JSON object per row
+	_, csvMeta := formatRows(FormatCSV, nil, rows)
+	assert.Less(t, csvMeta["estimated_tokens"], meta["estimated_tokens"])
+}
+
+func TestParseResultFormat(t *testing.T) {
+	f, err := ParseResultFormat(" CSV ")
+	require.NoError(t, err)
+	assert.Equal(t, FormatCSV, f)
+	f, err = ParseResultFormat("")
+	require.NoError(t, err)
+	assert.Equal(t, ResultFormat(""), f)
+	_, err = ParseResultFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestToolResultFormat(t *testing.T) {
+	srv, err := New(nil)
+	require.NoError(t, err)
+	require.NoError(t, srv.SetConnector(&connectortest.Connector{}))
+	require.NoError(t, srv.SetResultFormat("markdown"))
+	srv.SetTools([]model.Endpoint{
+		{MCPMethod: "count_users", Query: "SELECT count(*) AS count FROM users"},
+		{MCPMethod: "count_orders", Query: "SELECT count(*) AS count FROM orders", Format: "csv"},
+	})
+
+	call := func(name string, arguments map[string]any) *mcp.CallToolResult {
+		var request mcp.CallToolRequest
+		request.Params.Name = name
+		request.Params.Arguments = arguments
+		result, err := srv.Server().CallTool(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	result := call("count_users", map[string]any{})
+	assert.Equal(t, "| count |\n| --- |\n| 42 |\n", result.Content[1].(mcp.TextContent).Text)
+	assert.Equal(t, "markdown", result.Meta["format"])
+	assert.Equal(t, 1, result.Meta["rows"])
+	assert.Positive(t, result.Meta["estimated_tokens"])
+	assert.Nil(t, result.StructuredContent, "rows are not sent twice in other formats")
+
+	structured := call("count_users", map[string]any{"format": "json"})
+	require.NotNil(t, structured.StructuredContent)
+	text := structured.Content[1].(mcp.TextContent).Text
+	assert.Equal(t, estimateTokens(len(text))+estimateTokens(len(`{"rows":[{"count":42}]}`)), structured.Meta["estimated_tokens"],
+		"structured content is counted in the estimate")
+
+	tools := srv.Server().ListTools()
+	for _, tool := range tools {
+		assert.Nil(t, tool.OutputSchema, "%s returns no structured content by default", tool.Name)
+	}
+
+	result = call("count_orders", map[string]any{})
+	assert.Equal(t, "count\n42\n", result.Content[1].(mcp.TextContent).Text)
+
+	result = call("count_orders", map[string]any{"format": "columnar"})
+	assert.Equal(t, `{"count":[42]}`, result.Content[1].(mcp.TextContent).Text)
+
+	result = call("count_users", map[string]any{"format": "xml"})
+	assert.True(t, result.IsError)
+}
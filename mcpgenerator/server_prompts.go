@@ -0,0 +1,164 @@
+package mcpgenerator
+
+import (
+	"context"
+	"strings"
+	"text/template"
+
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/prompter"
+	"github.com/centralmind/gateway/server"
+	"golang.org/x/xerrors"
+)
+
+var promptFuncs = template.FuncMap{
+	"yaml": prompter.Yamlify,
+	"json": jsonify,
+}
+
+// SetPrompts registers prompts from the config, data endpoints must be registered with SetTools before.
+func (s *MCPServer) SetPrompts(prompts []model.Prompt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	endpoints := make(map[string]model.Endpoint, len(s.tools))
+	for _, endpoint := range s.tools {
+		endpoints[endpoint.MCPMethod] = endpoint
+	}
+	for _, prompt := range prompts {
+		tmpl, err := template.New(prompt.Name).Funcs(promptFuncs).Parse(prompt.Template)
+		if err != nil {
+			return xerrors.Errorf("unable to parse template of %s prompt: %w", prompt.Name, err)
+		}
+		params := make(map[string]map[string]*template.Template, len(prompt.Data))
+		for _, data := range prompt.Data {
+			if _, ok := endpoints[data.Endpoint]; !ok {
+				return xerrors.Errorf("%s prompt uses unknown endpoint: %s", prompt.Name, data.Endpoint)
+			}
+			params[data.Endpoint] = make(map[string]*template.Template, len(data.Params))
+			for name, value := range data.Params {
+				params[data.Endpoint][name], err = template.New(name).Funcs(promptFuncs).Parse(value)
+				if err != nil {
+					return xerrors.Errorf("unable to parse %s param of %s prompt: %w", name, prompt.Name, err)
+				}
+			}
+		}
+
+		opts := []mcp.PromptOption{mcp.WithPromptDescription(prompt.Description)}
+		for _, arg := range prompt.Arguments {
+			argOpts := []mcp.ArgumentOption{mcp.ArgumentDescription(arg.Description)}
+			if arg.Required {
+				argOpts = append(argOpts, mcp.RequiredArgument())
+			}
+			opts = append(opts, mcp.WithArgument(arg.Name, argOpts...))
+		}
+		s.server.AddPrompt(mcp.NewPrompt(prompt.Name, opts...), s.prompt(prompt, tmpl, endpoints, params))
+	}
+	s.prompts = prompts
+	return nil
+}
+
+func (s *MCPServer) prompt(
+	prompt model.Prompt,
+	tmpl *template.Template,
+	endpoints map[string]model.Endpoint,
+	params map[string]map[string]*template.Template,
+) server.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		// missing optional arguments are empty strings in templates, and null params of data endpoints
+		args := map[string]any{}
+		data := make(map[string]any, len(prompt.Arguments)+len(prompt.Data))
+		for _, arg := range prompt.Arguments {
+			value, ok := request.Params.Arguments[arg.Name]
+			if !ok && arg.Required {
+				return nil, xerrors.Errorf("argument %s is required", arg.Name)
+			}
+			if ok {
+				args[arg.Name] = value
+				data[arg.Name] = value
+			} else {
+				data[arg.Name] = ""
+			}
+		}
+
+		for _, d := range prompt.Data {
+			endpointParams, err := promptParams(endpoints[d.Endpoint], params[d.Endpoint], args, data)
+			if err != nil {
+				return nil, xerrors.Errorf("unable to prepare params of %s: %w", d.Endpoint, err)
+			}
+			rows, err := s.promptData(ctx, d.Endpoint, endpointParams)
+			if err != nil {
+				return nil, xerrors.Errorf("unable to query %s: %w", d.Endpoint, err)
+			}
+			data[d.Endpoint] = rows
+		}
+
+		var text strings.Builder
+		if err := tmpl.Execute(&text, data); err != nil {
+			return nil, xerrors.Errorf("unable to render %s prompt: %w", prompt.Name, err)
+		}
+		return &mcp.GetPromptResult{
+			Description: prompt.Description,
+			Messages: []mcp.PromptMessage{{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text.String(),
+				},
+			}},
+		}, nil
+	}
+}
+
+// promptData calls the endpoint tool through all tool middlewares, so prompts are authorized and approved
+// the same way as tool calls, and returns its rows.
+func (s *MCPServer) promptData(ctx context.Context, tool string, params map[string]any) ([]map[string]any, error) {
+	var request mcp.CallToolRequest
+	request.Params.Name = tool
+	request.Params.Arguments = params
+	result, err := s.server.CallTool(withStructuredRows(ctx), request)
+	if err != nil {
+		return nil, err
+	}
+	if result.IsError {
+		var text []string
+		for _, content := range result.Content {
+			if t, ok := content.(mcp.TextContent); ok {
+				text = append(text, t.Text)
+			}
+		}
+		return nil, xerrors.New(strings.Join(text, "\n"))
+	}
+	rows, ok := result.StructuredContent["rows"].([]map[string]any)
+	if !ok {
+		return nil, xerrors.Errorf("%s tool returned no rows", tool)
+	}
+	return rows, nil
+}
+
+// promptParams renders endpoint parameters, without templates arguments with the same names are passed as is.
+// Templates see missing optional arguments as empty strings, a param rendered empty is null.
+func promptParams(endpoint model.Endpoint, templates map[string]*template.Template, args, data map[string]any) (map[string]any, error) {
+	res := make(map[string]any, len(endpoint.Params))
+	for _, param := range endpoint.Params {
+		res[param.Name] = nil
+		if len(templates) == 0 {
+			if value, ok := args[param.Name]; ok {
+				res[param.Name] = value
+			}
+			continue
+		}
+		tmpl, ok := templates[param.Name]
+		if !ok {
+			continue
+		}
+		var value strings.Builder
+		if err := tmpl.Execute(&value, data); err != nil {
+			return nil, err
+		}
+		if value.Len() > 0 {
+			res[param.Name] = value.String()
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,89 @@
+package mcpgenerator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/centralmind/gateway/connectors/connectortest"
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetPrompts(t *testing.T) {
+	srv, err := New(nil)
+	require.NoError(t, err)
+	connector := &connectortest.Connector{}
+	require.NoError(t, srv.SetConnector(connector))
+	srv.SetTools([]model.Endpoint{{
+		MCPMethod: "get_account",
+		Query:     "SELECT count(*) AS count FROM accounts WHERE id = :id",
+		Params:    []model.EndpointParams{{Name: "id", Type: "number"}},
+	}})
+	require.NoError(t, srv.SetPrompts([]model.Prompt{{
+		Name:        "summarise_account",
+		Description: "Summarise an account",
+		Arguments:   []model.PromptArgument{{Name: "account_id", Required: true}},
+		Data:        []model.PromptData{{Endpoint: "get_account", Params: map[string]string{"id": "{{.account_id}}"}}},
+		Template:    "Summarise account {{.account_id}}:\n{{yaml .get_account}}",
+	}}))
+
+	resp, ok := handle(t, srv, "prompts/list", map[string]any{}).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	prompts := resp.Result.(mcp.ListPromptsResult).Prompts
+	require.Len(t, prompts, 1)
+	assert.Equal(t, "summarise_account", prompts[0].Name)
+	assert.True(t, prompts[0].Arguments[0].Required)
+
+	resp, ok = handle(t, srv, "prompts/get", map[string]any{
+		"name":      "summarise_account",
+		"arguments": map[string]string{"account_id": "7"},
+	}).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	result := resp.Result.(*mcp.GetPromptResult)
+	require.Len(t, result.Messages, 1)
+	text := result.Messages[0].Content.(mcp.TextContent).Text
+	assert.Contains(t, text, "Summarise account 7:")
+	assert.Contains(t, text, "count: 42")
+	assert.Len(t, connector.Queries, 1)
+
+	_, isErr := handle(t, srv, "prompts/get", map[string]any{"name": "summarise_account"}).(mcp.JSONRPCError)
+	assert.True(t, isErr, "required argument must be checked")
+
+	err = srv.SetPrompts([]model.Prompt{{Name: "broken", Data: []model.PromptData{{Endpoint: "missing"}}}})
+	assert.ErrorContains(t, err, "unknown endpoint")
+}
+
+func TestPromptDataAuthorization(t *testing.T) {
+	srv, err := New(nil)
+	require.NoError(t, err)
+	connector := &connectortest.Connector{}
+	require.NoError(t, srv.SetConnector(connector))
+	srv.SetTools([]model.Endpoint{{
+		MCPMethod: "list_accounts",
+		Query:     "SELECT count(*) AS count FROM accounts WHERE (:status IS NULL OR status = :status)",
+		Params:    []model.EndpointParams{{Name: "status", Type: "string"}},
+	}})
+	require.NoError(t, srv.SetPrompts([]model.Prompt{{
+		Name:      "accounts",
+		Arguments: []model.PromptArgument{{Name: "status"}},
+		Data:      []model.PromptData{{Endpoint: "list_accounts"}},
+		Template:  "Accounts [{{.status}}]:\n{{yaml .list_accounts}}",
+	}}))
+
+	resp, ok := handle(t, srv, "prompts/get", map[string]any{"name": "accounts"}).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	text := resp.Result.(*mcp.GetPromptResult).Messages[0].Content.(mcp.TextContent).Text
+	assert.Contains(t, text, "Accounts []:", "missing optional argument must render empty")
+	assert.Equal(t, map[string]any{"status": nil}, connector.Params[0])
+
+	srv.Server().AddToolMiddleware(func(ctx context.Context, tool server.ServerTool, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent("not allowed")}, IsError: true}, nil
+	})
+	failed, ok := handle(t, srv, "prompts/get", map[string]any{"name": "accounts"}).(mcp.JSONRPCError)
+	require.True(t, ok, "prompt data must go through tool middlewares")
+	assert.Contains(t, failed.Error.Message, "not allowed")
+	assert.Len(t, connector.Queries, 1)
+}
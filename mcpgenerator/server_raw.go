@@ -8,19 +8,26 @@ import (
 	"github.com/centralmind/gateway/mcp"
 	"github.com/centralmind/gateway/model"
 	"github.com/centralmind/gateway/prompter"
-	"github.com/centralmind/gateway/xcontext"
 	"golang.org/x/xerrors"
 )
 
+// rawGroup is the group of raw protocol tools, e.g. a client can hide them with ?tools=<endpoint groups>
+const rawGroup = "raw"
+
 func (s *MCPServer) EnableRawProtocol() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.raw = true
 	s.server.DeleteTools("list_tables", "discover_data", "prepare_query", "query")
+	for _, name := range []string{"list_tables", "discover_data", "prepare_query", "query"} {
+		s.groups[name] = rawGroup
+	}
 	s.server.AddTool(mcp.NewTool(
 		"list_tables",
 		mcp.WithDescription(fmt.Sprintf(`Return list of tables that available for data in %s database.
 This is usually first this agent shall call.
 `, s.connector.Config().Type())),
+		mcp.WithToolAnnotation(readOnlyAnnotation()),
 	), s.listTables)
 	s.server.AddTool(mcp.NewTool(
 		"discover_data",
@@ -29,6 +36,7 @@ tables_list parameter is comma separated table to fetch data samples.
 Disovery better to call with a list of interested tables, since it will load all their samples.
 `, s.connector.Config().Type())),
 		mcp.WithString("tables_list"),
+		mcp.WithToolAnnotation(readOnlyAnnotation()),
 	), s.discoverData)
 	s.server.AddTool(mcp.NewTool(
 		"prepare_query",
@@ -36,17 +44,37 @@ Disovery better to call with a list of interested tables, since it will load all
 This tool shall be executed before query, to examine output structure and verify that query is correct.
 `, s.connector.Config().Type())),
 		mcp.WithString("query", mcp.Required()),
+		mcp.WithToolAnnotation(readOnlyAnnotation()),
 	), s.prepareQuery)
 	s.server.AddTool(mcp.NewTool(
 		"query",
 		mcp.WithDescription(fmt.Sprintf("Query data structure for connected %s gateway", s.connector.Config().Type())),
 		mcp.WithString("query", mcp.Required()),
+		formatOption(),
+		mcp.WithToolAnnotation(s.queryAnnotation()),
 	), s.query)
+	s.exposeTables(false)
+}
+
+// queryAnnotation describes the raw query tool, it runs arbitrary SQL, so it's safe only with a read-only connector.
+func (s *MCPServer) queryAnnotation() mcp.ToolAnnotation {
+	readOnly := s.connector.Config().Readonly()
+	return mcp.ToolAnnotation{
+		ReadOnlyHint:    hint(readOnly),
+		DestructiveHint: hint(!readOnly),
+		IdempotentHint:  hint(readOnly),
+		OpenWorldHint:   hint(false),
+	}
 }
 
 func (s *MCPServer) query(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	resData, err := s.connector.Query(
+	format, err := s.resultFormat(request.Params.Arguments, "", FormatYAML)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.run(
 		ctx,
+		"query",
 		model.Endpoint{Query: request.Params.Arguments["query"].(string)},
 		make(map[string]any),
 	)
@@ -54,31 +82,16 @@ func (s *MCPServer) query(ctx context.Context, request mcp.CallToolRequest) (*mc
 		return nil, xerrors.Errorf("unable to infer query: %w", err)
 	}
 
-	var res []map[string]interface{}
-MAIN:
-	for _, row := range resData {
-		for _, interceptor := range s.interceptors {
-			r, skip := interceptor.Process(row, xcontext.Headers(ctx))
-			if skip {
-				continue MAIN
-			}
-			row = r
-		}
-		res = append(res, row)
-	}
 	var content []mcp.Content
 	content = append(content, mcp.TextContent{
 		Type: "text",
 		Text: fmt.Sprintf("Found %v records-(s).", len(res)),
 	})
-	for _, record := range res {
-		content = append(content, mcp.TextContent{
-			Type: "text",
-			Text: prompter.Yamlify(record),
-		})
-	}
+	rows, meta := formatRows(format, nil, res)
+	meta["rows"] = len(res)
 	return &mcp.CallToolResult{
-		Content: content,
+		Result:  mcp.Result{Meta: meta},
+		Content: append(content, rows...),
 	}, nil
 }
 
@@ -0,0 +1,287 @@
+package mcpgenerator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/prompter"
+	"github.com/centralmind/gateway/server"
+	"golang.org/x/xerrors"
+)
+
+const resourceMIMEType = "application/yaml"
+
+// tableResource is a discovered table that may be exposed as resources.
+type tableResource struct {
+	base      string // db://<schema>/<table>
+	table     model.Table
+	watermark string
+}
+
+// EnableResources exposes discovered tables as MCP resources, so clients can attach table context without tool calls.
+// Every table gets a db://<schema>/<table>/schema resource, tables with a single primary key column also get
+// a db://<schema>/<table>/rows/{pk} template that reads one row through the connector with all plugins and interceptors.
+// SQL tables also get a db://<schema>/<table>/stats resource with the row count and the watermark column maximum,
+// it's what subscriptions to the table poll for changes. Params may be nil.
+// Unless the raw protocol exposes the whole database, only tables queried by configured endpoints are exposed,
+// they follow endpoints set with SetTools.
+func (s *MCPServer) EnableResources(ctx context.Context, params *model.ResourcesParams) error {
+	tables, err := s.connector.Discovery(ctx, nil)
+	if err != nil {
+		return xerrors.Errorf("unable to discover tables: %w", err)
+	}
+	if params == nil {
+		params = &model.ResourcesParams{}
+	}
+	resources := make([]tableResource, 0, len(tables))
+	for _, table := range tables {
+		watermark, err := watermarkColumn(table, params.Tables[table.Name].Watermark)
+		if err != nil {
+			return err
+		}
+		resources = append(resources, tableResource{
+			base:      "db://" + tableResourcePath(table.Name),
+			table:     table,
+			watermark: watermark,
+		})
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watch.init(*params)
+	s.tableResources = resources
+	// resources of tables exposed before are registered again with the new params
+	s.exposeTables(true)
+	return nil
+}
+
+// exposeTables registers resources of tables clients may see and removes resources of tables they no longer may,
+// clients are notified of the changed list. Resources already registered are kept, unless all is set. s.mu must be held.
+func (s *MCPServer) exposeTables(all bool) {
+	if s.tableResources == nil {
+		return
+	}
+	exposed := make(map[string]model.Table)
+	var added []tableResource
+	s.watch.mu.Lock()
+	for _, resource := range s.tableResources {
+		if !s.raw && !slices.ContainsFunc(s.tools, func(endpoint model.Endpoint) bool {
+			return mentionsTable(endpoint.Query, resource.table.Name)
+		}) {
+			continue
+		}
+		exposed[resource.base] = resource.table
+		if _, ok := s.watch.tables[resource.base]; all || !ok {
+			added = append(added, resource)
+		}
+	}
+	var removed []string
+	for base := range s.watch.tables {
+		if _, ok := exposed[base]; !ok {
+			removed = append(removed, base+"/schema", base+"/stats", base+"/rows/{pk}")
+		}
+	}
+	s.watch.tables = exposed
+	s.watch.mu.Unlock()
+
+	if len(removed) > 0 {
+		s.server.DeleteResources(removed...)
+	}
+	for _, resource := range added {
+		s.addTableResources(resource)
+	}
+}
+
+func (s *MCPServer) addTableResources(resource tableResource) {
+	typ := s.connector.Config().Type()
+	rows := runsSQL(typ)
+	table := resource.table
+	s.server.AddResource(mcp.Resource{
+		URI:         resource.base + "/schema",
+		Name:        fmt.Sprintf("%s schema", table.Name),
+		Description: fmt.Sprintf("Columns of %s table with their types, %v rows in total", table.Name, table.RowCount),
+		MIMEType:    resourceMIMEType,
+	}, tableSchema(table))
+
+	if rows {
+		s.server.AddResource(mcp.Resource{
+			URI:         resource.base + "/stats",
+			Name:        fmt.Sprintf("%s stats", table.Name),
+			Description: fmt.Sprintf("Row count of %s table and the latest value of its watermark column, changes with the data", table.Name),
+			MIMEType:    resourceMIMEType,
+		}, s.tableStats(typ, table, resource.watermark))
+	}
+
+	pk, ok := primaryKey(table)
+	if !rows || !ok {
+		return
+	}
+	s.server.AddResourceTemplate(mcp.ResourceTemplate{
+		URITemplate: resource.base + "/rows/{pk}",
+		Name:        fmt.Sprintf("%s row", table.Name),
+		Description: fmt.Sprintf("Single row of %s table by its %s primary key", table.Name, pk.Name),
+		MIMEType:    resourceMIMEType,
+	}, s.tableRow(typ, table, pk))
+}
+
+func tableSchema(table model.Table) server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: resourceMIMEType,
+				Text:     prompter.Yamlify(table),
+			},
+		}, nil
+	}
+}
+
+// watermarkColumn finds the configured watermark column of the table, it defaults to the primary key,
+// which catches inserts with sequential keys, a column like updated_at catches updates too.
+// The configured name is matched case-insensitively, the column is then quoted with its name as discovered.
+func watermarkColumn(table model.Table, watermark string) (string, error) {
+	if watermark == "" {
+		if pk, ok := primaryKey(table); ok {
+			return pk.Name, nil
+		}
+		return "", nil
+	}
+	for _, col := range table.Columns {
+		if strings.EqualFold(col.Name, watermark) {
+			return col.Name, nil
+		}
+	}
+	return "", xerrors.Errorf("watermark column %s not found in %s table", watermark, table.Name)
+}
+
+// tableStats reads what changes when rows are added or modified, the watermark column may be empty.
+func (s *MCPServer) tableStats(typ string, table model.Table, watermark string) server.ResourceHandlerFunc {
+	query := fmt.Sprintf("SELECT COUNT(*) AS row_count FROM %s", quoteIdentifier(typ, table.Name))
+	if watermark != "" {
+		query = fmt.Sprintf("SELECT COUNT(*) AS row_count, MAX(%s) AS watermark FROM %s", quoteIdentifier(typ, watermark), quoteIdentifier(typ, table.Name))
+	}
+	endpoint := model.Endpoint{Query: query}
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		res, err := s.run(ctx, request.Params.URI, endpoint, map[string]any{})
+		if err != nil {
+			return nil, xerrors.Errorf("unable to query: %w", err)
+		}
+		if len(res) == 0 {
+			return nil, xerrors.Errorf("no stats for %s", table.Name)
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: resourceMIMEType,
+				Text:     prompter.Yamlify(res[0]),
+			},
+		}, nil
+	}
+}
+
+func (s *MCPServer) tableRow(typ string, table model.Table, pk model.ColumnSchema) server.ResourceTemplateHandlerFunc {
+	paramType := "string"
+	if pk.Type == model.TypeInteger || pk.Type == model.TypeNumber {
+		paramType = "number"
+	}
+	endpoint := model.Endpoint{
+		Query:  fmt.Sprintf("SELECT * FROM %s WHERE %s = :pk", quoteIdentifier(typ, table.Name), quoteIdentifier(typ, pk.Name)),
+		Params: []model.EndpointParams{{Name: "pk", Type: paramType, Required: true}},
+	}
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		res, err := s.run(ctx, request.Params.URI, endpoint, map[string]any{"pk": request.Params.Arguments["pk"]})
+		if err != nil {
+			return nil, xerrors.Errorf("unable to query: %w", err)
+		}
+		if len(res) == 0 {
+			return nil, xerrors.Errorf("no row in %s with %s = %v", table.Name, pk.Name, request.Params.Arguments["pk"])
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: resourceMIMEType,
+				Text:     prompter.Yamlify(res[0]),
+			},
+		}, nil
+	}
+}
+
+// tableResourcePath turns a table name as returned by discovery, e.g. "public"."users", into public/users.
+func tableResourcePath(name string) string {
+	return strings.Join(identifierParts(name), "/")
+}
+
+// quoteIdentifier quotes every part of a name as returned by discovery, e.g. public.Users or [dbo].[orders],
+// so mixed-case names and reserved words work in generated queries.
+func quoteIdentifier(typ, name string) string {
+	parts := identifierParts(name)
+	for i, part := range parts {
+		switch typ {
+		case "mssql":
+			parts[i] = "[" + strings.ReplaceAll(part, "]", "]]") + "]"
+		case "mysql", "clickhouse", "bigquery":
+			parts[i] = "`" + strings.ReplaceAll(part, "`", "``") + "`"
+		default:
+			parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// identifierParts splits a possibly quoted and qualified name by dots outside quotes and removes the quotes.
+func identifierParts(name string) []string {
+	var parts []string
+	var part strings.Builder
+	var closing rune
+	runes := []rune(name)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case closing != 0 && r == closing:
+			// a doubled closing quote is an escaped one
+			if i+1 < len(runes) && runes[i+1] == closing {
+				part.WriteRune(r)
+				i++
+			} else {
+				closing = 0
+			}
+		case closing != 0:
+			part.WriteRune(r)
+		case r == '"' || r == '`':
+			closing = r
+		case r == '[':
+			closing = ']'
+		case r == '.':
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteRune(r)
+		}
+	}
+	return append(parts, part.String())
+}
+
+func primaryKey(table model.Table) (model.ColumnSchema, bool) {
+	var pks []model.ColumnSchema
+	for _, col := range table.Columns {
+		if col.PrimaryKey {
+			pks = append(pks, col)
+		}
+	}
+	if len(pks) != 1 {
+		return model.ColumnSchema{}, false
+	}
+	return pks[0], true
+}
+
+// runsSQL reports whether the connector runs SQL queries, generated queries can't be used with others.
+func runsSQL(typ string) bool {
+	switch typ {
+	case "mongodb", "elasticsearch":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+package mcpgenerator
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/centralmind/gateway/connectors/connectortest"
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func handle(t *testing.T, srv *MCPServer, method string, params any) mcp.JSONRPCMessage {
+	raw, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	require.NoError(t, err)
+	return srv.Server().HandleMessage(context.Background(), raw)
+}
+
+func readResource(t *testing.T, srv *MCPServer, uri string) string {
+	resp, ok := handle(t, srv, "resources/read", map[string]any{"uri": uri}).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	result := resp.Result.(mcp.ReadResourceResult)
+	require.Len(t, result.Contents, 1)
+	return result.Contents[0].(mcp.TextResourceContents).Text
+}
+
+func TestEnableResources(t *testing.T) {
+	srv, err := New(nil)
+	require.NoError(t, err)
+	connector := &connectortest.Connector{}
+	require.NoError(t, srv.SetConnector(connector))
+	srv.EnableRawProtocol()
+	require.NoError(t, srv.EnableResources(context.Background(), nil))
+
+	resp, ok := handle(t, srv, "resources/list", map[string]any{}).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	resources := resp.Result.(mcp.ListResourcesResult).Resources
+	require.Len(t, resources, 2)
+	assert.ElementsMatch(t, []string{"db://users/schema", "db://users/stats"}, []string{resources[0].URI, resources[1].URI})
+
+	resp, ok = handle(t, srv, "resources/templates/list", map[string]any{}).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	templates := resp.Result.(mcp.ListResourceTemplatesResult).ResourceTemplates
+	require.Len(t, templates, 1)
+	assert.Equal(t, "db://users/rows/{pk}", templates[0].URITemplate)
+
+	assert.Contains(t, readResource(t, srv, "db://users/schema"), "primary_key: true")
+	assert.Contains(t, readResource(t, srv, "db://users/rows/7"), "count: 42")
+	assert.Contains(t, readResource(t, srv, "db://users/stats"), "count: 42")
+	assert.Equal(t, []string{
+		`SELECT * FROM "users" WHERE "id" = :pk`,
+		`SELECT COUNT(*) AS row_count, MAX("id") AS watermark FROM "users"`,
+	}, connector.Queries)
+}
+
+func listResources(t *testing.T, srv *MCPServer) []string {
+	resp, ok := handle(t, srv, "resources/list", map[string]any{}).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	var uris []string
+	for _, resource := range resp.Result.(mcp.ListResourcesResult).Resources {
+		uris = append(uris, resource.URI)
+	}
+	return uris
+}
+
+func TestResourcesOfEndpoints(t *testing.T) {
+	srv, err := New(nil)
+	require.NoError(t, err)
+	require.NoError(t, srv.SetConnector(&connectortest.Connector{Tables: []model.Table{
+		{Name: `"public"."users"`, Columns: []model.ColumnSchema{{Name: "id", Type: model.TypeInteger, PrimaryKey: true}}},
+		{Name: `"public"."secrets"`, Columns: []model.ColumnSchema{{Name: "token", Type: model.TypeString}}},
+	}}))
+	srv.SetTools([]model.Endpoint{{MCPMethod: "list_users", Query: "SELECT * FROM public.users"}})
+	require.NoError(t, srv.EnableResources(context.Background(), nil))
+	assert.ElementsMatch(t, []string{"db://public/users/schema", "db://public/users/stats"}, listResources(t, srv),
+		"only tables queried by endpoints are exposed outside raw mode")
+
+	_ = srv.Server().HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`))
+	queue := srv.Server().RegisterSession("session")
+	t.Cleanup(func() { srv.Server().UnregisterSession("session") })
+	srv.SetTools([]model.Endpoint{{MCPMethod: "list_secrets", Query: "SELECT token FROM secrets"}})
+	assert.ElementsMatch(t, []string{"db://public/secrets/schema", "db://public/secrets/stats"}, listResources(t, srv))
+
+	var methods []string
+	for len(queue) > 0 {
+		methods = append(methods, (<-queue).Notification.Method)
+	}
+	assert.Contains(t, methods, "notifications/resources/list_changed")
+}
+
+func TestTableResourcePath(t *testing.T) {
+	assert.Equal(t, "public/users", tableResourcePath(`"public"."users"`))
+	assert.Equal(t, "dbo/orders", tableResourcePath("[dbo].[orders]"))
+	assert.Equal(t, "users", tableResourcePath("users"))
+	assert.Equal(t, "my.schema/users", tableResourcePath(`"my.schema".users`))
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert.Equal(t, `"public"."Order"`, quoteIdentifier("postgres", `"public"."Order"`))
+	assert.Equal(t, `"SALES"."ORDER"`, quoteIdentifier("oracle", "SALES.ORDER"))
+	assert.Equal(t, `[dbo].[user]`, quoteIdentifier("mssql", "[dbo].[user]"))
+	assert.Equal(t, "`shop`.`order`", quoteIdentifier("mysql", "shop.order"))
+	assert.Equal(t, `"say ""hi"""`, quoteIdentifier("postgres", `"say ""hi"""`))
+	assert.Equal(t, `[a]]b]`, quoteIdentifier("mssql", "[a]]b]"))
+}
+
+func TestResourcesWatermark(t *testing.T) {
+	srv, err := New(nil)
+	require.NoError(t, err)
+	connector := &connectortest.Connector{
+		DBConfig: connectortest.Config{DBType: "mssql"},
+		Tables: []model.Table{{Name: "[dbo].[Order]", Columns: []model.ColumnSchema{
+			{Name: "Id", Type: model.TypeInteger, PrimaryKey: true},
+			{Name: "UpdatedAt", Type: model.TypeDatetime},
+		}}},
+	}
+	require.NoError(t, srv.SetConnector(connector))
+	srv.EnableRawProtocol()
+	require.NoError(t, srv.EnableResources(context.Background(), &model.ResourcesParams{
+		Tables: map[string]model.TableResource{"[dbo].[Order]": {Watermark: "updatedat"}},
+	}))
+
+	readResource(t, srv, "db://dbo/Order/stats")
+	readResource(t, srv, "db://dbo/Order/rows/1")
+	assert.Equal(t, []string{
+		"SELECT COUNT(*) AS row_count, MAX([UpdatedAt]) AS watermark FROM [dbo].[Order]",
+		"SELECT * FROM [dbo].[Order] WHERE [Id] = :pk",
+	}, connector.Queries)
+
+	err = srv.EnableResources(context.Background(), &model.ResourcesParams{
+		Tables: map[string]model.TableResource{"[dbo].[Order]": {Watermark: "changed_at"}},
+	})
+	assert.ErrorContains(t, err, "watermark column changed_at not found")
+}
@@ -0,0 +1,165 @@
+package mcpgenerator
+
+import (
+	"context"
+	"crypto/sha256"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/xcontext"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultPollInterval = 30 * time.Second
+
+// watchState tracks resources watched for changes while clients are subscribed to them.
+type watchState struct {
+	mu          sync.Mutex
+	params      model.ResourcesParams
+	tables      map[string]model.Table                    // db://<schema>/<table> -> table
+	subscribers map[string]map[string]map[string][]string // URI -> session ID -> headers of the session
+	watchers    map[string]resourceWatcher                // URI -> running watcher
+}
+
+// resourceWatcher is a running watch of a resource with headers of one of its subscribers.
+type resourceWatcher struct {
+	session string
+	cancel  context.CancelFunc
+}
+
+func (w *watchState) init(params model.ResourcesParams) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.params = params
+}
+
+// watchResource is the subscription handler of the MCP server: it starts watching a resource when the first client
+// subscribes and stops when the last one leaves. Queries of the watcher run with headers of a subscriber,
+// so auth plugins and interceptors apply to it the same way as to reads. When that subscriber leaves,
+// the watch is restarted with headers of a remaining one.
+func (s *MCPServer) watchResource(ctx context.Context, uri, sessionID string, subscribed bool) error {
+	s.watch.mu.Lock()
+	defer s.watch.mu.Unlock()
+	if s.watch.subscribers == nil {
+		s.watch.subscribers = make(map[string]map[string]map[string][]string)
+		s.watch.watchers = make(map[string]resourceWatcher)
+	}
+	if subscribed {
+		if s.watch.subscribers[uri] == nil {
+			s.watch.subscribers[uri] = make(map[string]map[string][]string)
+		}
+		s.watch.subscribers[uri][sessionID] = xcontext.Headers(ctx)
+		if _, ok := s.watch.watchers[uri]; !ok {
+			s.startWatch(uri, sessionID)
+		}
+		return nil
+	}
+
+	delete(s.watch.subscribers[uri], sessionID)
+	watcher, ok := s.watch.watchers[uri]
+	if !ok || watcher.session != sessionID {
+		return nil
+	}
+	watcher.cancel()
+	delete(s.watch.watchers, uri)
+	for remaining := range s.watch.subscribers[uri] {
+		s.startWatch(uri, remaining)
+		return nil
+	}
+	delete(s.watch.subscribers, uri)
+	return nil
+}
+
+// startWatch watches the resource with headers of the subscribed session, s.watch.mu must be held.
+func (s *MCPServer) startWatch(uri, sessionID string) {
+	watchCtx := xcontext.WithSession(context.Background(), sessionID)
+	watchCtx = xcontext.WithHeader(watchCtx, s.watch.subscribers[uri][sessionID])
+	watchCtx, cancel := context.WithCancel(watchCtx)
+	s.watch.watchers[uri] = resourceWatcher{session: sessionID, cancel: cancel}
+
+	table, tableURI, ok := s.watch.table(uri)
+	pollURI := uri
+	var channel string
+	if ok {
+		channel = s.watch.params.Tables[table.Name].NotifyChannel
+		// schema and stats of a table change together with its data, which shows in stats
+		if !strings.HasPrefix(uri, tableURI+"/rows/") && runsSQL(s.connector.Config().Type()) {
+			pollURI = tableURI + "/stats"
+		}
+	}
+	interval := s.watch.params.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	go s.watchChanges(watchCtx, uri, pollURI, channel, interval)
+}
+
+// table finds the table a resource URI belongs to.
+func (w *watchState) table(uri string) (model.Table, string, bool) {
+	for tableURI, table := range w.tables {
+		if strings.HasPrefix(uri, tableURI+"/") {
+			return table, tableURI, true
+		}
+	}
+	return model.Table{}, "", false
+}
+
+// watchChanges notifies subscribers of uri until the context is cancelled. Changes are pushed by the connector
+// when the table has a notify channel and the connector can listen to it, otherwise pollURI is read every interval
+// and compared by its checksum.
+func (s *MCPServer) watchChanges(ctx context.Context, uri, pollURI, channel string, interval time.Duration) {
+	if channel != "" && s.listener != nil {
+		err := s.listener.Listen(ctx, channel, func() {
+			s.server.NotifyResourceUpdated(uri)
+		})
+		if err == nil {
+			return
+		}
+		logrus.Warnf("Unable to listen to %s for changes of %s, falling back to polling: %v", channel, uri, err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last, _ := s.resourceChecksum(ctx, pollURI)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		sum, err := s.resourceChecksum(ctx, pollURI)
+		if err != nil {
+			if ctx.Err() == nil {
+				logrus.Warnf("Unable to check %s for changes: %v", pollURI, err)
+			}
+			continue
+		}
+		if last != "" && sum != last {
+			s.server.NotifyResourceUpdated(uri)
+		}
+		last = sum
+	}
+}
+
+func (s *MCPServer) resourceChecksum(ctx context.Context, uri string) (string, error) {
+	var request mcp.ReadResourceRequest
+	request.Params.URI = uri
+	contents, err := s.server.ReadResource(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	for _, content := range contents {
+		switch c := content.(type) {
+		case mcp.TextResourceContents:
+			hash.Write([]byte(c.Text))
+		case mcp.BlobResourceContents:
+			hash.Write([]byte(c.Blob))
+		}
+		hash.Write([]byte{0})
+	}
+	return string(hash.Sum(nil)), nil
+}
@@ -0,0 +1,128 @@
+package mcpgenerator
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/centralmind/gateway/connectors/connectortest"
+	"github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/server"
+	"github.com/centralmind/gateway/xcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// changingConnector returns the version as the table stats, and pushes changes to listeners of the channel.
+type changingConnector struct {
+	connectortest.Connector
+	version  atomic.Int64
+	mu       sync.Mutex
+	onChange func()
+	user     string
+}
+
+func (c *changingConnector) Query(ctx context.Context, endpoint model.Endpoint, params map[string]any) ([]map[string]any, error) {
+	c.mu.Lock()
+	c.user = xcontext.Header(ctx, "X-User")
+	c.mu.Unlock()
+	return []map[string]any{{"row_count": c.version.Load()}}, nil
+}
+
+func (c *changingConnector) lastUser() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.user
+}
+
+func (c *changingConnector) Listen(ctx context.Context, channel string, onChange func()) error {
+	c.mu.Lock()
+	c.onChange = onChange
+	c.mu.Unlock()
+	<-ctx.Done()
+	return nil
+}
+
+func (c *changingConnector) push() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.onChange == nil {
+		return false
+	}
+	c.onChange()
+	return true
+}
+
+func subscribeSession(t *testing.T, srv *MCPServer, sessionID, uri string) <-chan server.ServerNotification {
+	queue := srv.Server().RegisterSession(sessionID)
+	t.Cleanup(func() { srv.Server().UnregisterSession(sessionID) })
+	ctx := xcontext.WithHeader(context.Background(), map[string][]string{"X-User": {sessionID}})
+	ctx = srv.Server().WithContext(ctx, server.NotificationContext{ClientID: sessionID, SessionID: sessionID})
+	raw := `{"jsonrpc": "2.0", "id": 1, "method": "resources/subscribe", "params": {"uri": "` + uri + `"}}`
+	srv.Server().HandleMessage(ctx, []byte(raw))
+	return queue
+}
+
+func TestResourceSubscriptionPolling(t *testing.T) {
+	srv, err := New(nil)
+	require.NoError(t, err)
+	connector := &changingConnector{}
+	require.NoError(t, srv.SetConnector(connector))
+	srv.EnableRawProtocol()
+	require.NoError(t, srv.EnableResources(context.Background(), &model.ResourcesParams{PollInterval: 10 * time.Millisecond}))
+
+	assert.Contains(t, readResource(t, srv, "db://users/stats"), "row_count: 0")
+	queue := subscribeSession(t, srv, "session-1", "db://users/schema")
+
+	// nothing changed yet
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, queue)
+
+	connector.version.Add(1)
+	select {
+	case notification := <-queue:
+		assert.Equal(t, "notifications/resources/updated", notification.Notification.Method)
+		assert.Equal(t, "db://users/schema", notification.Notification.Params.AdditionalFields["uri"])
+	case <-time.After(time.Second):
+		t.Fatal("no notification after the table changed")
+	}
+}
+
+func TestResourceSubscriptionListen(t *testing.T) {
+	srv, err := New(nil)
+	require.NoError(t, err)
+	connector := &changingConnector{}
+	require.NoError(t, srv.SetConnector(connector))
+	srv.EnableRawProtocol()
+	require.NoError(t, srv.EnableResources(context.Background(), &model.ResourcesParams{
+		Tables: map[string]model.TableResource{"users": {NotifyChannel: "users_changed"}},
+	}))
+
+	queue := subscribeSession(t, srv, "session-1", "db://users/stats")
+	require.Eventually(t, connector.push, time.Second, 10*time.Millisecond)
+	select {
+	case notification := <-queue:
+		assert.Equal(t, "db://users/stats", notification.Notification.Params.AdditionalFields["uri"])
+	case <-time.After(time.Second):
+		t.Fatal("no notification for the pushed change")
+	}
+}
+
+func TestResourceSubscriptionHeaders(t *testing.T) {
+	srv, err := New(nil)
+	require.NoError(t, err)
+	connector := &changingConnector{}
+	require.NoError(t, srv.SetConnector(connector))
+	srv.EnableRawProtocol()
+	require.NoError(t, srv.EnableResources(context.Background(), &model.ResourcesParams{PollInterval: 10 * time.Millisecond}))
+
+	subscribeSession(t, srv, "session-1", "db://users/schema")
+	subscribeSession(t, srv, "session-2", "db://users/schema")
+	require.Eventually(t, func() bool { return connector.lastUser() == "session-1" }, time.Second, 10*time.Millisecond)
+
+	// the watch continues with headers of the remaining subscriber
+	srv.Server().UnregisterSession("session-1")
+	require.Eventually(t, func() bool { return connector.lastUser() == "session-2" }, time.Second, 10*time.Millisecond)
+}
@@ -3,16 +3,26 @@ package mcpgenerator
 import (
 	"context"
 	"fmt"
+	"time"
+
 	"github.com/centralmind/gateway/mcp"
 	"github.com/centralmind/gateway/model"
-	"github.com/centralmind/gateway/xcontext"
+	"github.com/sirupsen/logrus"
 )
 
+// inferTimeout limits how long result columns of an endpoint are inferred, inference runs the whole query.
+const inferTimeout = 15 * time.Second
+
 func (s *MCPServer) Tools() []model.Endpoint {
 	return s.tools
 }
 
 func (s *MCPServer) SetTools(tools []model.Endpoint) {
+	// inference runs queries, other calls must not wait for it
+	columns := make([][]model.ColumnSchema, len(tools))
+	for i, endpoint := range tools {
+		columns[i] = s.inferColumns(endpoint)
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	var names []string
@@ -20,7 +30,7 @@ func (s *MCPServer) SetTools(tools []model.Endpoint) {
 		names = append(names, t.MCPMethod)
 	}
 	s.server.DeleteTools(names...)
-	for _, endpoint := range tools {
+	for i, endpoint := range tools {
 		var opts []mcp.ToolOption
 		if endpoint.Description != "" {
 			opts = append(opts, mcp.WithDescription(endpoint.Description))
@@ -34,24 +44,66 @@ func (s *MCPServer) SetTools(tools []model.Endpoint) {
 				opts = append(opts, ArgumentOption(col))
 			}
 		}
-
+		if !hasParam(endpoint, formatArgument) {
+			opts = append(opts, formatOption())
+		}
+		opts = append(opts, mcp.WithToolAnnotation(s.endpointAnnotation(endpoint)))
+		format, err := ParseResultFormat(endpoint.Format)
+		if err != nil {
+			logrus.Warnf("endpoint %s: %v", endpoint.MCPMethod, err)
+		}
+		// a tool with an output schema must always return structured rows, it's only declared for tools
+		// that return JSON by default, so other formats don't send the rows twice
+		cols := columns[i]
+		schema := outputSchema(cols)
+		structured := schema != nil && s.defaultFormat(format) == FormatJSON
+		if structured {
+			opts = append(opts, schema)
+		}
+		names := make([]string, len(cols))
+		for i, col := range cols {
+			names[i] = col.Name
+		}
 		s.server.AddTool(mcp.NewTool(
 			endpoint.MCPMethod,
 			opts...,
-		), s.endpoint(endpoint))
+		), s.endpoint(endpoint, format, names, structured))
+		s.groups[endpoint.MCPMethod] = endpoint.Group
 	}
 	s.tools = tools
+	s.exposeTables(false)
 }
 
-func (s *MCPServer) endpoint(endpoint model.Endpoint) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+// endpoint returns the handler of an endpoint tool. Rows are returned as structured content in the JSON format,
+// with any format if the tool declares an output schema, or if the caller asks for them with withStructuredRows.
+func (s *MCPServer) endpoint(endpoint model.Endpoint, configured ResultFormat, columns []string, structured bool) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// an endpoint param named format is passed to the query
+	ownFormat := hasParam(endpoint, formatArgument)
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		arg := request.Params.Arguments
+		if arg == nil {
+			arg = map[string]interface{}{}
+		}
+		formatArg := arg
+		if ownFormat {
+			formatArg = nil
+		}
+		format, err := s.resultFormat(formatArg, configured, FormatJSON)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{mcp.NewTextContent(err.Error())},
+				IsError: true,
+			}, nil
+		}
+		if !ownFormat {
+			delete(arg, formatArgument)
+		}
 		for _, param := range endpoint.Params {
 			if _, ok := arg[param.Name]; !ok {
 				arg[param.Name] = nil
 			}
 		}
-		resData, err := s.connector.Query(ctx, endpoint, request.Params.Arguments)
+		res, err := s.run(ctx, endpoint.MCPMethod, endpoint, arg)
 		if err != nil {
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
@@ -63,34 +115,100 @@ func (s *MCPServer) endpoint(endpoint model.Endpoint) func(ctx context.Context,
 				IsError: true,
 			}, nil
 		}
-		var res []map[string]interface{}
-	MAIN:
-		for _, row := range resData {
-			for _, interceptor := range s.interceptors {
-				r, skip := interceptor.Process(row, xcontext.Headers(ctx))
-				if skip {
-					continue MAIN
-				}
-				row = r
-			}
-			res = append(res, row)
+		if res == nil {
+			res = []map[string]any{}
 		}
 		var content []mcp.Content
 		content = append(content, mcp.TextContent{
 			Type: "text",
 			Text: fmt.Sprintf("Found a %v row-(s) in %s.", len(res), endpoint.Group),
 		})
-		for _, row := range res {
-			content = append(content, mcp.TextContent{
-				Type: "text",
-				Text: jsonify(row),
-			})
+		rows, meta := formatRows(format, columns, res)
+		meta["rows"] = len(res)
+
+		result := &mcp.CallToolResult{
+			Result:  mcp.Result{Meta: meta},
+			Content: append(content, rows...),
+		}
+		if structured || format == FormatJSON || ctx.Value(structuredRowsKey{}) != nil {
+			result.StructuredContent = map[string]any{"rows": res}
+			// the client receives the rows twice, as text and as structured content
+			if s.server.Supports(ctx, mcp.FeatureStructuredOutput) {
+				meta["estimated_tokens"] = meta["estimated_tokens"].(int) + estimateTokens(len(jsonify(result.StructuredContent)))
+			}
+		}
+		return result, nil
+	}
+}
+
+// structuredRowsKey marks calls of endpoint tools that read rows from structured content, e.g. data of prompts.
+type structuredRowsKey struct{}
+
+func withStructuredRows(ctx context.Context) context.Context {
+	return context.WithValue(ctx, structuredRowsKey{}, true)
+}
+
+// defaultFormat is the format of calls without the format argument, s.mu must be held.
+func (s *MCPServer) defaultFormat(configured ResultFormat) ResultFormat {
+	switch {
+	case configured != "":
+		return configured
+	case s.format != "":
+		return s.format
+	}
+	return FormatJSON
+}
+
+// inferColumns returns result columns of the endpoint query, nil if the connector can't infer them in inferTimeout.
+func (s *MCPServer) inferColumns(endpoint model.Endpoint) []model.ColumnSchema {
+	if s.connector == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), inferTimeout)
+	defer cancel()
+	cols, err := s.connector.InferQuery(ctx, endpoint.Query)
+	if err != nil {
+		logrus.Warnf("unable to infer query %s: %v", endpoint.Query, err)
+		return nil
+	}
+	return cols
+}
+
+// outputSchema describes result rows of an endpoint with columns inferred from its query.
+// Columns are nullable, since inference can't tell if a column may be empty.
+func outputSchema(cols []model.ColumnSchema) mcp.ToolOption {
+	if len(cols) == 0 {
+		return nil
+	}
+	props := map[string]interface{}{}
+	for _, col := range cols {
+		prop := map[string]interface{}{
+			"type": []string{string(col.Type), string(model.TypeNull)},
+		}
+		if col.Type == model.TypeDatetime {
+			prop["type"] = []string{string(model.TypeString), string(model.TypeNull)}
+			prop["format"] = "date-time"
 		}
+		props[col.Name] = prop
+	}
+	return mcp.WithOutputSchema(map[string]interface{}{
+		"rows": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type":       "object",
+				"properties": props,
+			},
+		},
+	}, "rows")
+}
 
-		return &mcp.CallToolResult{
-			Content: content,
-		}, nil
+func hasParam(endpoint model.Endpoint, name string) bool {
+	for _, param := range endpoint.Params {
+		if param.Name == name {
+			return true
+		}
 	}
+	return false
 }
 
 func ArgumentOption(col model.EndpointParams, opts ...mcp.PropertyOption) mcp.ToolOption {
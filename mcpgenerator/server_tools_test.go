@@ -3,9 +3,13 @@ package mcpgenerator
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/centralmind/gateway/connectors/connectortest"
 	"github.com/centralmind/gateway/mcp"
 	"github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/server"
+	"github.com/centralmind/gateway/xcontext"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -35,3 +39,147 @@ func TestSetToolsIncludesDescription(t *testing.T) {
 		assert.Equal(t, "sample description", tools[0].Description)
 	}
 }
+
+func TestSetToolsStructuredOutput(t *testing.T) {
+	srv, err := New(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, srv.SetConnector(&connectortest.Connector{}))
+	ctx := context.Background()
+	_ = srv.Server().HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`))
+
+	srv.SetTools([]model.Endpoint{{
+		MCPMethod: "count_users",
+		Query:     "SELECT count(*) AS count FROM users",
+	}})
+
+	resp := srv.Server().HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`))
+	tools := resp.(mcp.JSONRPCResponse).Result.(mcp.ListToolsResult).Tools
+	if assert.Len(t, tools, 1) && assert.NotNil(t, tools[0].OutputSchema) {
+		assert.Equal(t, "object", tools[0].OutputSchema.Type)
+		assert.Equal(t, []string{"rows"}, tools[0].OutputSchema.Required)
+		rows := tools[0].OutputSchema.Properties["rows"].(map[string]interface{})
+		props := rows["items"].(map[string]interface{})["properties"].(map[string]interface{})
+		assert.Equal(t, []string{"integer", "null"}, props["count"].(map[string]interface{})["type"])
+	}
+
+	resp = srv.Server().HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"count_users","arguments":{}}}`))
+	result := resp.(mcp.JSONRPCResponse).Result.(*mcp.CallToolResult)
+	assert.False(t, result.IsError)
+	assert.NotEmpty(t, result.Content, "text content must stay as a fallback")
+	assert.Len(t, result.StructuredContent["rows"], 1)
+}
+
+func TestToolCallLogging(t *testing.T) {
+	srv, err := New(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, srv.SetConnector(&connectortest.Connector{}))
+	srv.SetTools([]model.Endpoint{{
+		MCPMethod: "count_users",
+		Query:     "SELECT count(*) AS count FROM users",
+	}})
+
+	queue := srv.Server().RegisterSession("session-1")
+	ctx := srv.Server().WithContext(context.Background(), server.NotificationContext{ClientID: "session-1", SessionID: "session-1"})
+	_ = srv.Server().HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"logging/setLevel","params":{"level":"debug"}}`))
+	_ = srv.Server().HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"count_users","arguments":{}}}`))
+
+	if !assert.Len(t, queue, 2) {
+		return
+	}
+	executed := (<-queue).Notification.Params.AdditionalFields
+	assert.Equal(t, mcp.LoggingLevelDebug, executed["level"])
+	assert.Equal(t, "SELECT count(*) AS count FROM users", executed["data"].(map[string]any)["query"])
+	completed := (<-queue).Notification.Params.AdditionalFields
+	assert.Equal(t, mcp.LoggingLevelInfo, completed["level"])
+	assert.Equal(t, "count_users", completed["data"].(map[string]any)["source"])
+	assert.Equal(t, 1, completed["data"].(map[string]any)["rows"])
+}
+
+func TestToolGroups(t *testing.T) {
+	srv, err := New(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, srv.SetConnector(&connectortest.Connector{}))
+	srv.SetTools([]model.Endpoint{
+		{Group: "orders", MCPMethod: "list_orders", Query: "SELECT * FROM orders"},
+		{Group: "users", MCPMethod: "list_users", Query: "SELECT * FROM users"},
+	})
+	srv.EnableRawProtocol()
+
+	list := func(ctx context.Context) []string {
+		var names []string
+		for _, tool := range srv.Server().ListToolsFor(ctx) {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+	assert.Len(t, list(context.Background()), 6)
+	assert.Equal(t, []string{"list_orders"}, list(xcontext.WithToolGroups(context.Background(), []string{"orders"})))
+	assert.Equal(
+		t,
+		[]string{"discover_data", "list_tables", "list_users", "prepare_query", "query"},
+		list(xcontext.WithToolGroups(context.Background(), []string{"users", "raw"})),
+	)
+}
+
+// inferringConnector tells how result columns were inferred.
+type inferringConnector struct {
+	connectortest.Connector
+	srv      *MCPServer
+	deadline bool
+	locked   bool
+}
+
+func (c *inferringConnector) InferQuery(ctx context.Context, query string) ([]model.ColumnSchema, error) {
+	_, c.deadline = ctx.Deadline()
+	if c.srv.mu.TryLock() {
+		c.srv.mu.Unlock()
+	} else {
+		c.locked = true
+	}
+	return c.Connector.InferQuery(ctx, query)
+}
+
+func TestSetToolsInfersOutsideLock(t *testing.T) {
+	srv, err := New(nil)
+	assert.NoError(t, err)
+	connector := &inferringConnector{srv: srv}
+	assert.NoError(t, srv.SetConnector(connector))
+
+	srv.SetTools([]model.Endpoint{{MCPMethod: "count_users", Query: "SELECT count(*) AS count FROM users"}})
+	assert.True(t, connector.deadline, "inference must not run without a timeout")
+	assert.False(t, connector.locked, "inference must not block other calls")
+}
+
+// blockingConnector runs every query until its context is done and reports the context error.
+type blockingConnector struct {
+	connectortest.Connector
+	started chan struct{}
+	errs    chan error
+}
+
+func (c *blockingConnector) Query(ctx context.Context, endpoint model.Endpoint, params map[string]any) ([]map[string]any, error) {
+	close(c.started)
+	<-ctx.Done()
+	c.errs <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func TestToolCallCancelsQuery(t *testing.T) {
+	srv, err := New(nil)
+	assert.NoError(t, err)
+	connector := &blockingConnector{started: make(chan struct{}), errs: make(chan error, 1)}
+	assert.NoError(t, srv.SetConnector(connector))
+	srv.SetTools([]model.Endpoint{{MCPMethod: "slow_users", Query: "SELECT * FROM users"}})
+
+	ctx := xcontext.WithSession(context.Background(), "session-1")
+	go srv.Server().HandleMessage(ctx, []byte(`{"jsonrpc": "2.0", "id": 3, "method": "tools/call", "params": {"name": "slow_users"}}`))
+	<-connector.started
+	srv.Server().HandleMessage(ctx, []byte(`{"jsonrpc": "2.0", "method": "notifications/cancelled", "params": {"requestId": 3}}`))
+
+	select {
+	case err := <-connector.errs:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("query was not cancelled")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"strings"
+	"time"
 
 	"golang.org/x/xerrors"
 	"gopkg.in/yaml.v3"
@@ -78,6 +79,27 @@ type Config struct {
 	API      APIParams      `yaml:"api" json:"api"`
 	Database Database       `yaml:"database" json:"database"`
 	Plugins  map[string]any `yaml:"plugins" json:"plugins"`
+	AI       *AIParams      `yaml:"ai,omitempty" json:"ai,omitempty"`
+	Prompts  []Prompt       `yaml:"prompts,omitempty" json:"prompts,omitempty"`
+	// Resources configures how changes of table resources are detected for MCP subscriptions.
+	Resources *ResourcesParams `yaml:"resources,omitempty" json:"resources,omitempty"`
+	// ResultFormat is the format of MCP tool results: json, yaml, csv, tsv, markdown or columnar.
+	ResultFormat string `yaml:"result_format,omitempty" json:"result_format,omitempty"`
+}
+
+type ResourcesParams struct {
+	// PollInterval is how often subscribed resources are checked for changes, 30s by default.
+	PollInterval time.Duration `yaml:"poll_interval,omitempty" json:"poll_interval,omitempty"`
+	// Tables configure change detection per table, by its name as returned by discovery.
+	Tables map[string]TableResource `yaml:"tables,omitempty" json:"tables,omitempty"`
+}
+
+type TableResource struct {
+	// Watermark is a column that grows with every change, e.g. updated_at. Defaults to the primary key.
+	Watermark string `yaml:"watermark,omitempty" json:"watermark,omitempty"`
+	// NotifyChannel is a channel that a trigger notifies on table changes, used instead of polling
+	// by connectors that support it, e.g. postgres LISTEN/NOTIFY.
+	NotifyChannel string `yaml:"notify_channel,omitempty" json:"notify_channel,omitempty"`
 }
 
 func FromYaml(raw []byte) (*Config, error) {
@@ -196,6 +218,60 @@ type APIParams struct {
 	Version     string `yaml:"version" json:"version,omitempty"`
 }
 
+// AIParams configures the model used by AI features of the running gateway.
+type AIParams struct {
+	Provider        string `yaml:"provider" json:"provider,omitempty"`
+	Model           string `yaml:"model,omitempty" json:"model,omitempty"`
+	APIKey          string `yaml:"api_key,omitempty" json:"api_key,omitempty"`
+	Endpoint        string `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
+	BedrockRegion   string `yaml:"bedrock_region,omitempty" json:"bedrock_region,omitempty"`
+	VertexAIRegion  string `yaml:"vertexai_region,omitempty" json:"vertexai_region,omitempty"`
+	VertexAIProject string `yaml:"vertexai_project,omitempty" json:"vertexai_project,omitempty"`
+	// Ask enables the ask MCP tool that answers questions in natural language.
+	Ask bool `yaml:"ask,omitempty" json:"ask,omitempty"`
+	// Chat serves an OpenAI-compatible chat completions API that answers with gateway tools.
+	Chat *ChatParams `yaml:"chat,omitempty" json:"chat,omitempty"`
+}
+
+// ChatParams configures the OpenAI-compatible chat completions endpoint.
+type ChatParams struct {
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty"`
+	// RawTools exposes all MCP tools to the model, not only configured endpoints.
+	RawTools bool `yaml:"raw_tools,omitempty" json:"raw_tools,omitempty"`
+	// MaxRounds limits tool calling rounds of a single completion.
+	MaxRounds int `yaml:"max_rounds,omitempty" json:"max_rounds,omitempty"`
+	// SystemPrompt replaces the default instructions sent to the model.
+	SystemPrompt string `yaml:"system_prompt,omitempty" json:"system_prompt,omitempty"`
+}
+
+// Prompt is an MCP prompt, its template is rendered with text/template.
+// Arguments are available by name, e.g. {{.account_id}}, rows of data endpoints by their mcp_method, e.g. {{yaml .get_account}}.
+type Prompt struct {
+	Name        string           `yaml:"name" json:"name"`
+	Description string           `yaml:"description,omitempty" json:"description,omitempty"`
+	Arguments   []PromptArgument `yaml:"arguments,omitempty" json:"arguments,omitempty"`
+	Data        []PromptData     `yaml:"data,omitempty" json:"data,omitempty"`
+	Template    string           `yaml:"template" json:"template"`
+}
+
+type PromptArgument struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Required    bool   `yaml:"required,omitempty" json:"required,omitempty"`
+	// Completion configures suggestions of argument values,
+	// by default they are taken from the endpoint param the argument is passed to.
+	Completion *Completion `yaml:"completion,omitempty" json:"completion,omitempty"`
+}
+
+// PromptData is an endpoint queried before the prompt is rendered.
+type PromptData struct {
+	// Endpoint is the mcp_method of a configured endpoint.
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+	// Params are templates of endpoint parameters rendered with prompt arguments,
+	// if empty, arguments with the same names as endpoint parameters are passed.
+	Params map[string]string `yaml:"params,omitempty" json:"params,omitempty"`
+}
+
 type Database struct {
 	Type       string               `yaml:"type" json:"type,omitempty"`
 	Connection any                  `yaml:"connection" json:"connection,omitempty"`
@@ -248,6 +324,24 @@ type Endpoint struct {
 	Query         string           `yaml:"query" json:"query,omitempty"`
 	IsArrayResult bool             `yaml:"is_array_result" json:"is_array_result,omitempty"`
 	Params        []EndpointParams `yaml:"params" json:"params,omitempty"`
+	// Annotations override MCP tool hints inferred from the HTTP method and the query.
+	Annotations *EndpointAnnotations `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+	// Format overrides the result format of the MCP tool, see Config.ResultFormat.
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+	// RequiresApproval holds the query until a human approves the call, see the approval plugin.
+	RequiresApproval bool `yaml:"requires_approval,omitempty" json:"requires_approval,omitempty"`
+	// Fingerprint is a hash of the endpoint as it was generated by discovery.
+	// Endpoints without a fingerprint, or whose content no longer matches it,
+	// are considered hand-edited and are never overwritten by a merge.
+	Fingerprint string `yaml:"fingerprint,omitempty" json:"fingerprint,omitempty"`
+}
+
+// EndpointAnnotations are MCP tool hints, unset ones are inferred.
+type EndpointAnnotations struct {
+	ReadOnly    *bool `yaml:"read_only,omitempty" json:"read_only,omitempty"`
+	Destructive *bool `yaml:"destructive,omitempty" json:"destructive,omitempty"`
+	Idempotent  *bool `yaml:"idempotent,omitempty" json:"idempotent,omitempty"`
+	OpenWorld   *bool `yaml:"open_world,omitempty" json:"open_world,omitempty"`
 }
 
 type EndpointParams struct {
@@ -257,6 +351,18 @@ type EndpointParams struct {
 	Required bool        `yaml:"required" json:"required,omitempty"`
 	Format   string      `yaml:"format,omitempty" json:"format,omitempty"`
 	Default  interface{} `yaml:"default,omitempty" json:"default,omitempty"`
+	// Completion configures suggestions of param values for MCP clients.
+	Completion *Completion `yaml:"completion,omitempty" json:"completion,omitempty"`
+}
+
+// Completion configures argument autocompletion. Without static values, distinct values of the column
+// are suggested, by default the column is taken from the condition of the endpoint query that uses the param.
+type Completion struct {
+	Values []string `yaml:"values,omitempty" json:"values,omitempty"`
+	Table  string   `yaml:"table,omitempty" json:"table,omitempty"`
+	Column string   `yaml:"column,omitempty" json:"column,omitempty"`
+	// Disabled turns off suggestions inferred from the query.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
 }
 
 func FromDSN(dsn string) (*Config, error) {
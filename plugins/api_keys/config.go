@@ -34,6 +34,16 @@ type Key struct {
 	AllowedMethods []string `yaml:"allowed_methods"`
 }
 
+// key finds configuration of the API key
+func (c Config) key(value string) (Key, bool) {
+	for _, key := range c.Keys {
+		if key.Key == value {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
 func (t *Key) Allowed(method string) bool {
 	if len(t.AllowedMethods) == 0 {
 		return true
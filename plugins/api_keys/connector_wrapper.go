@@ -20,18 +20,12 @@ func (c Connector) Query(ctx context.Context, endpoint model.Endpoint, params ma
 	if authToken == "" {
 		return nil, xerrors.Errorf("empty token: %w", errors.ErrNotAuthorized)
 	}
-	found := false
-	for _, token := range c.config.Keys {
-		if token.Key == authToken {
-			found = true
-			if !token.Allowed(endpoint.MCPMethod) {
-				return nil, xerrors.Errorf("method: %s is not authorized for this token: %w", endpoint.MCPMethod, errors.ErrNotAuthorized)
-			}
-			break
-		}
-	}
-	if !found {
+	token, ok := c.config.key(authToken)
+	if !ok {
 		return nil, xerrors.Errorf("unknown token: %w", errors.ErrNotAuthorized)
 	}
+	if !token.Allowed(endpoint.MCPMethod) {
+		return nil, xerrors.Errorf("method: %s is not authorized for this token: %w", endpoint.MCPMethod, errors.ErrNotAuthorized)
+	}
 	return c.Connector.Query(ctx, endpoint, params)
 }
@@ -1,11 +1,19 @@
 package api_keys
 
 import (
+	"context"
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
 	"github.com/danielgtaylor/huma/v2"
+	"slices"
 
 	"github.com/centralmind/gateway/connectors"
+	"github.com/centralmind/gateway/errors"
+	"github.com/centralmind/gateway/mcp"
 	"github.com/centralmind/gateway/plugins"
+	"github.com/centralmind/gateway/xcontext"
+	"golang.org/x/xerrors"
 )
 
 //go:embed README.md
@@ -18,6 +26,8 @@ func init() {
 type PluginBundle interface {
 	plugins.Wrapper
 	plugins.Swaggerer
+	plugins.MCPToolEnricher
+	plugins.Authenticator
 }
 
 func New(cfg Config) (PluginBundle, error) {
@@ -69,6 +79,38 @@ func (p Plugin) Wrap(connector connectors.Connector) (connectors.Connector, erro
 	}, nil
 }
 
+// EnrichMCP offers MCP clients only tools that their key is allowed to call, clients without a known key get none.
+func (p Plugin) EnrichMCP(tooler plugins.MCPTooler) {
+	tooler.Server().AddToolFilter(func(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+		key, ok := p.config.key(xcontext.Header(ctx, p.config.Name))
+		if !ok {
+			return tools[:0]
+		}
+		return slices.DeleteFunc(tools, func(tool mcp.Tool) bool {
+			return !key.Allowed(tool.Name)
+		})
+	})
+}
+
+// Credential returns the key the call was made with.
+func (p Plugin) Credential(ctx context.Context) string {
+	return xcontext.Header(ctx, p.config.Name)
+}
+
+// Authenticate accepts a known key that is allowed to call the method, e.g. typed in on an approval page.
+// The principal is a fingerprint of the key, so the key itself isn't kept.
+func (p Plugin) Authenticate(ctx context.Context, credential string, method string, params map[string]any) (string, error) {
+	key, ok := p.config.key(credential)
+	if !ok {
+		return "", xerrors.Errorf("unknown key: %w", errors.ErrNotAuthorized)
+	}
+	if !key.Allowed(method) {
+		return "", xerrors.Errorf("method: %s is not authorized for this key: %w", method, errors.ErrNotAuthorized)
+	}
+	sum := sha256.Sum256([]byte(key.Key))
+	return "api_key:" + hex.EncodeToString(sum[:]), nil
+}
+
 func (p Plugin) Doc() string {
 	return docString
 }
@@ -0,0 +1,154 @@
+package approval
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/centralmind/gateway/model"
+	"golang.org/x/xerrors"
+)
+
+type status string
+
+const (
+	statusPending  status = "pending"
+	statusApproved status = "approved"
+	statusRejected status = "rejected"
+)
+
+// request is a call of an endpoint that waits for a human decision
+type request struct {
+	ID        string
+	Name      string
+	Query     string
+	Params    map[string]any
+	Status    status
+	ExpiresAt time.Time
+
+	caller string
+	call   string
+	ttl    time.Duration
+}
+
+// registry keeps approvals by their ID, which is the secret part of the link, and by the call they are for
+type registry struct {
+	mu     sync.Mutex
+	byID   map[string]*request
+	byCall map[string]string
+}
+
+// for now - global var, better to replace with shared DB
+var approvals = &registry{
+	byID:   map[string]*request{},
+	byCall: map[string]string{},
+}
+
+// callKey identifies a call by its caller, endpoint and params, so that a retried call finds its approval.
+// Params are marshalled with sorted keys, so equal params have the same key.
+func callKey(caller string, endpoint model.Endpoint, params map[string]any) string {
+	raw, _ := json.Marshal(params)
+	sum := sha256.Sum256([]byte(caller + "\x00" + endpointName(endpoint) + "\x00" + endpoint.Query + "\x00" + string(raw)))
+	return hex.EncodeToString(sum[:])
+}
+
+// endpointName is the MCP tool of an endpoint, or its HTTP route for endpoints without one
+func endpointName(endpoint model.Endpoint) string {
+	if endpoint.MCPMethod != "" {
+		return endpoint.MCPMethod
+	}
+	return endpoint.HTTPMethod + " " + endpoint.HTTPPath
+}
+
+// check returns the approval of a call. A decided approval is removed, so that it authorizes a single run,
+// and a call without an approval, or with an expired one, gets a new pending approval of the caller.
+func (r *registry) check(caller, key string, endpoint model.Endpoint, params map[string]any, ttl time.Duration) (request, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.prune(now)
+
+	if id, ok := r.byCall[key]; ok {
+		req := r.byID[id]
+		if req.Status != statusPending {
+			r.remove(req)
+		}
+		return *req, nil
+	}
+
+	id, err := newID()
+	if err != nil {
+		return request{}, xerrors.Errorf("unable to generate approval id: %w", err)
+	}
+	req := &request{
+		ID:        id,
+		Name:      endpointName(endpoint),
+		Query:     endpoint.Query,
+		Params:    params,
+		Status:    statusPending,
+		ExpiresAt: now.Add(ttl),
+		caller:    caller,
+		call:      key,
+		ttl:       ttl,
+	}
+	r.byID[id] = req
+	r.byCall[key] = id
+	return *req, nil
+}
+
+// get returns an approval that didn't expire yet
+func (r *registry) get(id string) (request, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune(time.Now())
+	req, ok := r.byID[id]
+	if !ok {
+		return request{}, false
+	}
+	return *req, true
+}
+
+// decide approves or rejects a pending approval, which then waits for the call to be retried
+func (r *registry) decide(id string, approve bool) (request, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.prune(now)
+	req, ok := r.byID[id]
+	if !ok {
+		return request{}, xerrors.New("approval not found or expired")
+	}
+	if req.Status != statusPending {
+		return *req, xerrors.Errorf("call is already %s", req.Status)
+	}
+	req.Status = statusRejected
+	if approve {
+		req.Status = statusApproved
+	}
+	req.ExpiresAt = now.Add(req.ttl)
+	return *req, nil
+}
+
+func (r *registry) prune(now time.Time) {
+	for _, req := range r.byID {
+		if now.After(req.ExpiresAt) {
+			r.remove(req)
+		}
+	}
+}
+
+func (r *registry) remove(req *request) {
+	delete(r.byID, req.ID)
+	delete(r.byCall, req.call)
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
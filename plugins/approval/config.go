@@ -0,0 +1,41 @@
+package approval
+
+import "time"
+
+// Config represents approval plugin configuration
+type Config struct {
+	// BaseURL is the external address of the gateway used in approval links (default: "http://localhost:9090")
+	BaseURL string `yaml:"base_url"`
+
+	// Path is the gateway's path of approval pages (default: "/approvals")
+	Path string `yaml:"path"`
+
+	// TTL is how long a human has to answer, and how long an approval waits for the call to be retried
+	// Format: time.Duration string ("5m", "1h") (default: "15m")
+	TTL time.Duration `yaml:"ttl"`
+
+	// Approvers are the only principals or API keys that can decide on approval pages, e.g. "oauth:<subject>"
+	// Default: anyone with a credential that may call the held method
+	Approvers []string `yaml:"approvers"`
+}
+
+// WithDefaults sets default values for the config fields
+func (c *Config) WithDefaults() {
+	if c.BaseURL == "" {
+		c.BaseURL = "http://localhost:9090"
+	}
+	if c.Path == "" {
+		c.Path = "/approvals"
+	}
+	if c.TTL <= 0 {
+		c.TTL = 15 * time.Minute
+	}
+}
+
+func (c Config) Tag() string {
+	return "approval"
+}
+
+func (c Config) Doc() string {
+	return docString
+}
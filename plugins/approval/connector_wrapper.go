@@ -0,0 +1,130 @@
+package approval
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/centralmind/gateway/connectors"
+	"github.com/centralmind/gateway/errors"
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/plugins"
+	"github.com/centralmind/gateway/prompter"
+	"github.com/centralmind/gateway/server"
+	"github.com/centralmind/gateway/xcontext"
+	"golang.org/x/xerrors"
+)
+
+var errRejected = xerrors.New("rejected by the user")
+
+type Connector struct {
+	connectors.Connector
+	config         Config
+	authenticators []plugins.Authenticator
+}
+
+// Query runs endpoints that require approval only once a human approved the call. Clients that support
+// elicitation ask their user right away, others get a link to an approval page and retry the call.
+func (c *Connector) Query(ctx context.Context, endpoint model.Endpoint, params map[string]any) ([]map[string]any, error) {
+	if !endpoint.RequiresApproval {
+		return c.Connector.Query(ctx, endpoint, params)
+	}
+	var err error
+	if srv := server.ServerFromContext(ctx); srv != nil && srv.Supports(ctx, mcp.FeatureElicitation) {
+		err = c.elicit(ctx, srv, endpoint, params)
+	} else {
+		err = c.approved(ctx, endpoint, params)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.Connector.Query(ctx, endpoint, params)
+}
+
+func (c *Connector) elicit(ctx context.Context, srv *server.MCPServer, endpoint model.Endpoint, params map[string]any) error {
+	ctx, cancel := context.WithTimeout(ctx, c.config.TTL)
+	defer cancel()
+	result, err := srv.Elicit(ctx, fmt.Sprintf(
+		"%s wants to run:\n\n%s\n\nWith params:\n%s\nApprove it?",
+		endpointName(endpoint),
+		resolveQuery(endpoint.Query, params),
+		prompter.Yamlify(params),
+	), map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"approved": map[string]interface{}{
+				"type":        "boolean",
+				"title":       "Approve",
+				"description": "Run the query with these params",
+			},
+		},
+		"required": []string{"approved"},
+	})
+	if err != nil {
+		return xerrors.Errorf("unable to ask for approval: %w", err)
+	}
+	if approved, _ := result.Content["approved"].(bool); result.Action != mcp.ElicitationAccept || !approved {
+		return xerrors.Errorf("%s: %w", endpointName(endpoint), errRejected)
+	}
+	return nil
+}
+
+func (c *Connector) approved(ctx context.Context, endpoint model.Endpoint, params map[string]any) error {
+	caller, err := c.caller(ctx, endpoint, params)
+	if err != nil {
+		return err
+	}
+	req, err := approvals.check(caller, callKey(caller, endpoint, params), endpoint, params, c.config.TTL)
+	if err != nil {
+		return err
+	}
+	switch req.Status {
+	case statusApproved:
+		return nil
+	case statusRejected:
+		return xerrors.Errorf("%s: %w", endpointName(endpoint), errRejected)
+	}
+	link, err := url.JoinPath(c.config.BaseURL, c.config.Path, req.ID)
+	if err != nil {
+		return xerrors.Errorf("unable to build approval link: %w", err)
+	}
+	return xerrors.Errorf(
+		`
+This tool requires approval by a human before it runs.
+Approval page: [review the call](%s)
+Show this markdown link to the user. The user reviews the query on that page and decides with their own credentials.
+Do not open the link or try to approve the call yourself, a decision without the user's credentials is refused.
+
+!Important, once the user tells you the call is approved, retry this call with the same arguments.
+`,
+		link,
+	)
+}
+
+// caller identifies who waits for an approval: the principal of the credential the call was made with,
+// or the MCP session. REST and chat calls have no session, so without a credential accepted by an authenticator
+// plugin anyone could retry the call approved for somebody else, such calls are refused.
+func (c *Connector) caller(ctx context.Context, endpoint model.Endpoint, params map[string]any) (string, error) {
+	for _, authenticator := range c.authenticators {
+		credential := authenticator.Credential(ctx)
+		if credential == "" {
+			continue
+		}
+		if principal, err := authenticator.Authenticate(ctx, credential, endpointName(endpoint), params); err == nil {
+			return principalCaller(principal), nil
+		}
+	}
+	if session := xcontext.Session(ctx); session != "" {
+		return "session:" + session, nil
+	}
+	return "", xerrors.Errorf(
+		"%s requires approval, which needs an MCP session or a credential of the api_keys or oauth plugin: %w",
+		endpointName(endpoint),
+		errors.ErrNotAuthorized,
+	)
+}
+
+func principalCaller(principal string) string {
+	return "principal:" + principal
+}
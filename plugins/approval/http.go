@@ -0,0 +1,101 @@
+package approval
+
+import (
+	"crypto/subtle"
+	"embed"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/centralmind/gateway/prompter"
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed resources/*
+var resources embed.FS
+
+var page = template.Must(template.ParseFS(resources, "resources/approval.html"))
+
+// csrfCookie holds the token that a decision must repeat, a page of another site can't read it
+const csrfCookie = "approval_csrf"
+
+// HandleApproval shows the query and params of a call to a human, and takes their decision on POST.
+// A decision needs a credential that an authenticator plugin accepts for the called method, e.g. an API key,
+// so an agent that got the link can't approve its own call.
+func (p *Plugin) HandleApproval(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, strings.TrimSuffix(p.config.Path, "/")+"/")
+
+	var req request
+	var problem string
+	switch r.Method {
+	case http.MethodGet:
+		var ok bool
+		req, ok = approvals.get(id)
+		if !ok {
+			http.Error(w, "Approval not found or expired", http.StatusNotFound)
+			return
+		}
+	case http.MethodPost:
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form", http.StatusBadRequest)
+			return
+		}
+		cookie, err := r.Cookie(csrfCookie)
+		if err != nil || cookie.Value == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(r.PostForm.Get("csrf"))) != 1 {
+			http.Error(w, "Invalid or missing CSRF token, reload the page", http.StatusForbidden)
+			return
+		}
+		decision := r.PostForm.Get("decision")
+		if decision != "approve" && decision != "reject" {
+			http.Error(w, "Decision must be approve or reject", http.StatusBadRequest)
+			return
+		}
+		var ok bool
+		req, ok = approvals.get(id)
+		if !ok {
+			http.Error(w, "Approval not found or expired", http.StatusNotFound)
+			return
+		}
+		if err := p.authenticate(r, req); err != nil {
+			logrus.Warnf("Decision on %s is not authorized: %v", req.Name, err)
+			problem = err.Error()
+			w.WriteHeader(http.StatusForbidden)
+			break
+		}
+		req, err = approvals.decide(id, decision == "approve")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		logrus.Infof("Call of %s is %s", req.Name, req.Status)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, err := newID()
+	if err != nil {
+		http.Error(w, "Unable to generate CSRF token", http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookie,
+		Value:    token,
+		Path:     p.config.Path,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.Execute(w, map[string]any{
+		"Name":          req.Name,
+		"Query":         resolveQuery(req.Query, req.Params),
+		"Params":        prompter.Yamlify(req.Params),
+		"Status":        string(req.Status),
+		"CSRF":          token,
+		"Authenticated": len(p.authenticators) > 0,
+		"Problem":       problem,
+	}); err != nil {
+		logrus.Errorf("unable to render approval page: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package approval
+
+import (
+	"crypto/subtle"
+	_ "embed"
+	"net/http"
+	"strings"
+
+	"github.com/centralmind/gateway/connectors"
+	"github.com/centralmind/gateway/errors"
+	"github.com/centralmind/gateway/plugins"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+//go:embed README.md
+var docString string
+
+func init() {
+	plugins.Register(New)
+}
+
+type PluginBundle interface {
+	plugins.OuterWrapper
+	plugins.AuthenticatedHTTPServer
+}
+
+func New(cfg Config) (PluginBundle, error) {
+	cfg.WithDefaults()
+	if !strings.HasPrefix(cfg.Path, "/") {
+		return nil, xerrors.Errorf("approval path must start with /: %s", cfg.Path)
+	}
+	return &Plugin{
+		config: cfg,
+	}, nil
+}
+
+type Plugin struct {
+	config         Config
+	authenticators []plugins.Authenticator
+}
+
+func (p *Plugin) Doc() string {
+	return docString
+}
+
+func (p *Plugin) Wrap(connector connectors.Connector) (connectors.Connector, error) {
+	return &Connector{
+		Connector:      connector,
+		config:         p.config,
+		authenticators: p.authenticators,
+	}, nil
+}
+
+// Outermost makes approval wrap all other plugins, so a call is held before any of them runs or caches it
+func (p *Plugin) Outermost() {}
+
+func (p *Plugin) SetAuthenticators(authenticators []plugins.Authenticator) {
+	p.authenticators = authenticators
+}
+
+// authenticate checks the credential typed in on the approval page, it has to be accepted by one of
+// the authenticator plugins for the held call. Without such plugins nobody can decide on the page.
+// The caller can't decide on its own call, and with configured approvers only they can decide.
+func (p *Plugin) authenticate(r *http.Request, req request) error {
+	if len(p.authenticators) == 0 {
+		return xerrors.Errorf("approval pages require the api_keys or oauth plugin: %w", errors.ErrNotAuthorized)
+	}
+	credential := r.PostForm.Get("credential")
+	if credential == "" {
+		return xerrors.Errorf("credential is required: %w", errors.ErrNotAuthorized)
+	}
+	var err error
+	for _, authenticator := range p.authenticators {
+		var principal string
+		if principal, err = authenticator.Authenticate(r.Context(), credential, req.Name, req.Params); err != nil {
+			continue
+		}
+		if principalCaller(principal) == req.caller {
+			return xerrors.Errorf("a call can't be approved with the credential it was made with: %w", errors.ErrNotAuthorized)
+		}
+		if !p.approver(principal, credential) {
+			return xerrors.Errorf("%s is not an approver: %w", principal, errors.ErrNotAuthorized)
+		}
+		return nil
+	}
+	return err
+}
+
+// approver tells if the principal, or the API key it was authenticated with, is one of the configured approvers
+func (p *Plugin) approver(principal, credential string) bool {
+	if len(p.config.Approvers) == 0 {
+		return true
+	}
+	for _, approver := range p.config.Approvers {
+		if approver == principal || subtle.ConstantTimeCompare([]byte(approver), []byte(credential)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Plugin) RegisterRoutes(mux *http.ServeMux) {
+	if len(p.authenticators) == 0 {
+		logrus.Warn("Approval pages take decisions only with the api_keys or oauth plugin, calls can be approved by elicitation only")
+	}
+	mux.Handle(strings.TrimSuffix(p.config.Path, "/")+"/", http.HandlerFunc(p.HandleApproval))
+}
@@ -0,0 +1,316 @@
+package approval
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/centralmind/gateway/connectors"
+	"github.com/centralmind/gateway/errors"
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/model"
+	"github.com/centralmind/gateway/plugins"
+	"github.com/centralmind/gateway/plugins/api_keys"
+	lrucache "github.com/centralmind/gateway/plugins/lru_cache"
+	"github.com/centralmind/gateway/server"
+	"github.com/centralmind/gateway/xcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingConnector counts queries that actually ran
+type countingConnector struct {
+	connectors.Connector
+	runs int
+}
+
+func (c *countingConnector) Query(ctx context.Context, endpoint model.Endpoint, params map[string]any) ([]map[string]any, error) {
+	c.runs++
+	return []map[string]any{{"deleted": 1}}, nil
+}
+
+var deleteOrder = model.Endpoint{
+	MCPMethod:        "delete_order",
+	Query:            "DELETE FROM orders WHERE id = :id",
+	RequiresApproval: true,
+}
+
+func newConnector(t *testing.T) (*Plugin, *countingConnector, connectors.Connector) {
+	plugin, err := New(Config{BaseURL: "http://gateway.local"})
+	require.NoError(t, err)
+	keys, err := api_keys.New(api_keys.Config{Name: "X-API-Key", Keys: []api_keys.Key{
+		{Key: "admin-key", AllowedMethods: []string{"delete_order"}},
+		{Key: "ops-key", AllowedMethods: []string{"delete_order"}},
+		{Key: "reader-key", AllowedMethods: []string{"list_orders"}},
+	}})
+	require.NoError(t, err)
+	plugin.SetAuthenticators([]plugins.Authenticator{keys})
+	inner := &countingConnector{}
+	wrapped, err := plugin.Wrap(inner)
+	require.NoError(t, err)
+	return plugin.(*Plugin), inner, wrapped
+}
+
+var linkRe = regexp.MustCompile(`\((http://gateway\.local/approvals/[0-9a-f]+)\)`)
+
+// decide opens the approval page like a browser and posts the decision with the CSRF token of the page
+func decide(t *testing.T, handler http.Handler, path, decision, credential string) *httptest.ResponseRecorder {
+	page := httptest.NewRecorder()
+	handler.ServeHTTP(page, httptest.NewRequest(http.MethodGet, path, nil))
+	require.Equal(t, http.StatusOK, page.Code)
+	cookies := page.Result().Cookies()
+	require.Len(t, cookies, 1)
+
+	form := url.Values{"decision": {decision}, "csrf": {cookies[0].Value}, "credential": {credential}}
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(cookies[0])
+	handler.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestApprovalLink(t *testing.T) {
+	plugin, inner, connector := newConnector(t)
+	mux := http.NewServeMux()
+	plugin.RegisterRoutes(mux)
+	ctx := xcontext.WithSession(context.Background(), "session-1")
+	params := map[string]any{"id": 42}
+
+	_, err := connector.Query(ctx, model.Endpoint{MCPMethod: "list_orders"}, params)
+	require.NoError(t, err)
+	assert.Equal(t, 1, inner.runs, "endpoints without requires_approval run right away")
+
+	_, err = connector.Query(ctx, deleteOrder, params)
+	require.Error(t, err)
+	match := linkRe.FindStringSubmatch(err.Error())
+	require.Len(t, match, 2, err.Error())
+	link, err := url.Parse(match[1])
+	require.NoError(t, err)
+	assert.Equal(t, 1, inner.runs)
+
+	// a retry before the decision gets the same link
+	_, err = connector.Query(ctx, deleteOrder, params)
+	assert.Contains(t, err.Error(), link.String())
+
+	page := httptest.NewRecorder()
+	mux.ServeHTTP(page, httptest.NewRequest(http.MethodGet, link.Path, nil))
+	assert.Equal(t, http.StatusOK, page.Code)
+	assert.Contains(t, page.Body.String(), "DELETE FROM orders WHERE id = 42")
+	assert.Contains(t, page.Body.String(), "id: 42")
+	assert.Contains(t, page.Body.String(), `name="csrf" value="`+page.Result().Cookies()[0].Value+`"`)
+
+	assert.Equal(t, http.StatusOK, decide(t, mux, link.Path, "approve", "admin-key").Code)
+	assert.Equal(t, http.StatusConflict, decide(t, mux, link.Path, "reject", "admin-key").Code, "a decision is final")
+
+	// other params or another session are a different call
+	_, err = connector.Query(ctx, deleteOrder, map[string]any{"id": 43})
+	assert.Error(t, err)
+	_, err = connector.Query(xcontext.WithSession(context.Background(), "session-2"), deleteOrder, params)
+	assert.Error(t, err)
+	assert.Equal(t, 1, inner.runs)
+
+	_, err = connector.Query(ctx, deleteOrder, params)
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.runs)
+
+	// an approval is good for a single run
+	_, err = connector.Query(ctx, deleteOrder, params)
+	assert.Error(t, err)
+	assert.Equal(t, 2, inner.runs)
+	assert.NotContains(t, err.Error(), link.String())
+}
+
+func TestApprovalRejected(t *testing.T) {
+	plugin, inner, connector := newConnector(t)
+	ctx := xcontext.WithSession(context.Background(), "session-3")
+
+	_, err := connector.Query(ctx, deleteOrder, map[string]any{"id": 1})
+	match := linkRe.FindStringSubmatch(err.Error())
+	require.Len(t, match, 2)
+	link, _ := url.Parse(match[1])
+
+	resp := decide(t, http.HandlerFunc(plugin.HandleApproval), link.Path, "reject", "admin-key")
+	assert.Contains(t, resp.Body.String(), "rejected")
+
+	_, err = connector.Query(ctx, deleteOrder, map[string]any{"id": 1})
+	assert.ErrorIs(t, err, errRejected)
+	assert.Equal(t, 0, inner.runs)
+
+	resp = httptest.NewRecorder()
+	plugin.HandleApproval(resp, httptest.NewRequest(http.MethodGet, "/approvals/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestApprovalByPrincipal(t *testing.T) {
+	plugin, inner, connector := newConnector(t)
+	handler := http.HandlerFunc(plugin.HandleApproval)
+	params := map[string]any{"id": 9}
+	// REST and chat calls have no session
+	withKey := func(key string) context.Context {
+		return xcontext.WithHeader(context.Background(), map[string][]string{"X-API-Key": {key}})
+	}
+
+	_, err := connector.Query(withKey("admin-key"), deleteOrder, params)
+	match := linkRe.FindStringSubmatch(err.Error())
+	require.Len(t, match, 2)
+	adminLink, _ := url.Parse(match[1])
+	_, err = connector.Query(withKey("ops-key"), deleteOrder, params)
+	match = linkRe.FindStringSubmatch(err.Error())
+	require.Len(t, match, 2)
+	assert.NotEqual(t, adminLink.String(), match[1], "callers without a session must not share approvals")
+
+	assert.Equal(t, http.StatusForbidden, decide(t, handler, adminLink.Path, "approve", "admin-key").Code,
+		"a caller can't approve its own call")
+	assert.Equal(t, http.StatusOK, decide(t, handler, adminLink.Path, "approve", "ops-key").Code)
+	_, err = connector.Query(withKey("ops-key"), deleteOrder, params)
+	assert.Error(t, err)
+	assert.Equal(t, 0, inner.runs)
+	_, err = connector.Query(withKey("admin-key"), deleteOrder, params)
+	require.NoError(t, err)
+	assert.Equal(t, 1, inner.runs)
+
+	// a call without a session nor a credential gets no link
+	_, err = connector.Query(context.Background(), deleteOrder, params)
+	assert.ErrorIs(t, err, errors.ErrNotAuthorized)
+	assert.Empty(t, linkRe.FindStringSubmatch(err.Error()))
+	_, err = connector.Query(withKey("unknown-key"), deleteOrder, params)
+	assert.ErrorIs(t, err, errors.ErrNotAuthorized)
+	assert.Equal(t, 1, inner.runs)
+}
+
+func TestApprovalElicitation(t *testing.T) {
+	_, inner, connector := newConnector(t)
+	srv := server.NewMCPServer("test-server", "1.0.0")
+	srv.AddTool(mcp.NewTool("delete_order"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if _, err := connector.Query(ctx, deleteOrder, map[string]any{"id": 42}); err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText("deleted"), nil
+	})
+	queue := srv.RegisterSession("session-4")
+	defer srv.UnregisterSession("session-4")
+	ctx := srv.WithContext(context.Background(), server.NotificationContext{ClientID: "session-4", SessionID: "session-4"})
+	srv.HandleMessage(ctx, []byte(`{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2025-06-18", "capabilities": {"elicitation": {}}}}`))
+
+	call := func(answer string) mcp.JSONRPCMessage {
+		done := make(chan mcp.JSONRPCMessage, 1)
+		go func() {
+			done <- srv.HandleMessage(ctx, []byte(`{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "delete_order"}}`))
+		}()
+		var request *mcp.JSONRPCRequest
+		select {
+		case notification := <-queue:
+			request = notification.Request
+		case <-time.After(time.Second):
+			t.Fatal("no elicitation request")
+		}
+		require.NotNil(t, request)
+		assert.Contains(t, request.Params.(mcp.ElicitParams).Message, "DELETE FROM orders WHERE id = 42")
+		srv.HandleMessage(ctx, []byte(fmt.Sprintf(`{"jsonrpc": "2.0", "id": %d, "result": %s}`, request.ID, answer)))
+		select {
+		case resp := <-done:
+			return resp
+		case <-time.After(time.Second):
+			t.Fatal("tool call didn't finish after the answer")
+			return nil
+		}
+	}
+
+	_, ok := call(`{"action": "decline"}`).(mcp.JSONRPCError)
+	assert.True(t, ok)
+	_, ok = call(`{"action": "accept", "content": {"approved": false}}`).(mcp.JSONRPCError)
+	assert.True(t, ok)
+	assert.Equal(t, 0, inner.runs)
+
+	_, ok = call(`{"action": "accept", "content": {"approved": true}}`).(mcp.JSONRPCResponse)
+	assert.True(t, ok)
+	assert.Equal(t, 1, inner.runs)
+}
+
+func TestApprovalDecisionAuthorization(t *testing.T) {
+	plugin, inner, connector := newConnector(t)
+	handler := http.HandlerFunc(plugin.HandleApproval)
+	ctx := xcontext.WithSession(context.Background(), "session-5")
+	params := map[string]any{"id": 7}
+
+	_, err := connector.Query(ctx, deleteOrder, params)
+	match := linkRe.FindStringSubmatch(err.Error())
+	require.Len(t, match, 2)
+	link, _ := url.Parse(match[1])
+
+	// what an agent can do with the link alone
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, link.Path, strings.NewReader("decision=approve"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusForbidden, resp.Code, "a decision without the CSRF token is refused")
+
+	assert.Equal(t, http.StatusForbidden, decide(t, handler, link.Path, "approve", "").Code)
+	assert.Equal(t, http.StatusForbidden, decide(t, handler, link.Path, "approve", "unknown-key").Code)
+	assert.Equal(t, http.StatusForbidden, decide(t, handler, link.Path, "approve", "reader-key").Code,
+		"the key must be allowed to call the held method")
+	_, err = connector.Query(ctx, deleteOrder, params)
+	assert.Error(t, err)
+	assert.Equal(t, 0, inner.runs)
+
+	// without authenticator plugins the page only shows the call
+	plugin.SetAuthenticators(nil)
+	assert.Equal(t, http.StatusForbidden, decide(t, handler, link.Path, "approve", "admin-key").Code)
+	plugin.SetAuthenticators([]plugins.Authenticator{})
+
+	page := httptest.NewRecorder()
+	handler.ServeHTTP(page, httptest.NewRequest(http.MethodGet, link.Path, nil))
+	assert.NotContains(t, page.Body.String(), `value="approve"`)
+}
+
+func TestApprovalApprovers(t *testing.T) {
+	plugin, inner, connector := newConnector(t)
+	handler := http.HandlerFunc(plugin.HandleApproval)
+	ctx := xcontext.WithSession(context.Background(), "session-6")
+	params := map[string]any{"id": 8}
+
+	_, err := connector.Query(ctx, deleteOrder, params)
+	match := linkRe.FindStringSubmatch(err.Error())
+	require.Len(t, match, 2)
+	link, _ := url.Parse(match[1])
+
+	plugin.config.Approvers = []string{"ops-key"}
+	assert.Equal(t, http.StatusForbidden, decide(t, handler, link.Path, "approve", "admin-key").Code,
+		"only approvers can decide")
+	assert.Equal(t, http.StatusOK, decide(t, handler, link.Path, "approve", "ops-key").Code)
+	_, err = connector.Query(ctx, deleteOrder, params)
+	require.NoError(t, err)
+	assert.Equal(t, 1, inner.runs)
+}
+
+func TestApprovalOutermost(t *testing.T) {
+	cfg := map[string]any{
+		"approval":  map[string]any{},
+		"lru_cache": map[string]any{"max_size": 10, "ttl": "1m"},
+	}
+	// plugins come from a map, the order must not depend on it
+	for range 20 {
+		wrapped, err := plugins.Wrap(cfg, &countingConnector{})
+		require.NoError(t, err)
+		assert.IsType(t, &Connector{}, wrapped)
+		assert.IsType(t, &lrucache.Connector{}, wrapped.(*Connector).Connector)
+	}
+}
+
+func TestResolveQuery(t *testing.T) {
+	assert.Equal(t,
+		"UPDATE orders SET note = 'it''s :late', status = 'shipped', paid = TRUE WHERE id = 42 AND created_at::date > NULL AND x = :missing",
+		resolveQuery(
+			"UPDATE orders SET note = 'it''s :late', status = :status, paid = :paid WHERE id = :id AND created_at::date > :since AND x = :missing",
+			map[string]any{"status": "shipped", "paid": true, "id": 42, "since": nil, "late": "no"},
+		),
+	)
+	assert.Equal(t, "SELECT 'a''b'", resolveQuery("SELECT :v", map[string]any{"v": "a'b"}))
+}
@@ -0,0 +1,66 @@
+package approval
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// resolveQuery substitutes :name params of the query with SQL literals, so a human sees the statement that runs.
+// Casts such as ::int and text inside quotes are kept as they are, params without a value stay named.
+func resolveQuery(query string, params map[string]any) string {
+	var res strings.Builder
+	runes := []rune(query)
+	var quote rune
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == ':' && i+1 < len(runes) && runes[i+1] == ':':
+			res.WriteString("::")
+			i++
+			continue
+		case r == ':' && i+1 < len(runes) && (runes[i+1] == '_' || unicode.IsLetter(runes[i+1])):
+			end := i + 1
+			for end < len(runes) && (runes[end] == '_' || unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end])) {
+				end++
+			}
+			if value, ok := params[string(runes[i+1:end])]; ok {
+				res.WriteString(literal(value))
+				i = end - 1
+				continue
+			}
+		}
+		res.WriteRune(r)
+	}
+	return res.String()
+}
+
+// literal renders a param value as a SQL literal
+func literal(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	case time.Time:
+		return quoteString(v.Format(time.RFC3339Nano))
+	default:
+		return quoteString(fmt.Sprint(v))
+	}
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -1,9 +1,11 @@
 package plugins
 
 import (
+	"context"
 	"github.com/centralmind/gateway/server"
 	"github.com/danielgtaylor/huma/v2"
 	"net/http"
+	"slices"
 
 	"github.com/centralmind/gateway/connectors"
 	"github.com/centralmind/gateway/remapper"
@@ -38,6 +40,28 @@ type HTTPServer interface {
 	RegisterRoutes(mux *http.ServeMux)
 }
 
+// Authenticator is a plugin that checks credentials a person typed in, e.g. on an approval page.
+type Authenticator interface {
+	Plugin
+	// Credential returns the credential a call was made with, or an empty string for calls without one.
+	Credential(ctx context.Context) string
+	// Authenticate returns the principal the credential belongs to, e.g. the subject of a token,
+	// or an error wrapping errors.ErrNotAuthorized unless the credential may call the method with params
+	Authenticate(ctx context.Context, credential string, method string, params map[string]any) (string, error)
+}
+
+// Authenticated is a plugin that checks credentials with the authenticator plugins of the config.
+type Authenticated interface {
+	Plugin
+	SetAuthenticators(authenticators []Authenticator)
+}
+
+// AuthenticatedHTTPServer is an HTTPServer whose pages check credentials with the authenticator plugins of the config.
+type AuthenticatedHTTPServer interface {
+	HTTPServer
+	Authenticated
+}
+
 type MCPTooler interface {
 	Server() *server.MCPServer
 }
@@ -55,6 +79,13 @@ type Wrapper interface {
 	Wrap(connector connectors.Connector) (connectors.Connector, error)
 }
 
+// OuterWrapper is a Wrapper that wraps all other wrappers, so it sees a query before any other plugin,
+// e.g. approval holds a call before another plugin runs or caches it.
+type OuterWrapper interface {
+	Wrapper
+	Outermost()
+}
+
 // Swaggerer represents a plugin that can modify OpenAPI documentation
 type Swaggerer interface {
 	Plugin
@@ -114,8 +145,15 @@ func Routes(pluginsCfg map[string]any, mux *http.ServeMux) error {
 	if err != nil {
 		return err
 	}
+	authenticators, err := Plugins[Authenticator](pluginsCfg)
+	if err != nil {
+		return err
+	}
 
 	for _, plug := range plugs {
+		if authenticated, ok := plug.(AuthenticatedHTTPServer); ok {
+			authenticated.SetAuthenticators(authenticators)
+		}
 		plug.RegisterRoutes(mux)
 	}
 	return nil
@@ -137,6 +175,27 @@ func Wrap(pluginsCfg map[string]any, connector connectors.Connector) (connectors
 	if err != nil {
 		return nil, err
 	}
+	authenticators, err := Plugins[Authenticator](pluginsCfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, plug := range plugs {
+		if authenticated, ok := plug.(Authenticated); ok {
+			authenticated.SetAuthenticators(authenticators)
+		}
+	}
+	// plugins come in random order of the config map, outer wrappers are applied last
+	slices.SortStableFunc(plugs, func(a, b Wrapper) int {
+		_, outerA := a.(OuterWrapper)
+		_, outerB := b.(OuterWrapper)
+		switch {
+		case outerA == outerB:
+			return 0
+		case outerA:
+			return 1
+		}
+		return -1
+	})
 	for _, wrapper := range plugs {
 		connector, err = wrapper.Wrap(connector)
 		if err != nil {
@@ -165,19 +165,37 @@ func matchRegex(actual interface{}, pattern string) (bool, error) {
 
 // checkAuthorization verifies authorization for a method
 func (c *Connector) checkAuthorization(method string, claims map[string]interface{}, params map[string]interface{}) error {
+	return authorize(c.config.AuthorizationRules, method, func(rule ClaimRule) (bool, error) {
+		return evaluateClaimRule(rule, claims, params)
+	})
+}
+
+// mayAuthorize tells whether a method can be authorized for the claims before its params are known,
+// claim rules with templates of params are assumed to match.
+func mayAuthorize(rules []AuthorizationRule, method string, claims map[string]interface{}) bool {
+	return authorize(rules, method, func(rule ClaimRule) (bool, error) {
+		if strings.Contains(rule.Value, "{{") {
+			return true, nil
+		}
+		return evaluateClaimRule(rule, claims, nil)
+	}) == nil
+}
+
+// authorize applies rules of the method, evaluate checks a single claim rule
+func authorize(rules []AuthorizationRule, method string, evaluate func(rule ClaimRule) (bool, error)) error {
 	// no rules == not authorization, only authentication
-	if len(c.config.AuthorizationRules) == 0 {
+	if len(rules) == 0 {
 		return nil
 	}
 	// Check rules for the method
 	var applicableRules []AuthorizationRule
-	for _, rule := range c.config.AuthorizationRules {
+	for _, rule := range rules {
 		if contains(rule.Methods, method) {
 			applicableRules = append(applicableRules, rule)
 		}
 	}
 	if len(applicableRules) == 0 {
-		for _, rule := range c.config.AuthorizationRules {
+		for _, rule := range rules {
 			if len(rule.Methods) == 1 && rule.Methods[0] == "*" {
 				// wildcard match
 				applicableRules = append(applicableRules, rule)
@@ -193,7 +211,7 @@ func (c *Connector) checkAuthorization(method string, claims map[string]interfac
 		// Check all claim rules
 		matches := 0
 		for _, claimRule := range rule.ClaimRules {
-			ok, err := evaluateClaimRule(claimRule, claims, params)
+			ok, err := evaluate(claimRule)
 			if err != nil {
 				return xerrors.Errorf("failed to evaluate claim rule: %w", err)
 			}
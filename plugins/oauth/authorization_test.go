@@ -404,3 +404,21 @@ func TestBerlinResidencyAuthorization(t *testing.T) {
 		})
 	}
 }
+
+func TestMayAuthorize(t *testing.T) {
+	rules := []AuthorizationRule{
+		{Methods: []string{"list_orders"}, ClaimRules: []ClaimRule{{Claim: "role", Operation: "eq", Value: "sales"}}},
+		{Methods: []string{"get_customer"}, ClaimRules: []ClaimRule{{Claim: "customer_id", Operation: "eq", Value: "{{ .id }}"}}},
+		{Methods: []string{"health"}, AllowPublic: true},
+	}
+	sales := map[string]interface{}{"role": "sales"}
+	support := map[string]interface{}{"role": "support"}
+
+	require.True(t, mayAuthorize(rules, "list_orders", sales))
+	require.False(t, mayAuthorize(rules, "list_orders", support))
+	// depends on params of the call
+	require.True(t, mayAuthorize(rules, "get_customer", support))
+	require.True(t, mayAuthorize(rules, "health", nil))
+	// no rule for the method
+	require.False(t, mayAuthorize(rules, "query", sales))
+}
@@ -40,18 +40,41 @@ func (c *Connector) Query(ctx context.Context, endpoint model.Endpoint, params m
 	tokenString := parts[1]
 
 	// Validate token through provider
-	userInfo, err := validateToken(ctx, c.config, tokenString)
+	userInfo, err := requestClaims(ctx, c.config, tokenString)
 	if err != nil {
 		return nil, xerrors.Errorf("token validation failed: %w", err)
 	}
 
-	ctx = xcontext.WithClaims(ctx, userInfo)
+	ctx = withValidatedToken(ctx, tokenString, userInfo)
 	if err := c.checkAuthorization(endpoint.MCPMethod, userInfo, params); err != nil {
 		return nil, xerrors.Errorf("unable to authorize: %w", err)
 	}
 	return c.Connector.Query(ctx, endpoint, params)
 }
 
+// validatedTokenKey holds the token validated earlier in the request with its claims
+type validatedTokenKey struct{}
+
+type validatedToken struct {
+	token  string
+	claims map[string]any
+}
+
+// withValidatedToken caches claims of a validated token on the context of the request
+func withValidatedToken(ctx context.Context, token string, claims map[string]any) context.Context {
+	ctx = context.WithValue(ctx, validatedTokenKey{}, validatedToken{token: token, claims: claims})
+	return xcontext.WithClaims(ctx, claims)
+}
+
+// requestClaims returns claims of the token, claims of the same token validated earlier in the request,
+// e.g. by the AuthChecker, are reused, so the IDP is asked once per request.
+func requestClaims(ctx context.Context, config Config, token string) (map[string]any, error) {
+	if validated, ok := ctx.Value(validatedTokenKey{}).(validatedToken); ok && validated.token == token {
+		return validated.claims, nil
+	}
+	return validateToken(ctx, config, token)
+}
+
 // validateToken makes a request to IDP to validate the token
 func validateToken(ctx context.Context, config Config, token string) (map[string]any, error) {
 	provider := strings.ToLower(config.Provider)
@@ -2,11 +2,15 @@ package oauth
 
 import (
 	"context"
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -38,10 +42,15 @@ type PluginBundle interface {
 	plugins.Swaggerer
 	plugins.HTTPServer
 	plugins.MCPToolEnricher
+	plugins.Authenticator
 }
 
 func New(cfg Config) (PluginBundle, error) {
 	cfg.WithDefaults()
+	if v := cfg.MCPProtocolVersion; v != "" && mcp.NegotiateProtocolVersion(v) != v {
+		cfg.MCPProtocolVersion = mcp.NearestProtocolVersion(v)
+		logrus.Warnf("unsupported mcp_protocol_version %q, using %s, supported: %v", v, cfg.MCPProtocolVersion, mcp.SupportedProtocolVersions)
+	}
 	oauthConfig := cfg.GetOAuthConfig()
 	if oauthConfig == nil {
 		return nil, xerrors.New("failed to create OAuth config")
@@ -64,22 +73,17 @@ type Plugin struct {
 
 func (p *Plugin) EnrichMCP(tooler plugins.MCPTooler) {
 	u, _ := url.Parse(p.config.RedirectURL)
-	tooler.Server().AddAuthorizer(func(r *http.Request) bool {
-		if p.config.MCPProtocolVersion < "2025-03-26" {
-			return false
-		}
+	tooler.Server().AddAuthorizer(func(r *http.Request) (context.Context, bool) {
 		if r.Header.Get("Authorization") == "" {
-			return false
+			return r.Context(), false
 		}
-		_, err := validateToken(
-			r.Context(),
-			p.config,
-			strings.Replace(r.Header.Get("Authorization"), "Bearer ", "", 1),
-		)
+		token := strings.Replace(r.Header.Get("Authorization"), "Bearer ", "", 1)
+		claims, err := validateToken(r.Context(), p.config, token)
 		if err != nil {
-			return false
+			return r.Context(), false
 		}
-		return true
+		// tool filters, middlewares and the connector of the request reuse the claims
+		return withValidatedToken(r.Context(), token, claims), true
 	})
 	tooler.Server().AddTool(mcp.NewTool(
 		"whoami",
@@ -96,19 +100,17 @@ Print information about who sits behind the keyboard, authorization info about u
 			},
 		}, nil
 	})
+	tooler.Server().AddToolFilter(p.authorizedTools)
 	tooler.Server().AddToolMiddleware(func(ctx context.Context, tool server.ServerTool, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		logrus.Infof("MCP Tool: %s \nRequest: \n%v", tool.Tool.Name, prompter.Yamlify(request.Params.Arguments))
-		authHeader := xcontext.Header(ctx, p.config.TokenHeader)
-		if authHeader == "" {
-			ss, ok := authorizedSessions.Load(xcontext.Session(ctx))
-			if !ok {
-				return nil, xerrors.New("empty authorization")
-			}
-			authHeader = ss.(string)
+		authHeader, ok := p.authHeader(ctx)
+		if !ok {
+			return nil, xerrors.New("empty authorization")
 		}
-		userInfo, err := validateToken(ctx, p.config, strings.Replace(authHeader, "Bearer ", "", 1))
+		token := strings.Replace(authHeader, "Bearer ", "", 1)
+		userInfo, err := requestClaims(ctx, p.config, token)
 		if err == nil {
-			ctx = xcontext.WithClaims(ctx, userInfo)
+			ctx = withValidatedToken(ctx, token, userInfo)
 			return tool.Handler(ctx, request)
 		}
 
@@ -140,6 +142,77 @@ Prompt to user a link as markdown link above.
 	})
 }
 
+// authHeader returns the authorization of the request, or the one the session got with the OAuth flow
+func (p *Plugin) authHeader(ctx context.Context) (string, bool) {
+	if authHeader := xcontext.Header(ctx, p.config.TokenHeader); authHeader != "" {
+		return authHeader, true
+	}
+	ss, ok := authorizedSessions.Load(xcontext.Session(ctx))
+	if !ok {
+		return "", false
+	}
+	return ss.(string), true
+}
+
+// authorizedTools hides tools that authorization rules reject for claims of the caller.
+// Callers without a token are offered all tools, since the OAuth flow starts with their first tool call.
+func (p *Plugin) authorizedTools(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	if len(p.config.AuthorizationRules) == 0 {
+		return tools
+	}
+	authHeader, ok := p.authHeader(ctx)
+	if !ok {
+		return tools
+	}
+	claims, err := requestClaims(ctx, p.config, strings.Replace(authHeader, "Bearer ", "", 1))
+	if err != nil {
+		return tools
+	}
+	return slices.DeleteFunc(tools, func(tool mcp.Tool) bool {
+		return tool.Name != "whoami" && !mayAuthorize(p.config.AuthorizationRules, tool.Name, claims)
+	})
+}
+
+// Credential returns the access token the call was made with.
+func (p *Plugin) Credential(ctx context.Context) string {
+	authHeader, _ := p.authHeader(ctx)
+	return strings.TrimPrefix(authHeader, "Bearer ")
+}
+
+// Authenticate accepts an access token whose claims pass authorization_rules of the method,
+// e.g. a token pasted on an approval page. The principal is the subject of the token.
+func (p *Plugin) Authenticate(ctx context.Context, credential string, method string, params map[string]any) (string, error) {
+	token := strings.TrimPrefix(credential, "Bearer ")
+	claims, err := requestClaims(ctx, p.config, token)
+	if err != nil {
+		return "", xerrors.Errorf("token validation failed: %v: %w", err, gerrors.ErrNotAuthorized)
+	}
+	if err := authorize(p.config.AuthorizationRules, method, func(rule ClaimRule) (bool, error) {
+		return evaluateClaimRule(rule, claims, params)
+	}); err != nil {
+		return "", err
+	}
+	return "oauth:" + subject(claims, token), nil
+}
+
+// subject identifies the user of a token by its sub claim, or by the id of GitHub users.
+// Tokens of an IDP that returns neither are told apart by their fingerprint.
+func subject(claims map[string]any, token string) string {
+	if sub, ok := claims["sub"].(string); ok && sub != "" {
+		return sub
+	}
+	switch id := claims["id"].(type) {
+	case string:
+		if id != "" {
+			return id
+		}
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64)
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(sum[:])
+}
+
 func (p *Plugin) RegisterRoutes(mux *http.ServeMux) {
 	if p.config.AuthURL == "" || p.config.CallbackURL == "" {
 		return
@@ -172,6 +245,10 @@ func (p *Plugin) RegisterRoutes(mux *http.ServeMux) {
 		mux.Handle(p.config.RegisterURL, CORSMiddleware(registrationHandler))
 	}
 
+	// Older revisions of the protocol have no authorization discovery, their clients must send a token they got elsewhere
+	if v := p.config.MCPProtocolVersion; v != "" && !mcp.ProtocolSupports(v, mcp.FeatureAuthorization) {
+		return
+	}
 	// Register the well-known endpoint with CORS middleware
 	mux.Handle("/.well-known/oauth-authorization-server", CORSMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Add registration endpoint to metadata
@@ -0,0 +1,92 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/server"
+	"github.com/centralmind/gateway/xcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tooler struct {
+	srv *server.MCPServer
+}
+
+func (t tooler) Server() *server.MCPServer { return t.srv }
+
+func TestNeedAuthByProtocolVersion(t *testing.T) {
+	for version, metadata := range map[string]bool{
+		"":           true,
+		"2024-11-05": false,
+		"2025-03-26": true,
+		"2025-06-18": true,
+	} {
+		srv := server.NewMCPServer("test", "1.0.0")
+		p := &Plugin{config: Config{MCPProtocolVersion: version}}
+		p.EnrichMCP(tooler{srv: srv})
+		assert.True(t, srv.NeedAuth(httptest.NewRequest("GET", "/sse", nil)), "version %q must reject requests without a token", version)
+
+		p.config.WithDefaults()
+		p.config.IssuerURL = "https://gateway.example.com"
+		mux := http.NewServeMux()
+		p.RegisterRoutes(mux)
+		_, pattern := mux.Handler(httptest.NewRequest("GET", "/.well-known/oauth-authorization-server", nil))
+		assert.Equal(t, metadata, pattern != "", "version %q", version)
+	}
+}
+
+func TestUnsupportedProtocolVersion(t *testing.T) {
+	plugin, err := New(Config{MCPProtocolVersion: "2025-05-01"})
+	require.NoError(t, err)
+	assert.Equal(t, "2025-03-26", plugin.(*Plugin).config.MCPProtocolVersion)
+}
+
+func TestClaimsValidatedOncePerRequest(t *testing.T) {
+	var introspections atomic.Int32
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		introspections.Add(1)
+		fmt.Fprint(w, `{"active": true, "role": "sales"}`)
+	}))
+	defer idp.Close()
+
+	srv := server.NewMCPServer("test", "1.0.0")
+	srv.AddTool(mcp.NewTool("list_orders"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return &mcp.CallToolResult{}, nil
+	})
+	srv.AddTool(mcp.NewTool("list_payments"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return &mcp.CallToolResult{}, nil
+	})
+	p := &Plugin{config: Config{
+		Provider:         "keycloak",
+		IntrospectionURL: idp.URL,
+		TokenHeader:      "Authorization",
+		AuthorizationRules: []AuthorizationRule{
+			{Methods: []string{"list_orders"}, ClaimRules: []ClaimRule{{Claim: "role", Operation: "eq", Value: "sales"}}},
+		},
+	}}
+	p.EnrichMCP(tooler{srv: srv})
+
+	r := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+	r.Header.Set("Authorization", "Bearer token")
+	r, ok := srv.Authorize(r)
+	require.True(t, ok)
+	ctx := xcontext.WithHeader(r.Context(), r.Header)
+
+	var names []string
+	for _, tool := range srv.ListToolsFor(ctx) {
+		names = append(names, tool.Name)
+	}
+	assert.ElementsMatch(t, []string{"list_orders", "whoami"}, names)
+	var request mcp.CallToolRequest
+	request.Params.Name = "list_orders"
+	_, err := srv.CallTool(ctx, request)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, introspections.Load(), "the token must be validated once per request")
+}
@@ -2,6 +2,7 @@ package prompter
 
 import (
 	_ "embed"
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -14,6 +15,12 @@ var (
 	//go:embed endpoints_schema.json
 	apiConfigSchema []byte
 
+	//go:embed pii_report_schema.json
+	piiReportSchema []byte
+
+	//go:embed ask_schema.json
+	askSchema []byte
+
 	endpointsPrompt = `
 !Important rules:
 	- The final output must contain *only valid single JSON* with no additional commentary, explanations, or markdown formatting!
@@ -29,16 +36,67 @@ var (
 	- If some entity requires pagination, there should be separate API that calculates total_count, so pagination can be queried
 	- For Postgres, use all table names and column names in double quotes, e.g., "table_name" and "column_name". 
 	- If a schema is specified in the table name (format: schema.table), use it in your queries appropriately for the database type. For Postgres, this would be "schema"."table_name".
+`
+	mergeEndpointsPrompt = `
+!Incremental discovery rules:
+	- The config already contains the endpoints listed below in <existing_endpoints>.
+	- Do not repeat existing endpoints. Generate endpoints only for tables that are not covered yet, or whose schema changed so that existing SQL is no longer valid.
+	- When you replace an existing endpoint, keep its mcp_method so it can be matched with the old one.
+	- Follow naming, grouping and path conventions of existing endpoints.
+	- If nothing needs to be added or changed, return an empty endpoints list.
 `
 	piiReportPrompt = `
 !Important rules:
 	- The final output must contain *only valid single JSON* with no additional commentary, explanations, or markdown formatting!
 	- The JSON configuration must strictly adhere to the provided JSON schema, including all required fields.
-	- Analyze endpoints generated against endpoint definitions.
+	- Analyze column names, types and data samples of every table.
 	- Detect where is PII or sensitive data located in data samples
+	- Report only columns that really contain PII or sensitive data, technical identifiers and status codes are not PII.
+`
+	askPrompt = `
+!Important rules:
+	- The final output must contain *only valid single JSON* with no additional commentary, explanations, or markdown formatting!
+	- The JSON must strictly adhere to the provided JSON schema, including all required fields.
+	- Write exactly one read-only SELECT query (CTEs are allowed) for {database_type}, never modify data.
+	- Use only tables and columns listed below, do not guess names.
+	- Put literal values from the question directly into the query, it must not have parameters.
+	- Unless the question asks for everything, limit the result to at most {max_rows} rows.
+	- For Postgres, use all table names and column names in double quotes, e.g., "table_name" and "column_name".
+	- If a schema is specified in the table name (format: schema.table), use it in your query appropriately for the database type.
 `
 )
 
+// EndpointsSchema is the JSON schema of discovered endpoints, providers that support structured output enforce it.
+func EndpointsSchema() json.RawMessage {
+	return apiConfigSchema
+}
+
+// PIIReportSchema is the JSON schema of the PII classification report.
+func PIIReportSchema() json.RawMessage {
+	return piiReportSchema
+}
+
+// AskSchema is the JSON schema of an answer to a natural language question.
+func AskSchema() json.RawMessage {
+	return askSchema
+}
+
+// AskPrompt asks the model to write a SQL query that answers the question.
+// Tables are expected without data samples, so no data is sent to the model.
+func AskPrompt(connector connectors.Connector, question string, tables []TableData, schema string, maxRows int) string {
+	res := fmt.Sprintf("I need a SQL query for a %s database that answers a question of a user...", connector.Config().Type())
+	res += "\n"
+	res += strings.NewReplacer("{database_type}", connector.Config().Type(), "{max_rows}", fmt.Sprint(maxRows)).Replace(askPrompt)
+	for _, extraPrompt := range connector.Config().ExtraPrompt() {
+		res += "	- " + extraPrompt + "\n"
+	}
+	res += "\n" + string(askSchema) + "\n\n"
+	res += TablesPrompt(tables, schema)
+	res += fmt.Sprintf("\n<question>\n%s\n</question>\n", question)
+
+	return res
+}
+
 func DiscoverEndpointsPrompt(connector connectors.Connector, extraPrompt string, tables []TableData, schema string) string {
 	res := "I need a config for an automatic API that will be used by another AI bot or LLMs..."
 	res += "\n"
@@ -52,19 +110,42 @@ func DiscoverEndpointsPrompt(connector connectors.Connector, extraPrompt string,
 	return res
 }
 
+// MergeEndpointsPrompt extends DiscoverEndpointsPrompt with already configured endpoints,
+// so the model only designs endpoints for new or changed tables.
+func MergeEndpointsPrompt(connector connectors.Connector, extraPrompt string, tables []TableData, schema string, existing []gw_model.Endpoint) string {
+	res := DiscoverEndpointsPrompt(connector, extraPrompt, tables, schema)
+	res += mergeEndpointsPrompt
+	endpoints := make([]gw_model.Endpoint, len(existing))
+	for i, endpoint := range existing {
+		endpoint.Fingerprint = ""
+		endpoints[i] = endpoint
+	}
+	raw, _ := json.MarshalIndent(endpoints, "", "  ")
+	res += fmt.Sprintf("\n<existing_endpoints>\n%s\n</existing_endpoints>\n", raw)
+
+	return res
+}
+
+// PIIReportPrompt asks the model to classify columns that contain PII or other sensitive data.
+func PIIReportPrompt(connector connectors.Connector, tables []TableData, schema string) string {
+	res := fmt.Sprintf("I need to find personally identifiable information (PII) and other sensitive data in a %s database...", connector.Config().Type())
+	res += "\n"
+	res += piiReportPrompt
+	res += "\n" + string(piiReportSchema) + "\n\n"
+	res += TablesPrompt(tables, schema)
+
+	return res
+}
+
 func TablesPrompt(tables []TableData, schema string) string {
 	var res string
 	for _, table := range tables {
 		// Apply schema to table name if schema is provided and not empty
-		var tableName string
-
-		if !strings.Contains(table.Name, ".") {
-			if schema != "" {
-				// Qualify the table name with schema
-				tableName = fmt.Sprintf("%s.%s", schema, table.Name)
-			}
-		} else {
-			tableName = table.Name
+		tableName := table.Name
+
+		if !strings.Contains(table.Name, ".") && schema != "" {
+			// Qualify the table name with schema
+			tableName = fmt.Sprintf("%s.%s", schema, table.Name)
 		}
 
 		res += fmt.Sprintf(`
@@ -2,7 +2,9 @@ package anthropic
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -95,6 +97,8 @@ func NewAnthropicProviderIntl(providerConfig providers.ModelProviderConfig, vert
 
 		clientOpts := []option.RequestOption{
 			option.WithAPIKey(effectiveAPIKey),
+			// retries are handled by providers.RetryProvider, so they share backoff and budget with other providers
+			option.WithMaxRetries(0),
 		}
 
 		if effectiveEndpoint != "" {
@@ -119,6 +123,7 @@ func NewAnthropicProviderIntl(providerConfig providers.ModelProviderConfig, vert
 
 		client = anthropic.NewClient(
 			vertex.WithGoogleAuth(context.Background(), effectiveVertexAIRegion, effectiveVertexAIProject),
+			option.WithMaxRetries(0),
 		)
 	}
 
@@ -147,9 +152,11 @@ func (ap *AnthropicProvider) Chat(ctx context.Context, req *providers.Conversati
 		}
 	}
 
+	// Structured output is emulated with a forced tool call, which is not compatible with extended thinking.
+	reasoning := providers.ReasoningWithForcedTool(ap.GetName(), req)
 	temperature := req.Temperature
 	temperature = max(temperature, 0.0)
-	if req.Reasoning {
+	if reasoning {
 		temperature = 1.0
 	}
 
@@ -161,19 +168,23 @@ func (ap *AnthropicProvider) Chat(ctx context.Context, req *providers.Conversati
 	messages := prepareAnthropicMessages(req.Messages)
 
 	params := anthropic.MessageNewParams{
-		Model:       anthropic.F(modelId),
-		MaxTokens:   anthropic.F(int64(maxTokens)),
-		Temperature: anthropic.F(float64(temperature)),
-		Messages:    anthropic.F(messages),
+		Model:     anthropic.F(modelId),
+		MaxTokens: anthropic.F(int64(maxTokens)),
+		Messages:  anthropic.F(messages),
+	}
+	if !req.DefaultTemperature || reasoning {
+		params.Temperature = anthropic.F(float64(temperature))
 	}
 
-	if req.Reasoning {
+	if reasoning {
 		params.Thinking = anthropic.F[anthropic.ThinkingConfigParamUnion](anthropic.ThinkingConfigEnabledParam{
 			BudgetTokens: anthropic.F(int64(4096)),
 			Type:         anthropic.F(anthropic.ThinkingConfigEnabledTypeEnabled),
 		})
 	}
 
+	applyAnthropicStructuredOutput(&params, req)
+
 	if req.System != "" {
 		params.System = anthropic.F([]anthropic.TextBlockParam{
 			{
@@ -185,7 +196,7 @@ func (ap *AnthropicProvider) Chat(ctx context.Context, req *providers.Conversati
 
 	resp, err := ap.Client.Messages.New(ctx, params, option.WithRequestTimeout(15*60*time.Second))
 	if err != nil {
-		return nil, err
+		return nil, convertAnthropicError(err)
 	}
 
 	if len(resp.Content) == 0 {
@@ -206,9 +217,15 @@ func (ap *AnthropicProvider) Chat(ctx context.Context, req *providers.Conversati
 				})
 			}
 		}
+		if block.Type == "tool_use" {
+			responseContentBlocks = append(responseContentBlocks, convertAnthropicToolUse(block, req))
+		}
 	}
 
 	stopReason := convertAnthropicStopReason(string(resp.StopReason))
+	if req.ResponseSchema != nil && stopReason == providers.StopReasonToolCalls {
+		stopReason = providers.StopReasonStop
+	}
 	usage := &providers.ModelUsage{
 		InputTokens:  int(resp.Usage.InputTokens),
 		OutputTokens: int(resp.Usage.OutputTokens),
@@ -258,9 +275,11 @@ func (ap *AnthropicProvider) ChatStream(ctx context.Context, req *providers.Conv
 		}
 	}
 
+	// Structured output is emulated with a forced tool call, which is not compatible with extended thinking.
+	reasoning := providers.ReasoningWithForcedTool(ap.GetName(), req)
 	temperature := req.Temperature
 	temperature = max(temperature, 0.0)
-	if req.Reasoning {
+	if reasoning {
 		temperature = 1.0
 	}
 
@@ -272,19 +291,23 @@ func (ap *AnthropicProvider) ChatStream(ctx context.Context, req *providers.Conv
 	messages := prepareAnthropicMessages(req.Messages)
 
 	params := anthropic.MessageNewParams{
-		Model:       anthropic.F(modelId),
-		MaxTokens:   anthropic.F(int64(maxTokens)),
-		Temperature: anthropic.F(float64(temperature)),
-		Messages:    anthropic.F(messages),
+		Model:     anthropic.F(modelId),
+		MaxTokens: anthropic.F(int64(maxTokens)),
+		Messages:  anthropic.F(messages),
+	}
+	if !req.DefaultTemperature || reasoning {
+		params.Temperature = anthropic.F(float64(temperature))
 	}
 
-	if req.Reasoning {
+	if reasoning {
 		params.Thinking = anthropic.F[anthropic.ThinkingConfigParamUnion](anthropic.ThinkingConfigEnabledParam{
 			BudgetTokens: anthropic.F(int64(4096)),
 			Type:         anthropic.F(anthropic.ThinkingConfigEnabledTypeEnabled),
 		})
 	}
 
+	applyAnthropicStructuredOutput(&params, req)
+
 	if req.System != "" {
 		params.System = anthropic.F([]anthropic.TextBlockParam{
 			{
@@ -328,8 +351,21 @@ func (ap *AnthropicProvider) ChatStream(ctx context.Context, req *providers.Conv
 						}
 					}
 				case anthropic.MessageStopEvent:
+					// tool inputs arrive as partial JSON, so tool calls are emitted from the accumulated message
+					for _, block := range message.Content {
+						if block.Type == "tool_use" {
+							eventCh <- &providers.StreamChunkContent{
+								Content: convertAnthropicToolUse(block, req),
+							}
+						}
+					}
+
+					stopReason := convertAnthropicStopReason(string(message.StopReason))
+					if req.ResponseSchema != nil && stopReason == providers.StopReasonToolCalls {
+						stopReason = providers.StopReasonStop
+					}
 					eventCh <- &providers.StreamChunkStop{
-						StopReason: convertAnthropicStopReason(string(message.StopReason)),
+						StopReason: stopReason,
 					}
 
 					eventCh <- &providers.StreamChunkUsage{
@@ -347,6 +383,7 @@ func (ap *AnthropicProvider) ChatStream(ctx context.Context, req *providers.Conv
 		if err := stream.Err(); err != nil {
 			eventCh <- &providers.StreamChunkError{
 				Error: err.Error(),
+				Err:   convertAnthropicError(err),
 			}
 		}
 	}()
@@ -361,8 +398,13 @@ func prepareAnthropicMessages(messages []providers.Message) []anthropic.MessageP
 	for _, msg := range messages {
 		var contentBlocks []anthropic.ContentBlockParamUnion
 		for _, content := range msg.Content {
-			if textBlock, ok := content.(*providers.ContentBlockText); ok {
-				contentBlocks = append(contentBlocks, anthropic.NewTextBlock(textBlock.Value))
+			switch block := content.(type) {
+			case *providers.ContentBlockText:
+				contentBlocks = append(contentBlocks, anthropic.NewTextBlock(block.Value))
+			case *providers.ContentBlockToolUse:
+				contentBlocks = append(contentBlocks, anthropic.NewToolUseBlockParam(block.ID, block.Name, block.Input))
+			case *providers.ContentBlockToolResult:
+				contentBlocks = append(contentBlocks, anthropic.NewToolResultBlock(block.ToolUseID, block.Content, block.IsError))
 			}
 		}
 
@@ -379,6 +421,18 @@ func prepareAnthropicMessages(messages []providers.Message) []anthropic.MessageP
 	return anthropicMessages
 }
 
+func convertAnthropicError(err error) error {
+	var apiErr *anthropic.Error
+	if errors.As(err, &apiErr) {
+		var header http.Header
+		if apiErr.Response != nil {
+			header = apiErr.Response.Header
+		}
+		return providers.NewAPIError(apiErr.StatusCode, header, err)
+	}
+	return err
+}
+
 func convertAnthropicStopReason(reason string) providers.StopReason {
 	switch reason {
 	case "end_turn":
@@ -391,3 +445,44 @@ func convertAnthropicStopReason(reason string) providers.StopReason {
 		return providers.StopReasonStop
 	}
 }
+
+// applyAnthropicStructuredOutput adds tools to the request. A response schema becomes a tool the model is forced to call,
+// its input is the structured answer.
+func applyAnthropicStructuredOutput(params *anthropic.MessageNewParams, req *providers.ConversationRequest) {
+	var tools []anthropic.ToolUnionUnionParam
+	for _, tool := range req.Tools {
+		tools = append(tools, anthropic.ToolParam{
+			Name:        anthropic.F(tool.Name),
+			Description: anthropic.F(tool.Description),
+			InputSchema: anthropic.F[interface{}](tool.InputSchema),
+		})
+	}
+
+	if req.ResponseSchema != nil {
+		tools = append(tools, anthropic.ToolParam{
+			Name:        anthropic.F(req.ResponseSchema.SchemaName()),
+			Description: anthropic.F(req.ResponseSchema.Description),
+			InputSchema: anthropic.F[interface{}](req.ResponseSchema.Schema),
+		})
+		params.ToolChoice = anthropic.F[anthropic.ToolChoiceUnionParam](anthropic.ToolChoiceToolParam{
+			Type: anthropic.F(anthropic.ToolChoiceToolTypeTool),
+			Name: anthropic.F(req.ResponseSchema.SchemaName()),
+		})
+	}
+
+	if len(tools) > 0 {
+		params.Tools = anthropic.F(tools)
+	}
+}
+
+// convertAnthropicToolUse converts tool_use block, a call of the response schema tool is the structured answer.
+func convertAnthropicToolUse(block anthropic.ContentBlock, req *providers.ConversationRequest) providers.ContentBlock {
+	if req.ResponseSchema != nil && block.Name == req.ResponseSchema.SchemaName() {
+		return &providers.ContentBlockText{Value: string(block.Input)}
+	}
+	return &providers.ContentBlockToolUse{
+		ID:    block.ID,
+		Name:  block.Name,
+		Input: json.RawMessage(block.Input),
+	}
+}
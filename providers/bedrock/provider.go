@@ -2,11 +2,13 @@ package bedrock
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"os"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
@@ -78,7 +80,10 @@ func NewBedrockProvider(providerConfig providers.ModelProviderConfig) (providers
 		return nil, err
 	}
 
-	client := bedrockruntime.NewFromConfig(cfg)
+	client := bedrockruntime.NewFromConfig(cfg, func(o *bedrockruntime.Options) {
+		// retries are handled by providers.RetryProvider, so they share backoff and budget with other providers
+		o.Retryer = aws.NopRetryer{}
+	})
 
 	return &BedrockProvider{
 		Client:     client,
@@ -107,8 +112,13 @@ func (bp *BedrockProvider) Chat(ctx context.Context, req *providers.Conversation
 		})
 	}
 
-	temperature := aws.Float32(max(req.Temperature, 0.0))
-	if req.Reasoning {
+	// Structured output is emulated with a forced tool call, which is not compatible with extended thinking.
+	reasoning := providers.ReasoningWithForcedTool(bp.GetName(), req)
+	var temperature *float32
+	if !req.DefaultTemperature {
+		temperature = aws.Float32(max(req.Temperature, 0.0))
+	}
+	if reasoning {
 		temperature = aws.Float32(1.0)
 	}
 
@@ -129,7 +139,9 @@ func (bp *BedrockProvider) Chat(ctx context.Context, req *providers.Conversation
 		},
 	}
 
-	if req.Reasoning {
+	converseInput.ToolConfig = prepareBedrockToolConfig(req)
+
+	if reasoning {
 		converseInput.AdditionalModelRequestFields = document.NewLazyDocument(map[string]any{
 			"thinking": map[string]any{
 				"type":          "enabled",
@@ -140,7 +152,7 @@ func (bp *BedrockProvider) Chat(ctx context.Context, req *providers.Conversation
 
 	output, err := bp.Client.Converse(ctx, converseInput)
 	if err != nil {
-		return nil, err
+		return nil, convertBedrockError(err)
 	}
 
 	response, ok := output.Output.(*types.ConverseOutputMemberMessage)
@@ -162,9 +174,23 @@ func (bp *BedrockProvider) Chat(ctx context.Context, req *providers.Conversation
 				})
 			}
 		}
+		if toolUse, ok := block.(*types.ContentBlockMemberToolUse); ok {
+			var input json.RawMessage
+			if toolUse.Value.Input != nil {
+				raw, err := toolUse.Value.Input.MarshalSmithyDocument()
+				if err != nil {
+					return nil, err
+				}
+				input = raw
+			}
+			responseContentBlocks = append(responseContentBlocks, convertBedrockToolUse(aws.ToString(toolUse.Value.ToolUseId), aws.ToString(toolUse.Value.Name), input, req))
+		}
 	}
 
 	stopReason := convertBedrockStopReason(output.StopReason)
+	if req.ResponseSchema != nil && stopReason == providers.StopReasonToolCalls {
+		stopReason = providers.StopReasonStop
+	}
 	usage := convertBedrockUsage(output.Usage)
 
 	return &providers.ConversationResponse{
@@ -213,8 +239,13 @@ func (bp *BedrockProvider) ChatStream(ctx context.Context, req *providers.Conver
 		})
 	}
 
-	temperature := aws.Float32(max(req.Temperature, 0.0))
-	if req.Reasoning {
+	// Structured output is emulated with a forced tool call, which is not compatible with extended thinking.
+	reasoning := providers.ReasoningWithForcedTool(bp.GetName(), req)
+	var temperature *float32
+	if !req.DefaultTemperature {
+		temperature = aws.Float32(max(req.Temperature, 0.0))
+	}
+	if reasoning {
 		temperature = aws.Float32(1.0)
 	}
 
@@ -235,7 +266,9 @@ func (bp *BedrockProvider) ChatStream(ctx context.Context, req *providers.Conver
 		},
 	}
 
-	if req.Reasoning {
+	converseStreamInput.ToolConfig = prepareBedrockToolConfig(req)
+
+	if reasoning {
 		converseStreamInput.AdditionalModelRequestFields = document.NewLazyDocument(map[string]any{
 			"thinking": map[string]any{
 				"type":          "enabled",
@@ -246,7 +279,7 @@ func (bp *BedrockProvider) ChatStream(ctx context.Context, req *providers.Conver
 
 	res, err := bp.Client.ConverseStream(ctx, converseStreamInput)
 	if err != nil {
-		return nil, err
+		return nil, convertBedrockError(err)
 	}
 
 	eventCh := make(chan providers.StreamChunk, defaultBedrockStreamBufferSize)
@@ -261,6 +294,9 @@ func (bp *BedrockProvider) ChatStream(ctx context.Context, req *providers.Conver
 		defer stream.Close()
 
 		var stopReason providers.StopReason = providers.StopReasonStop
+		// tool inputs arrive as partial JSON, a tool call is emitted when its content block stops
+		toolCalls := map[int32]*providers.ContentBlockToolUse{}
+		toolInputs := map[int32]*strings.Builder{}
 
 		for event := range stream.Events() {
 			select {
@@ -276,6 +312,15 @@ func (bp *BedrockProvider) ChatStream(ctx context.Context, req *providers.Conver
 			switch v := event.(type) {
 			case *types.ConverseStreamOutputMemberMessageStart:
 				// Message start event, nothing specific to handle
+			case *types.ConverseStreamOutputMemberContentBlockStart:
+				if start, ok := v.Value.Start.(*types.ContentBlockStartMemberToolUse); ok {
+					idx := aws.ToInt32(v.Value.ContentBlockIndex)
+					toolCalls[idx] = &providers.ContentBlockToolUse{
+						ID:   aws.ToString(start.Value.ToolUseId),
+						Name: aws.ToString(start.Value.Name),
+					}
+					toolInputs[idx] = &strings.Builder{}
+				}
 			case *types.ConverseStreamOutputMemberContentBlockDelta:
 				if textDelta, ok := v.Value.Delta.(*types.ContentBlockDeltaMemberText); ok {
 					eventCh <- &providers.StreamChunkContent{
@@ -284,15 +329,30 @@ func (bp *BedrockProvider) ChatStream(ctx context.Context, req *providers.Conver
 						},
 					}
 				}
+				if toolDelta, ok := v.Value.Delta.(*types.ContentBlockDeltaMemberToolUse); ok {
+					if input, ok := toolInputs[aws.ToInt32(v.Value.ContentBlockIndex)]; ok {
+						input.WriteString(aws.ToString(toolDelta.Value.Input))
+					}
+				}
 			case *types.ConverseStreamOutputMemberMessageStop:
 				if v.Value.StopReason != "" {
 					stopReason = convertBedrockStopReason(v.Value.StopReason)
 				}
+				if req.ResponseSchema != nil && stopReason == providers.StopReasonToolCalls {
+					stopReason = providers.StopReasonStop
+				}
 				eventCh <- &providers.StreamChunkStop{
 					StopReason: stopReason,
 				}
 			case *types.ConverseStreamOutputMemberContentBlockStop:
-				// Content block stop event, nothing specific to handle
+				idx := aws.ToInt32(v.Value.ContentBlockIndex)
+				if call, ok := toolCalls[idx]; ok {
+					eventCh <- &providers.StreamChunkContent{
+						Content: convertBedrockToolUse(call.ID, call.Name, json.RawMessage(toolInputs[idx].String()), req),
+					}
+					delete(toolCalls, idx)
+					delete(toolInputs, idx)
+				}
 			case *types.ConverseStreamOutputMemberMetadata:
 				if v.Value.Usage != nil {
 					usage := convertBedrockUsage(v.Value.Usage)
@@ -308,6 +368,13 @@ func (bp *BedrockProvider) ChatStream(ctx context.Context, req *providers.Conver
 				logrus.Debugf("Unhandled event type: %T\n", v)
 			}
 		}
+
+		if err := stream.Err(); err != nil {
+			eventCh <- &providers.StreamChunkError{
+				Error: err.Error(),
+				Err:   convertBedrockError(err),
+			}
+		}
 	}()
 
 	return &BedrockStreamOutput{
@@ -315,6 +382,15 @@ func (bp *BedrockProvider) ChatStream(ctx context.Context, req *providers.Conver
 	}, nil
 }
 
+func convertBedrockError(err error) error {
+	// aws response errors carry the failed HTTP response, throttling is reported as 429 with a Retry-After header
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) && respErr.Response != nil && respErr.Response.Response != nil {
+		return providers.NewAPIError(respErr.HTTPStatusCode(), respErr.Response.Header, err)
+	}
+	return err
+}
+
 func prepareBedrockMessages(messages []providers.Message) []types.Message {
 	var bedrockMessages []types.Message
 	for _, msg := range messages {
@@ -325,9 +401,32 @@ func prepareBedrockMessages(messages []providers.Message) []types.Message {
 
 		var contentBlocks []types.ContentBlock
 		for _, content := range msg.Content {
-			if textBlock, ok := content.(*providers.ContentBlockText); ok {
+			switch block := content.(type) {
+			case *providers.ContentBlockText:
 				contentBlocks = append(contentBlocks, &types.ContentBlockMemberText{
-					Value: textBlock.Value,
+					Value: block.Value,
+				})
+			case *providers.ContentBlockToolUse:
+				contentBlocks = append(contentBlocks, &types.ContentBlockMemberToolUse{
+					Value: types.ToolUseBlock{
+						ToolUseId: aws.String(block.ID),
+						Name:      aws.String(block.Name),
+						Input:     document.NewLazyDocument(schemaDocument(block.Input)),
+					},
+				})
+			case *providers.ContentBlockToolResult:
+				status := types.ToolResultStatusSuccess
+				if block.IsError {
+					status = types.ToolResultStatusError
+				}
+				contentBlocks = append(contentBlocks, &types.ContentBlockMemberToolResult{
+					Value: types.ToolResultBlock{
+						ToolUseId: aws.String(block.ToolUseID),
+						Content: []types.ToolResultContentBlock{
+							&types.ToolResultContentBlockMemberText{Value: block.Content},
+						},
+						Status: status,
+					},
 				})
 			}
 		}
@@ -365,3 +464,64 @@ func convertBedrockUsage(usage *types.TokenUsage) *providers.ModelUsage {
 		TotalTokens:  int(*usage.TotalTokens),
 	}
 }
+
+// prepareBedrockToolConfig converts tools of the request. A response schema becomes a tool the model is forced to call,
+// its input is the structured answer.
+func prepareBedrockToolConfig(req *providers.ConversationRequest) *types.ToolConfiguration {
+	var tools []types.Tool
+	for _, tool := range req.Tools {
+		tools = append(tools, bedrockTool(tool.Name, tool.Description, tool.InputSchema))
+	}
+
+	var toolChoice types.ToolChoice
+	if req.ResponseSchema != nil {
+		tools = append(tools, bedrockTool(req.ResponseSchema.SchemaName(), req.ResponseSchema.Description, req.ResponseSchema.Schema))
+		toolChoice = &types.ToolChoiceMemberTool{
+			Value: types.SpecificToolChoice{Name: aws.String(req.ResponseSchema.SchemaName())},
+		}
+	}
+
+	if len(tools) == 0 {
+		return nil
+	}
+	return &types.ToolConfiguration{
+		Tools:      tools,
+		ToolChoice: toolChoice,
+	}
+}
+
+func bedrockTool(name, description string, schema json.RawMessage) types.Tool {
+	spec := types.ToolSpecification{
+		Name: aws.String(name),
+		InputSchema: &types.ToolInputSchemaMemberJson{
+			Value: document.NewLazyDocument(schemaDocument(schema)),
+		},
+	}
+	if description != "" {
+		spec.Description = aws.String(description)
+	}
+	return &types.ToolMemberToolSpec{Value: spec}
+}
+
+// schemaDocument decodes raw JSON, so it can be wrapped into a smithy document.
+func schemaDocument(raw json.RawMessage) any {
+	var doc any = map[string]any{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &doc); err != nil {
+			logrus.Debugf("Invalid JSON in tool definition: %v", err)
+		}
+	}
+	return doc
+}
+
+// convertBedrockToolUse converts tool use block, a call of the response schema tool is the structured answer.
+func convertBedrockToolUse(id, name string, input json.RawMessage, req *providers.ConversationRequest) providers.ContentBlock {
+	if req.ResponseSchema != nil && name == req.ResponseSchema.SchemaName() {
+		return &providers.ContentBlockText{Value: string(input)}
+	}
+	return &providers.ContentBlockToolUse{
+		ID:    id,
+		Name:  name,
+		Input: input,
+	}
+}
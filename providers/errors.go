@@ -0,0 +1,91 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// APIError is a failed call to a model API.
+// Providers convert errors of their SDKs to it, so failures can be retried the same way for every provider.
+type APIError struct {
+	StatusCode int
+	// RetryAfter is the delay requested by the API, zero if it wasn't specified.
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the call may succeed if repeated: rate limits, timeouts and server side failures.
+// A conflict is not transient, the same request would conflict again.
+func (e *APIError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusRequestTimeout:
+		return true
+	}
+	return e.StatusCode >= http.StatusInternalServerError
+}
+
+// NewAPIError wraps err with the HTTP status and the Retry-After hint of a failed response, header may be nil.
+func NewAPIError(statusCode int, header http.Header, err error) error {
+	return &APIError{
+		StatusCode: statusCode,
+		RetryAfter: ParseRetryAfter(header),
+		Err:        err,
+	}
+}
+
+// ParseRetryAfter reads the delay requested by the API.
+// Besides the standard Retry-After (seconds or HTTP date) it understands retry-after-ms sent by OpenAI and Anthropic.
+func ParseRetryAfter(header http.Header) time.Duration {
+	if header == nil {
+		return 0
+	}
+	if ms, err := strconv.ParseFloat(header.Get("retry-after-ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+// IsRetryable reports whether err is a transient failure: a retryable APIError or a network error.
+// Cancelled and expired contexts are never retried.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
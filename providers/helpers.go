@@ -2,6 +2,8 @@ package providers
 
 import (
 	"strings"
+
+	"github.com/sirupsen/logrus"
 )
 
 func ExtractJSON(text string) string {
@@ -26,3 +28,36 @@ func ExtractJSON(text string) string {
 	// If no JSON found, return original string
 	return text
 }
+
+// DefaultResponseSchemaName is used for a ResponseSchema without a name.
+const DefaultResponseSchemaName = "response"
+
+// SchemaName returns name of the response schema, providers use it as a tool name when structured output is emulated.
+func (s *ResponseSchema) SchemaName() string {
+	if s.Name == "" {
+		return DefaultResponseSchemaName
+	}
+	return s.Name
+}
+
+// ToolCalls returns tool calls requested by the model.
+func ToolCalls(resp *ConversationResponse) []*ContentBlockToolUse {
+	var res []*ContentBlockToolUse
+	for _, block := range resp.Content {
+		if call, ok := block.(*ContentBlockToolUse); ok {
+			res = append(res, call)
+		}
+	}
+	return res
+}
+
+// ReasoningWithForcedTool reports whether extended thinking can be enabled for providers that emulate
+// structured output with a forced tool call, their APIs don't allow both in a single request.
+// Reasoning is dropped with a warning when a response schema is enforced.
+func ReasoningWithForcedTool(providerName string, req *ConversationRequest) bool {
+	if req.Reasoning && req.ResponseSchema != nil {
+		logrus.Warnf("%s: reasoning is turned off because the response schema %s is enforced with a forced tool call", providerName, req.ResponseSchema.SchemaName())
+		return false
+	}
+	return req.Reasoning
+}
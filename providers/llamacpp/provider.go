@@ -0,0 +1,409 @@
+package llamacpp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/centralmind/gateway/providers"
+)
+
+const (
+	defaultLlamaCppEndpoint         = "http://localhost:8080"
+	defaultLlamaCppModelId          = "local"
+	defaultLlamaCppMaxTokens        = 16_000
+	defaultLlamaCppStreamBufferSize = 100
+	// charsPerToken is a rough estimate used to fit the answer into the context window.
+	charsPerToken = 4
+)
+
+var (
+	ErrLlamaCppEmptyChoices      = errors.New("unexpected empty response from llama.cpp")
+	ErrLlamaCppToolsNotSupported = errors.New("tool calling is not supported by the llama.cpp provider")
+	ErrLlamaCppContextExceeded   = errors.New("prompt does not fit into llama.cpp context window, restart llama-server with larger --ctx-size")
+)
+
+type LlamaCppProvider struct {
+	Client   *http.Client
+	Endpoint string
+	APIKey   string
+
+	propsOnce   sync.Once
+	contextSize int
+}
+
+var _ providers.ModelProvider = (*LlamaCppProvider)(nil)
+
+func init() {
+	providers.RegisterModelProvider("llamacpp", NewLlamaCppProvider)
+}
+
+func (lp *LlamaCppProvider) GetName() string {
+	return "llama.cpp"
+}
+
+// CostEstimate is always zero, local models are free to run.
+func (lp *LlamaCppProvider) CostEstimate(modelId string, usage providers.ModelUsage) float64 {
+	return 0.0
+}
+
+func NewLlamaCppProvider(providerConfig providers.ModelProviderConfig) (providers.ModelProvider, error) {
+	effectiveEndpoint := providerConfig.Endpoint
+	if effectiveEndpoint == "" {
+		effectiveEndpoint = os.Getenv("LLAMACPP_ENDPOINT")
+		if effectiveEndpoint == "" {
+			effectiveEndpoint = defaultLlamaCppEndpoint
+		}
+	}
+
+	effectiveAPIKey := providerConfig.APIKey
+	if effectiveAPIKey == "" {
+		effectiveAPIKey = os.Getenv("LLAMACPP_API_KEY")
+	}
+
+	return &LlamaCppProvider{
+		Client:   &http.Client{Timeout: 30 * time.Minute},
+		Endpoint: strings.TrimSuffix(strings.TrimSuffix(effectiveEndpoint, "/"), "/v1"),
+		APIKey:   effectiveAPIKey,
+	}, nil
+}
+
+type llamaCppMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type llamaCppResponseFormat struct {
+	Type       string              `json:"type"`
+	JSONSchema *llamaCppJSONSchema `json:"json_schema,omitempty"`
+}
+
+type llamaCppJSONSchema struct {
+	Name   string          `json:"name,omitempty"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+type llamaCppStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type llamaCppChatRequest struct {
+	Model          string                  `json:"model"`
+	Messages       []llamaCppMessage       `json:"messages"`
+	MaxTokens      int                     `json:"max_tokens,omitempty"`
+	Temperature    *float32                `json:"temperature,omitempty"`
+	Stream         bool                    `json:"stream"`
+	StreamOptions  *llamaCppStreamOptions  `json:"stream_options,omitempty"`
+	ResponseFormat *llamaCppResponseFormat `json:"response_format,omitempty"`
+}
+
+type llamaCppUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type llamaCppChoice struct {
+	Message      llamaCppMessage `json:"message"`
+	Delta        llamaCppMessage `json:"delta"`
+	FinishReason string          `json:"finish_reason"`
+}
+
+type llamaCppChatResponse struct {
+	Model   string           `json:"model"`
+	Choices []llamaCppChoice `json:"choices"`
+	Usage   *llamaCppUsage   `json:"usage"`
+}
+
+type llamaCppError struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type llamaCppProps struct {
+	DefaultGenerationSettings struct {
+		NContext int `json:"n_ctx"`
+	} `json:"default_generation_settings"`
+}
+
+// loadContextSize reads context window of the running server, zero if it is unknown.
+func (lp *LlamaCppProvider) loadContextSize(ctx context.Context) int {
+	lp.propsOnce.Do(func() {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, lp.Endpoint+"/props", nil)
+		if err != nil {
+			return
+		}
+		lp.authorize(httpReq)
+		resp, err := lp.Client.Do(httpReq)
+		if err != nil {
+			return
+		}
+		defer resp.Body.Close()
+		var props llamaCppProps
+		if resp.StatusCode == http.StatusOK && json.NewDecoder(resp.Body).Decode(&props) == nil {
+			lp.contextSize = props.DefaultGenerationSettings.NContext
+		}
+	})
+	return lp.contextSize
+}
+
+func (lp *LlamaCppProvider) authorize(httpReq *http.Request) {
+	if lp.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+lp.APIKey)
+	}
+}
+
+func (lp *LlamaCppProvider) prepareRequest(ctx context.Context, req *providers.ConversationRequest, stream bool) (llamaCppChatRequest, error) {
+	if len(req.Tools) > 0 {
+		return llamaCppChatRequest{}, ErrLlamaCppToolsNotSupported
+	}
+
+	modelId := req.ModelId
+	if modelId == "" {
+		if envModelId := os.Getenv("LLAMACPP_MODEL_ID"); envModelId != "" {
+			modelId = envModelId
+		} else {
+			modelId = defaultLlamaCppModelId
+		}
+	}
+
+	maxTokens := defaultLlamaCppMaxTokens
+	if req.MaxTokens > 0 {
+		maxTokens = req.MaxTokens
+	}
+
+	messages := prepareLlamaCppMessages(req.Messages, req.System)
+
+	// llama-server rejects requests that exceed its context window, so the answer is shrunk to fit.
+	if contextSize := lp.loadContextSize(ctx); contextSize > 0 {
+		var chars int
+		for _, msg := range messages {
+			chars += len(msg.Content)
+		}
+		available := contextSize - chars/charsPerToken
+		if available <= 0 {
+			return llamaCppChatRequest{}, ErrLlamaCppContextExceeded
+		}
+		maxTokens = min(maxTokens, available)
+	}
+
+	request := llamaCppChatRequest{
+		Model:     modelId,
+		Messages:  messages,
+		MaxTokens: maxTokens,
+		Stream:    stream,
+	}
+	if stream {
+		request.StreamOptions = &llamaCppStreamOptions{IncludeUsage: true}
+	}
+	if !req.DefaultTemperature {
+		temperature := max(req.Temperature, 0.0)
+		request.Temperature = &temperature
+	}
+	if req.JsonResponse {
+		request.ResponseFormat = &llamaCppResponseFormat{Type: "json_object"}
+	}
+	if req.ResponseSchema != nil {
+		// llama-server compiles the schema into a grammar, so the answer always matches it
+		request.ResponseFormat = &llamaCppResponseFormat{
+			Type: "json_schema",
+			JSONSchema: &llamaCppJSONSchema{
+				Name:   req.ResponseSchema.SchemaName(),
+				Schema: req.ResponseSchema.Schema,
+			},
+		}
+	}
+	return request, nil
+}
+
+func (lp *LlamaCppProvider) post(ctx context.Context, request llamaCppChatRequest) (*http.Response, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, lp.Endpoint+"/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	lp.authorize(httpReq)
+	resp, err := lp.Client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(resp.Body)
+		var errResp llamaCppError
+		if json.Unmarshal(raw, &errResp) == nil && errResp.Error.Message != "" {
+			return nil, providers.NewAPIError(resp.StatusCode, resp.Header, fmt.Errorf("llama.cpp error (status %d): %s", resp.StatusCode, errResp.Error.Message))
+		}
+		return nil, providers.NewAPIError(resp.StatusCode, resp.Header, fmt.Errorf("llama.cpp error (status %d): %s", resp.StatusCode, strings.TrimSpace(string(raw))))
+	}
+	return resp, nil
+}
+
+func (lp *LlamaCppProvider) Chat(ctx context.Context, req *providers.ConversationRequest) (*providers.ConversationResponse, error) {
+	request, err := lp.prepareRequest(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := lp.post(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var chatResp llamaCppChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return nil, fmt.Errorf("unable to decode llama.cpp response: %w", err)
+	}
+	if len(chatResp.Choices) == 0 {
+		return nil, ErrLlamaCppEmptyChoices
+	}
+
+	content := chatResp.Choices[0].Message.Content
+	if req.JsonResponse || req.ResponseSchema != nil {
+		content = providers.ExtractJSON(content)
+	}
+
+	return &providers.ConversationResponse{
+		ProviderName: "llama.cpp",
+		ModelId:      request.Model,
+		Content: []providers.ContentBlock{
+			&providers.ContentBlockText{Value: content},
+		},
+		StopReason: convertLlamaCppStopReason(chatResp.Choices[0].FinishReason),
+		Usage:      convertLlamaCppUsage(chatResp.Usage),
+	}, nil
+}
+
+type LlamaCppStreamOutput struct {
+	stream *LlamaCppStream
+}
+
+func (o *LlamaCppStreamOutput) GetStream() providers.ChatStream {
+	return o.stream
+}
+
+type LlamaCppStream struct {
+	eventCh chan providers.StreamChunk
+}
+
+func (s *LlamaCppStream) Events() <-chan providers.StreamChunk {
+	return s.eventCh
+}
+
+func (lp *LlamaCppProvider) ChatStream(ctx context.Context, req *providers.ConversationRequest) (providers.ChatStreamOutput, error) {
+	request, err := lp.prepareRequest(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := lp.post(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	eventCh := make(chan providers.StreamChunk, defaultLlamaCppStreamBufferSize)
+	llamaCppStream := &LlamaCppStream{
+		eventCh: eventCh,
+	}
+
+	go func() {
+		defer close(eventCh)
+		defer resp.Body.Close()
+
+		// llama-server streams server-sent events in OpenAI format, terminated by [DONE].
+		var usage *llamaCppUsage
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if data == "[DONE]" {
+				break
+			}
+			var chunk llamaCppChatResponse
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				eventCh <- &providers.StreamChunkError{Error: err.Error()}
+				return
+			}
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+			for _, choice := range chunk.Choices {
+				if choice.Delta.Content != "" {
+					eventCh <- &providers.StreamChunkContent{
+						Content: &providers.ContentBlockText{Value: choice.Delta.Content},
+					}
+				}
+				if choice.FinishReason != "" {
+					eventCh <- &providers.StreamChunkStop{StopReason: convertLlamaCppStopReason(choice.FinishReason)}
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			eventCh <- &providers.StreamChunkError{Error: err.Error(), Err: err}
+			return
+		}
+		eventCh <- &providers.StreamChunkUsage{ModelId: request.Model, Usage: convertLlamaCppUsage(usage)}
+	}()
+
+	return &LlamaCppStreamOutput{
+		stream: llamaCppStream,
+	}, nil
+}
+
+func prepareLlamaCppMessages(messages []providers.Message, systemContent string) []llamaCppMessage {
+	var res []llamaCppMessage
+	if systemContent != "" {
+		res = append(res, llamaCppMessage{Role: "system", Content: systemContent})
+	}
+	for _, msg := range messages {
+		var content strings.Builder
+		for _, block := range msg.Content {
+			if text, ok := block.(*providers.ContentBlockText); ok {
+				content.WriteString(text.Value)
+			}
+		}
+		res = append(res, llamaCppMessage{Role: string(msg.Role), Content: content.String()})
+	}
+	return res
+}
+
+func convertLlamaCppStopReason(reason string) providers.StopReason {
+	switch reason {
+	case "length":
+		return providers.StopReasonLength
+	case "tool_calls":
+		return providers.StopReasonToolCalls
+	default:
+		return providers.StopReasonStop
+	}
+}
+
+func convertLlamaCppUsage(usage *llamaCppUsage) *providers.ModelUsage {
+	if usage == nil {
+		return &providers.ModelUsage{}
+	}
+	return &providers.ModelUsage{
+		InputTokens:  usage.PromptTokens,
+		OutputTokens: usage.CompletionTokens,
+		TotalTokens:  usage.TotalTokens,
+	}
+}
@@ -0,0 +1,125 @@
+package llamacpp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/centralmind/gateway/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func request() *providers.ConversationRequest {
+	return &providers.ConversationRequest{
+		System:       "respond in JSON",
+		JsonResponse: true,
+		Temperature:  0.2,
+		Messages: []providers.Message{
+			{Role: providers.UserRole, Content: []providers.ContentBlock{&providers.ContentBlockText{Value: "hello"}}},
+		},
+	}
+}
+
+// newTestServer emulates llama-server with the given context size.
+func newTestServer(t *testing.T, contextSize int, chat http.HandlerFunc) providers.ModelProvider {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/props", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"default_generation_settings":{"n_ctx":%d}}`, contextSize)
+	})
+	mux.HandleFunc("/v1/chat/completions", chat)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	provider, err := providers.NewModelProvider(providers.ModelProviderConfig{Name: "llamacpp", Endpoint: srv.URL + "/v1", APIKey: "secret"})
+	require.NoError(t, err)
+	return provider
+}
+
+func TestChat(t *testing.T) {
+	var received llamaCppChatRequest
+	provider := newTestServer(t, 4096, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"{\"ok\": true}"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`)
+	})
+
+	resp, err := provider.Chat(context.Background(), request())
+	require.NoError(t, err)
+
+	assert.Equal(t, defaultLlamaCppModelId, received.Model)
+	assert.Equal(t, "json_object", received.ResponseFormat.Type)
+	require.NotNil(t, received.Temperature)
+	assert.InDelta(t, 0.2, *received.Temperature, 0.001)
+	assert.Less(t, received.MaxTokens, 4096)
+	require.Len(t, received.Messages, 2)
+	assert.Equal(t, "system", received.Messages[0].Role)
+
+	assert.Equal(t, `{"ok": true}`, resp.Content[0].(*providers.ContentBlockText).Value)
+	assert.Equal(t, providers.StopReasonStop, resp.StopReason)
+	assert.Equal(t, &providers.ModelUsage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15}, resp.Usage)
+	assert.Zero(t, provider.CostEstimate(resp.ModelId, *resp.Usage))
+}
+
+func TestContextExceeded(t *testing.T) {
+	provider := newTestServer(t, 100, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("request must not be sent")
+	})
+
+	req := request()
+	req.Messages[0].Content = []providers.ContentBlock{&providers.ContentBlockText{Value: strings.Repeat("x", 1000)}}
+	_, err := provider.Chat(context.Background(), req)
+	assert.ErrorIs(t, err, ErrLlamaCppContextExceeded)
+}
+
+func TestChatError(t *testing.T) {
+	provider := newTestServer(t, 4096, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"error":{"code":503,"message":"Loading model","type":"unavailable_error"}}`)
+	})
+
+	_, err := provider.Chat(context.Background(), request())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Loading model")
+}
+
+func TestChatStream(t *testing.T) {
+	provider := newTestServer(t, 4096, func(w http.ResponseWriter, r *http.Request) {
+		var received llamaCppChatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		assert.True(t, received.Stream)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"{\\\"ok\\\"\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\": true}\"},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":4,\"total_tokens\":7}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	output, err := provider.ChatStream(context.Background(), request())
+	require.NoError(t, err)
+
+	var content string
+	var stop *providers.StreamChunkStop
+	var usage *providers.StreamChunkUsage
+	for chunk := range output.GetStream().Events() {
+		switch chunk := chunk.(type) {
+		case *providers.StreamChunkContent:
+			content += chunk.Content.(*providers.ContentBlockText).Value
+		case *providers.StreamChunkStop:
+			stop = chunk
+		case *providers.StreamChunkUsage:
+			usage = chunk
+		case *providers.StreamChunkError:
+			t.Fatalf("unexpected error: %s", chunk.Error)
+		}
+	}
+	assert.Equal(t, `{"ok": true}`, content)
+	require.NotNil(t, stop)
+	assert.Equal(t, providers.StopReasonStop, stop.StopReason)
+	require.NotNil(t, usage)
+	assert.Equal(t, 7, usage.Usage.TotalTokens)
+}
@@ -0,0 +1,344 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/centralmind/gateway/providers"
+)
+
+const (
+	defaultOllamaEndpoint         = "http://localhost:11434"
+	defaultOllamaModelId          = "qwen2.5:32b"
+	defaultOllamaMaxTokens        = 16_000
+	defaultOllamaContextSize      = 8192
+	defaultOllamaStreamBufferSize = 100
+	// charsPerToken is a rough estimate used to size the context window before the model counts tokens.
+	charsPerToken = 4
+)
+
+var (
+	ErrOllamaEmptyResponse     = errors.New("unexpected empty response from Ollama")
+	ErrOllamaToolsNotSupported = errors.New("tool calling is not supported by the Ollama provider")
+)
+
+type OllamaProvider struct {
+	Client   *http.Client
+	Endpoint string
+	// ContextSize overrides the context window (num_ctx), if zero it is sized to fit every request.
+	ContextSize int
+}
+
+var _ providers.ModelProvider = (*OllamaProvider)(nil)
+
+func init() {
+	providers.RegisterModelProvider("ollama", NewOllamaProvider)
+}
+
+func (op *OllamaProvider) GetName() string {
+	return "Ollama"
+}
+
+// CostEstimate is always zero, local models are free to run.
+func (op *OllamaProvider) CostEstimate(modelId string, usage providers.ModelUsage) float64 {
+	return 0.0
+}
+
+func NewOllamaProvider(providerConfig providers.ModelProviderConfig) (providers.ModelProvider, error) {
+	effectiveEndpoint := providerConfig.Endpoint
+	if effectiveEndpoint == "" {
+		effectiveEndpoint = os.Getenv("OLLAMA_HOST")
+		if effectiveEndpoint == "" {
+			effectiveEndpoint = defaultOllamaEndpoint
+		}
+	}
+	if !strings.HasPrefix(effectiveEndpoint, "http://") && !strings.HasPrefix(effectiveEndpoint, "https://") {
+		effectiveEndpoint = "http://" + effectiveEndpoint
+	}
+
+	var contextSize int
+	if envContextSize := os.Getenv("OLLAMA_NUM_CTX"); envContextSize != "" {
+		size, err := strconv.Atoi(envContextSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OLLAMA_NUM_CTX: %w", err)
+		}
+		contextSize = size
+	}
+
+	return &OllamaProvider{
+		Client:      &http.Client{Timeout: 30 * time.Minute},
+		Endpoint:    strings.TrimSuffix(effectiveEndpoint, "/"),
+		ContextSize: contextSize,
+	}, nil
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaOptions struct {
+	Temperature *float32 `json:"temperature,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+	NumCtx      int      `json:"num_ctx,omitempty"`
+}
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	// Format is either "json" or a JSON schema the answer must match.
+	Format  json.RawMessage `json:"format,omitempty"`
+	Options ollamaOptions   `json:"options"`
+}
+
+type ollamaChatResponse struct {
+	Model           string        `json:"model"`
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+func (op *OllamaProvider) prepareRequest(req *providers.ConversationRequest, stream bool) (ollamaChatRequest, error) {
+	if len(req.Tools) > 0 {
+		return ollamaChatRequest{}, ErrOllamaToolsNotSupported
+	}
+
+	modelId := req.ModelId
+	if modelId == "" {
+		if envModelId := os.Getenv("OLLAMA_MODEL_ID"); envModelId != "" {
+			modelId = envModelId
+		} else {
+			modelId = defaultOllamaModelId
+		}
+	}
+
+	maxTokens := defaultOllamaMaxTokens
+	if req.MaxTokens > 0 {
+		maxTokens = req.MaxTokens
+	}
+
+	messages := prepareOllamaMessages(req.Messages, req.System)
+
+	request := ollamaChatRequest{
+		Model:    modelId,
+		Messages: messages,
+		Stream:   stream,
+		Options: ollamaOptions{
+			NumPredict: maxTokens,
+			NumCtx:     op.contextSize(messages, maxTokens),
+		},
+	}
+	if !req.DefaultTemperature {
+		temperature := max(req.Temperature, 0.0)
+		request.Options.Temperature = &temperature
+	}
+	if req.JsonResponse {
+		request.Format = json.RawMessage(`"json"`)
+	}
+	if req.ResponseSchema != nil {
+		request.Format = req.ResponseSchema.Schema
+	}
+	return request, nil
+}
+
+// contextSize returns num_ctx large enough for the prompt and the answer.
+// Ollama silently truncates prompts that don't fit its default window, which breaks discovery of large schemas.
+func (op *OllamaProvider) contextSize(messages []ollamaMessage, maxTokens int) int {
+	if op.ContextSize > 0 {
+		return op.ContextSize
+	}
+	var chars int
+	for _, msg := range messages {
+		chars += len(msg.Content)
+	}
+	needed := chars/charsPerToken + maxTokens
+	size := defaultOllamaContextSize
+	for size < needed {
+		size *= 2
+	}
+	return size
+}
+
+func (op *OllamaProvider) post(ctx context.Context, request ollamaChatRequest) (*http.Response, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, op.Endpoint+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := op.Client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(resp.Body)
+		var errResp ollamaChatResponse
+		if json.Unmarshal(raw, &errResp) == nil && errResp.Error != "" {
+			return nil, providers.NewAPIError(resp.StatusCode, resp.Header, fmt.Errorf("ollama error (status %d): %s", resp.StatusCode, errResp.Error))
+		}
+		return nil, providers.NewAPIError(resp.StatusCode, resp.Header, fmt.Errorf("ollama error (status %d): %s", resp.StatusCode, strings.TrimSpace(string(raw))))
+	}
+	return resp, nil
+}
+
+func (op *OllamaProvider) Chat(ctx context.Context, req *providers.ConversationRequest) (*providers.ConversationResponse, error) {
+	request, err := op.prepareRequest(req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := op.post(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var chatResp ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return nil, fmt.Errorf("unable to decode Ollama response: %w", err)
+	}
+	if chatResp.Message.Content == "" {
+		return nil, ErrOllamaEmptyResponse
+	}
+
+	content := chatResp.Message.Content
+	if req.JsonResponse || req.ResponseSchema != nil {
+		content = providers.ExtractJSON(content)
+	}
+
+	return &providers.ConversationResponse{
+		ProviderName: "Ollama",
+		ModelId:      request.Model,
+		Content: []providers.ContentBlock{
+			&providers.ContentBlockText{Value: content},
+		},
+		StopReason: convertOllamaStopReason(chatResp.DoneReason),
+		Usage:      convertOllamaUsage(chatResp),
+	}, nil
+}
+
+type OllamaStreamOutput struct {
+	stream *OllamaStream
+}
+
+func (o *OllamaStreamOutput) GetStream() providers.ChatStream {
+	return o.stream
+}
+
+type OllamaStream struct {
+	eventCh chan providers.StreamChunk
+}
+
+func (s *OllamaStream) Events() <-chan providers.StreamChunk {
+	return s.eventCh
+}
+
+func (op *OllamaProvider) ChatStream(ctx context.Context, req *providers.ConversationRequest) (providers.ChatStreamOutput, error) {
+	request, err := op.prepareRequest(req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := op.post(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	eventCh := make(chan providers.StreamChunk, defaultOllamaStreamBufferSize)
+	ollamaStream := &OllamaStream{
+		eventCh: eventCh,
+	}
+
+	go func() {
+		defer close(eventCh)
+		defer resp.Body.Close()
+
+		// Ollama streams newline delimited JSON objects, the last one has done set and carries usage.
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var chunk ollamaChatResponse
+			if err := json.Unmarshal(line, &chunk); err != nil {
+				eventCh <- &providers.StreamChunkError{Error: err.Error()}
+				return
+			}
+			if chunk.Error != "" {
+				eventCh <- &providers.StreamChunkError{Error: chunk.Error}
+				return
+			}
+			if chunk.Message.Content != "" {
+				eventCh <- &providers.StreamChunkContent{
+					Content: &providers.ContentBlockText{Value: chunk.Message.Content},
+				}
+			}
+			if chunk.Done {
+				eventCh <- &providers.StreamChunkStop{StopReason: convertOllamaStopReason(chunk.DoneReason)}
+				eventCh <- &providers.StreamChunkUsage{ModelId: request.Model, Usage: convertOllamaUsage(chunk)}
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			eventCh <- &providers.StreamChunkError{Error: err.Error(), Err: err}
+		}
+	}()
+
+	return &OllamaStreamOutput{
+		stream: ollamaStream,
+	}, nil
+}
+
+func prepareOllamaMessages(messages []providers.Message, systemContent string) []ollamaMessage {
+	var res []ollamaMessage
+	if systemContent != "" {
+		res = append(res, ollamaMessage{Role: "system", Content: systemContent})
+	}
+	for _, msg := range messages {
+		var content strings.Builder
+		for _, block := range msg.Content {
+			if text, ok := block.(*providers.ContentBlockText); ok {
+				content.WriteString(text.Value)
+			}
+		}
+		res = append(res, ollamaMessage{Role: string(msg.Role), Content: content.String()})
+	}
+	return res
+}
+
+func convertOllamaStopReason(reason string) providers.StopReason {
+	switch reason {
+	case "length":
+		return providers.StopReasonLength
+	default:
+		return providers.StopReasonStop
+	}
+}
+
+func convertOllamaUsage(resp ollamaChatResponse) *providers.ModelUsage {
+	return &providers.ModelUsage{
+		InputTokens:  resp.PromptEvalCount,
+		OutputTokens: resp.EvalCount,
+		TotalTokens:  resp.PromptEvalCount + resp.EvalCount,
+	}
+}
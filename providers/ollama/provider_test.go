@@ -0,0 +1,143 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/centralmind/gateway/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func request() *providers.ConversationRequest {
+	return &providers.ConversationRequest{
+		ModelId:      "llama3",
+		System:       "respond in JSON",
+		JsonResponse: true,
+		Temperature:  -1,
+		Messages: []providers.Message{
+			{Role: providers.UserRole, Content: []providers.ContentBlock{&providers.ContentBlockText{Value: "hello"}}},
+		},
+	}
+}
+
+func newTestProvider(t *testing.T, handler http.HandlerFunc) providers.ModelProvider {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	provider, err := providers.NewModelProvider(providers.ModelProviderConfig{Name: "ollama", Endpoint: srv.URL})
+	require.NoError(t, err)
+	return provider
+}
+
+func TestChat(t *testing.T) {
+	var received ollamaChatRequest
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		fmt.Fprint(w, `{"model":"llama3","message":{"role":"assistant","content":"Sure: {\"ok\": true}"},"done":true,"done_reason":"stop","prompt_eval_count":12,"eval_count":7}`)
+	})
+
+	resp, err := provider.Chat(context.Background(), request())
+	require.NoError(t, err)
+
+	assert.Equal(t, "llama3", received.Model)
+	assert.False(t, received.Stream)
+	assert.JSONEq(t, `"json"`, string(received.Format))
+	require.NotNil(t, received.Options.Temperature, "a negative temperature is clamped to zero")
+	assert.Zero(t, *received.Options.Temperature)
+	assert.Equal(t, 16384, received.Options.NumCtx)
+	assert.Equal(t, defaultOllamaMaxTokens, received.Options.NumPredict)
+	require.Len(t, received.Messages, 2)
+	assert.Equal(t, ollamaMessage{Role: "system", Content: "respond in JSON"}, received.Messages[0])
+	assert.Equal(t, ollamaMessage{Role: "user", Content: "hello"}, received.Messages[1])
+
+	assert.Equal(t, `{"ok": true}`, resp.Content[0].(*providers.ContentBlockText).Value)
+	assert.Equal(t, providers.StopReasonStop, resp.StopReason)
+	assert.Equal(t, &providers.ModelUsage{InputTokens: 12, OutputTokens: 7, TotalTokens: 19}, resp.Usage)
+	assert.Zero(t, provider.CostEstimate(resp.ModelId, *resp.Usage))
+}
+
+func TestChatResponseSchema(t *testing.T) {
+	var received ollamaChatRequest
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":"{\"ok\": true}"},"done":true}`)
+	})
+
+	req := request()
+	req.ResponseSchema = &providers.ResponseSchema{Schema: json.RawMessage(`{"type":"object","properties":{"ok":{"type":"boolean"}}}`)}
+	_, err := provider.Chat(context.Background(), req)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"object","properties":{"ok":{"type":"boolean"}}}`, string(received.Format))
+
+	req.Tools = []providers.Tool{{Name: "lookup"}}
+	_, err = provider.Chat(context.Background(), req)
+	assert.ErrorIs(t, err, ErrOllamaToolsNotSupported)
+}
+
+func TestContextSizeFitsPrompt(t *testing.T) {
+	var received ollamaChatRequest
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":"{}"},"done":true}`)
+	})
+
+	req := request()
+	req.MaxTokens = 1000
+	req.Messages[0].Content = []providers.ContentBlock{&providers.ContentBlockText{Value: strings.Repeat("x", 100_000)}}
+	_, err := provider.Chat(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 32768, received.Options.NumCtx)
+	assert.Equal(t, 1000, received.Options.NumPredict)
+}
+
+func TestChatError(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"model \"llama3\" not found, try pulling it first"}`)
+	})
+
+	_, err := provider.Chat(context.Background(), request())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "try pulling it first")
+}
+
+func TestChatStream(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		var received ollamaChatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		assert.True(t, received.Stream)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"{\"ok\""},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":": true}"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true,"done_reason":"length","prompt_eval_count":3,"eval_count":4}`)
+	})
+
+	output, err := provider.ChatStream(context.Background(), request())
+	require.NoError(t, err)
+
+	var content string
+	var stop *providers.StreamChunkStop
+	var usage *providers.StreamChunkUsage
+	for chunk := range output.GetStream().Events() {
+		switch chunk := chunk.(type) {
+		case *providers.StreamChunkContent:
+			content += chunk.Content.(*providers.ContentBlockText).Value
+		case *providers.StreamChunkStop:
+			stop = chunk
+		case *providers.StreamChunkUsage:
+			usage = chunk
+		case *providers.StreamChunkError:
+			t.Fatalf("unexpected error: %s", chunk.Error)
+		}
+	}
+	assert.Equal(t, `{"ok": true}`, content)
+	require.NotNil(t, stop)
+	assert.Equal(t, providers.StopReasonLength, stop.StopReason)
+	require.NotNil(t, usage)
+	assert.Equal(t, 7, usage.Usage.TotalTokens)
+}
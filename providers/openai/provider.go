@@ -2,12 +2,15 @@ package openai
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"net/http"
 	"os"
 	"strings"
 
 	"github.com/centralmind/gateway/providers"
 	openai "github.com/sashabaranov/go-openai"
+	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -116,6 +119,7 @@ func NewOpenAIProviderIntl(providerConfig providers.ModelProviderConfig, gemini
 	if effectiveEndpoint != "" {
 		config.BaseURL = effectiveEndpoint
 	}
+	config.HTTPClient = &http.Client{Transport: &headerCapturingTransport{base: http.DefaultTransport}}
 	client := openai.NewClientWithConfig(config)
 
 	return &OpenAIProvider{
@@ -171,7 +175,7 @@ func (op *OpenAIProvider) Chat(ctx context.Context, req *providers.ConversationR
 		}
 	}
 
-	if supportsTemperature {
+	if supportsTemperature && !req.DefaultTemperature {
 		request.Temperature = max(req.Temperature, 0.0)
 	}
 
@@ -185,9 +189,12 @@ func (op *OpenAIProvider) Chat(ctx context.Context, req *providers.ConversationR
 		}
 	}
 
+	applyOpenAIStructuredOutput(&request, req)
+
+	ctx, header := withResponseHeader(ctx)
 	resp, err := op.Client.CreateChatCompletion(ctx, request)
 	if err != nil {
-		return nil, err
+		return nil, convertOpenAIError(err, *header)
 	}
 
 	if len(resp.Choices) == 0 {
@@ -195,13 +202,23 @@ func (op *OpenAIProvider) Chat(ctx context.Context, req *providers.ConversationR
 	}
 
 	var responseContentBlocks []providers.ContentBlock
-	if req.JsonResponse {
-		responseContentBlocks = append(responseContentBlocks, &providers.ContentBlockText{
-			Value: providers.ExtractJSON(resp.Choices[0].Message.Content),
-		})
-	} else {
-		responseContentBlocks = append(responseContentBlocks, &providers.ContentBlockText{
-			Value: resp.Choices[0].Message.Content,
+	message := resp.Choices[0].Message
+	if message.Content != "" || len(message.ToolCalls) == 0 {
+		if req.JsonResponse || req.ResponseSchema != nil {
+			responseContentBlocks = append(responseContentBlocks, &providers.ContentBlockText{
+				Value: providers.ExtractJSON(message.Content),
+			})
+		} else {
+			responseContentBlocks = append(responseContentBlocks, &providers.ContentBlockText{
+				Value: message.Content,
+			})
+		}
+	}
+	for _, call := range message.ToolCalls {
+		responseContentBlocks = append(responseContentBlocks, &providers.ContentBlockToolUse{
+			ID:    call.ID,
+			Name:  call.Function.Name,
+			Input: json.RawMessage(call.Function.Arguments),
 		})
 	}
 
@@ -283,7 +300,7 @@ func (op *OpenAIProvider) ChatStream(ctx context.Context, req *providers.Convers
 		}
 	}
 
-	if supportsTemperature {
+	if supportsTemperature && !req.DefaultTemperature {
 		request.Temperature = max(req.Temperature, 0.0)
 	}
 
@@ -297,9 +314,12 @@ func (op *OpenAIProvider) ChatStream(ctx context.Context, req *providers.Convers
 		}
 	}
 
+	applyOpenAIStructuredOutput(&request, req)
+
+	ctx, header := withResponseHeader(ctx)
 	stream, err := op.Client.CreateChatCompletionStream(ctx, request)
 	if err != nil {
-		return nil, err
+		return nil, convertOpenAIError(err, *header)
 	}
 
 	eventCh := make(chan providers.StreamChunk, defaultOpenAIStreamBufferSize)
@@ -312,6 +332,9 @@ func (op *OpenAIProvider) ChatStream(ctx context.Context, req *providers.Convers
 		defer stream.Close()
 
 		var stopReason providers.StopReason = providers.StopReasonStop
+		// tool calls arrive in fragments, they are emitted as whole blocks when the choice finishes
+		var toolCalls []*providers.ContentBlockToolUse
+		var toolArguments []strings.Builder
 
 		for {
 			select {
@@ -326,6 +349,7 @@ func (op *OpenAIProvider) ChatStream(ctx context.Context, req *providers.Convers
 				if err != nil {
 					eventCh <- &providers.StreamChunkError{
 						Error: err.Error(),
+						Err:   convertOpenAIError(err, nil),
 					}
 					return
 				}
@@ -348,7 +372,31 @@ func (op *OpenAIProvider) ChatStream(ctx context.Context, req *providers.Convers
 						}
 					}
 
+					for _, delta := range choice.Delta.ToolCalls {
+						idx := len(toolCalls) - 1
+						if delta.Index != nil {
+							idx = *delta.Index
+						}
+						for idx >= len(toolCalls) {
+							toolCalls = append(toolCalls, &providers.ContentBlockToolUse{})
+							toolArguments = append(toolArguments, strings.Builder{})
+						}
+						if delta.ID != "" {
+							toolCalls[idx].ID = delta.ID
+						}
+						if delta.Function.Name != "" {
+							toolCalls[idx].Name = delta.Function.Name
+						}
+						toolArguments[idx].WriteString(delta.Function.Arguments)
+					}
+
 					if choice.FinishReason != "" {
+						for i, call := range toolCalls {
+							call.Input = json.RawMessage(toolArguments[i].String())
+							eventCh <- &providers.StreamChunkContent{Content: call}
+						}
+						toolCalls, toolArguments = nil, nil
+
 						stopReason = convertOpenAIStopReason(choice.FinishReason)
 						eventCh <- &providers.StreamChunkStop{
 							StopReason: stopReason,
@@ -381,21 +429,114 @@ func prepareOpenAIMessages(messages []providers.Message, systemContent string) [
 		}
 
 		var contentText string
+		var toolCalls []openai.ToolCall
+		var toolResults []openai.ChatCompletionMessage
 		for _, content := range msg.Content {
-			if textBlock, ok := content.(*providers.ContentBlockText); ok {
-				contentText += textBlock.Value
+			switch block := content.(type) {
+			case *providers.ContentBlockText:
+				contentText += block.Value
+			case *providers.ContentBlockToolUse:
+				toolCalls = append(toolCalls, openai.ToolCall{
+					ID:   block.ID,
+					Type: openai.ToolTypeFunction,
+					Function: openai.FunctionCall{
+						Name:      block.Name,
+						Arguments: string(block.Input),
+					},
+				})
+			case *providers.ContentBlockToolResult:
+				// OpenAI expects every tool result as a separate message with the tool role
+				toolResults = append(toolResults, openai.ChatCompletionMessage{
+					Role:       openai.ChatMessageRoleTool,
+					Content:    block.Content,
+					ToolCallID: block.ToolUseID,
+				})
 			}
 		}
 
+		openaiMessages = append(openaiMessages, toolResults...)
+		if contentText == "" && len(toolCalls) == 0 && len(toolResults) > 0 {
+			continue
+		}
 		openaiMessages = append(openaiMessages, openai.ChatCompletionMessage{
-			Role:    role,
-			Content: contentText,
+			Role:      role,
+			Content:   contentText,
+			ToolCalls: toolCalls,
 		})
 	}
 
 	return openaiMessages
 }
 
+// applyOpenAIStructuredOutput sets JSON schema response format and tools of the request.
+func applyOpenAIStructuredOutput(request *openai.ChatCompletionRequest, req *providers.ConversationRequest) {
+	if req.ResponseSchema != nil {
+		// Strict mode makes the API enforce the schema, schemas it can't express are only a hint for the model
+		schema, strict := req.ResponseSchema.Schema, true
+		if converted, err := providers.StrictSchema(schema); err == nil {
+			schema = converted
+		} else {
+			strict = false
+			logrus.Warnf("Response schema %s is not enforced strictly: %v", req.ResponseSchema.SchemaName(), err)
+		}
+		request.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:        req.ResponseSchema.SchemaName(),
+				Description: req.ResponseSchema.Description,
+				Schema:      schema,
+				Strict:      strict,
+			},
+		}
+	}
+
+	for _, tool := range req.Tools {
+		request.Tools = append(request.Tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.InputSchema,
+			},
+		})
+	}
+}
+
+// responseHeaderKey carries headers of a failed response from the transport to the provider,
+// go-openai errors don't expose them but Retry-After is needed to back off properly.
+type responseHeaderKey struct{}
+
+func withResponseHeader(ctx context.Context) (context.Context, *http.Header) {
+	header := new(http.Header)
+	return context.WithValue(ctx, responseHeaderKey{}, header), header
+}
+
+type headerCapturingTransport struct {
+	base http.RoundTripper
+}
+
+func (t *headerCapturingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.StatusCode >= http.StatusBadRequest {
+		if header, ok := req.Context().Value(responseHeaderKey{}).(*http.Header); ok {
+			*header = resp.Header.Clone()
+		}
+	}
+	return resp, err
+}
+
+func convertOpenAIError(err error, header http.Header) error {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode > 0 {
+		return providers.NewAPIError(apiErr.HTTPStatusCode, header, err)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) && reqErr.HTTPStatusCode > 0 {
+		return providers.NewAPIError(reqErr.HTTPStatusCode, header, err)
+	}
+	return err
+}
+
 func convertOpenAIStopReason(reason openai.FinishReason) providers.StopReason {
 	switch reason {
 	case openai.FinishReasonStop:
@@ -0,0 +1,138 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/centralmind/gateway/providers"
+	"golang.org/x/xerrors"
+)
+
+var (
+	ErrNoTranscript = errors.New("replay transcript not provided")
+	ErrNoRecording  = errors.New("no recorded response for request")
+)
+
+// ReplayProvider serves responses from a recorded transcript instead of calling a model.
+// Responses are matched by RequestKey. If the same request was recorded several times,
+// recordings are returned in order and the last one is repeated afterwards.
+type ReplayProvider struct {
+	mu        sync.Mutex
+	responses map[string][]Entry
+	served    map[string]int
+}
+
+var _ providers.ModelProvider = (*ReplayProvider)(nil)
+
+func init() {
+	providers.RegisterModelProvider("replay", NewReplayProvider)
+}
+
+func NewReplayProvider(providerConfig providers.ModelProviderConfig) (providers.ModelProvider, error) {
+	transcript := providerConfig.Transcript
+	if transcript == "" {
+		transcript = os.Getenv("REPLAY_TRANSCRIPT")
+		if transcript == "" {
+			return nil, ErrNoTranscript
+		}
+	}
+
+	entries, err := LoadTranscript(transcript)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayProviderFromEntries(entries), nil
+}
+
+func NewReplayProviderFromEntries(entries []Entry) *ReplayProvider {
+	responses := map[string][]Entry{}
+	for _, entry := range entries {
+		responses[entry.Key] = append(responses[entry.Key], entry)
+	}
+	return &ReplayProvider{
+		responses: responses,
+		served:    map[string]int{},
+	}
+}
+
+func (rp *ReplayProvider) GetName() string {
+	return "Replay"
+}
+
+// CostEstimate is always zero, replayed responses cost nothing.
+func (rp *ReplayProvider) CostEstimate(modelId string, usage providers.ModelUsage) float64 {
+	return 0.0
+}
+
+func (rp *ReplayProvider) lookup(req *providers.ConversationRequest) (Entry, error) {
+	key := RequestKey(req)
+
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	recorded := rp.responses[key]
+	if len(recorded) == 0 {
+		return Entry{}, xerrors.Errorf("%w: %s", ErrNoRecording, key)
+	}
+	idx := min(rp.served[key], len(recorded)-1)
+	rp.served[key]++
+	return recorded[idx], nil
+}
+
+func (rp *ReplayProvider) Chat(ctx context.Context, req *providers.ConversationRequest) (*providers.ConversationResponse, error) {
+	entry, err := rp.lookup(req)
+	if err != nil {
+		return nil, err
+	}
+
+	stopReason := entry.StopReason
+	if stopReason == "" {
+		stopReason = providers.StopReasonStop
+	}
+	usage := entry.Usage
+	if usage == nil {
+		usage = &providers.ModelUsage{}
+	}
+	return &providers.ConversationResponse{
+		ProviderName: rp.GetName(),
+		ModelId:      entry.ModelId,
+		Content:      entry.content(),
+		StopReason:   stopReason,
+		Usage:        usage,
+	}, nil
+}
+
+type ReplayStreamOutput struct {
+	stream *ReplayStream
+}
+
+func (o *ReplayStreamOutput) GetStream() providers.ChatStream {
+	return o.stream
+}
+
+type ReplayStream struct {
+	eventCh chan providers.StreamChunk
+}
+
+func (s *ReplayStream) Events() <-chan providers.StreamChunk {
+	return s.eventCh
+}
+
+func (rp *ReplayProvider) ChatStream(ctx context.Context, req *providers.ConversationRequest) (providers.ChatStreamOutput, error) {
+	resp, err := rp.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// every chunk is sent up front, so the buffer has to hold the content blocks, the stop and the usage
+	eventCh := make(chan providers.StreamChunk, len(resp.Content)+2)
+	for _, block := range resp.Content {
+		eventCh <- &providers.StreamChunkContent{Content: block}
+	}
+	eventCh <- &providers.StreamChunkStop{StopReason: resp.StopReason}
+	eventCh <- &providers.StreamChunkUsage{ModelId: resp.ModelId, Usage: resp.Usage}
+	close(eventCh)
+
+	return &ReplayStreamOutput{stream: &ReplayStream{eventCh: eventCh}}, nil
+}
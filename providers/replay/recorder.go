@@ -0,0 +1,131 @@
+package replay
+
+import (
+	"context"
+	"strings"
+
+	"github.com/centralmind/gateway/providers"
+)
+
+// Recorder wraps a real provider and appends every completed call to a transcript,
+// which can later be served by the replay provider.
+type Recorder struct {
+	provider providers.ModelProvider
+	writer   *transcriptWriter
+}
+
+var _ providers.ModelProvider = (*Recorder)(nil)
+
+// NewRecorder returns provider that records calls of the given provider into transcript file.
+// Entries are appended, so several runs or providers can share a single transcript.
+func NewRecorder(provider providers.ModelProvider, transcript string) *Recorder {
+	return &Recorder{
+		provider: provider,
+		writer:   &transcriptWriter{path: transcript},
+	}
+}
+
+func (r *Recorder) GetName() string {
+	return r.provider.GetName()
+}
+
+func (r *Recorder) CostEstimate(modelId string, usage providers.ModelUsage) float64 {
+	return r.provider.CostEstimate(modelId, usage)
+}
+
+func (r *Recorder) Chat(ctx context.Context, req *providers.ConversationRequest) (*providers.ConversationResponse, error) {
+	resp, err := r.provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.writer.append(Entry{
+		Key:          RequestKey(req),
+		ProviderName: resp.ProviderName,
+		ModelId:      resp.ModelId,
+		Prompt:       lastUserMessage(req),
+		Response:     responseText(resp),
+		ToolCalls:    responseToolCalls(resp),
+		StopReason:   resp.StopReason,
+		Usage:        resp.Usage,
+	}); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+type recordingStreamOutput struct {
+	stream *recordingStream
+}
+
+func (o *recordingStreamOutput) GetStream() providers.ChatStream {
+	return o.stream
+}
+
+type recordingStream struct {
+	eventCh chan providers.StreamChunk
+}
+
+func (s *recordingStream) Events() <-chan providers.StreamChunk {
+	return s.eventCh
+}
+
+// ChatStream passes stream chunks through and records the accumulated response once the stream ends without errors.
+func (r *Recorder) ChatStream(ctx context.Context, req *providers.ConversationRequest) (providers.ChatStreamOutput, error) {
+	output, err := r.provider.ChatStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	eventCh := make(chan providers.StreamChunk)
+	go func() {
+		defer close(eventCh)
+		entry := Entry{Key: RequestKey(req), ProviderName: r.provider.GetName(), Prompt: lastUserMessage(req)}
+		var text strings.Builder
+		failed := false
+		for chunk := range output.GetStream().Events() {
+			switch chunk := chunk.(type) {
+			case *providers.StreamChunkContent:
+				switch block := chunk.Content.(type) {
+				case *providers.ContentBlockText:
+					text.WriteString(block.Value)
+				case *providers.ContentBlockToolUse:
+					entry.ToolCalls = append(entry.ToolCalls, *block)
+				}
+			case *providers.StreamChunkStop:
+				entry.StopReason = chunk.StopReason
+			case *providers.StreamChunkUsage:
+				entry.ModelId = chunk.ModelId
+				entry.Usage = chunk.Usage
+			case *providers.StreamChunkError:
+				failed = true
+			}
+			// the consumer may stop reading once the context is cancelled, an unfinished stream is not recorded
+			select {
+			case eventCh <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if failed {
+			return
+		}
+		entry.Response = text.String()
+		if err := r.writer.append(entry); err != nil {
+			select {
+			case eventCh <- &providers.StreamChunkError{Error: err.Error()}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return &recordingStreamOutput{stream: &recordingStream{eventCh: eventCh}}, nil
+}
+
+func lastUserMessage(req *providers.ConversationRequest) string {
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == providers.UserRole {
+			return messageText(req.Messages[i])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,171 @@
+package replay
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/centralmind/gateway/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvider struct {
+	calls int
+}
+
+func (f *fakeProvider) GetName() string {
+	return "Fake"
+}
+
+func (f *fakeProvider) CostEstimate(modelId string, usage providers.ModelUsage) float64 {
+	return float64(usage.TotalTokens)
+}
+
+func (f *fakeProvider) Chat(ctx context.Context, req *providers.ConversationRequest) (*providers.ConversationResponse, error) {
+	f.calls++
+	return &providers.ConversationResponse{
+		ProviderName: f.GetName(),
+		ModelId:      "fake-model",
+		Content: []providers.ContentBlock{
+			&providers.ContentBlockText{Value: `{"answer": ` + string(rune('0'+f.calls)) + `}`},
+		},
+		StopReason: providers.StopReasonStop,
+		Usage:      &providers.ModelUsage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+	}, nil
+}
+
+func (f *fakeProvider) ChatStream(ctx context.Context, req *providers.ConversationRequest) (providers.ChatStreamOutput, error) {
+	resp, err := f.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	eventCh := make(chan providers.StreamChunk, 4)
+	eventCh <- &providers.StreamChunkContent{Content: &providers.ContentBlockText{Value: "streamed "}}
+	eventCh <- &providers.StreamChunkContent{Content: resp.Content[0]}
+	eventCh <- &providers.StreamChunkStop{StopReason: providers.StopReasonStop}
+	eventCh <- &providers.StreamChunkUsage{ModelId: resp.ModelId, Usage: resp.Usage}
+	close(eventCh)
+	return &ReplayStreamOutput{stream: &ReplayStream{eventCh: eventCh}}, nil
+}
+
+func request(prompt string) *providers.ConversationRequest {
+	return &providers.ConversationRequest{
+		System: "system",
+		Messages: []providers.Message{
+			{Role: providers.UserRole, Content: []providers.ContentBlock{&providers.ContentBlockText{Value: prompt}}},
+		},
+	}
+}
+
+func text(resp *providers.ConversationResponse) string {
+	return resp.Content[0].(*providers.ContentBlockText).Value
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+	transcript := filepath.Join(t.TempDir(), "transcript.jsonl")
+
+	fake := &fakeProvider{}
+	recorder := NewRecorder(fake, transcript)
+	first, err := recorder.Chat(ctx, request("first"))
+	require.NoError(t, err)
+	second, err := recorder.Chat(ctx, request("first"))
+	require.NoError(t, err)
+	other, err := recorder.Chat(ctx, request("other"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, fake.calls)
+	assert.Equal(t, 15.0, recorder.CostEstimate("fake-model", *first.Usage))
+
+	provider, err := providers.NewModelProvider(providers.ModelProviderConfig{Name: "replay", Transcript: transcript})
+	require.NoError(t, err)
+
+	t.Run("Responses are matched by request", func(t *testing.T) {
+		resp, err := provider.Chat(ctx, request("other"))
+		require.NoError(t, err)
+		assert.Equal(t, text(other), text(resp))
+		assert.Equal(t, "fake-model", resp.ModelId)
+		assert.Equal(t, 15, resp.Usage.TotalTokens)
+		assert.Zero(t, provider.CostEstimate(resp.ModelId, *resp.Usage))
+	})
+
+	t.Run("Repeated requests are served in order", func(t *testing.T) {
+		resp, err := provider.Chat(ctx, request("first"))
+		require.NoError(t, err)
+		assert.Equal(t, text(first), text(resp))
+		resp, err = provider.Chat(ctx, request("first"))
+		require.NoError(t, err)
+		assert.Equal(t, text(second), text(resp))
+		resp, err = provider.Chat(ctx, request("first"))
+		require.NoError(t, err)
+		assert.Equal(t, text(second), text(resp))
+	})
+
+	t.Run("Model options are not part of the key", func(t *testing.T) {
+		req := request("other")
+		req.ModelId = "another-model"
+		req.Temperature = 0.5
+		_, err := provider.Chat(ctx, req)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Unknown request fails", func(t *testing.T) {
+		_, err := provider.Chat(ctx, request("unknown"))
+		assert.ErrorIs(t, err, ErrNoRecording)
+	})
+}
+
+func TestRecordStream(t *testing.T) {
+	ctx := context.Background()
+	transcript := filepath.Join(t.TempDir(), "transcript.jsonl")
+
+	recorder := NewRecorder(&fakeProvider{}, transcript)
+	output, err := recorder.ChatStream(ctx, request("stream"))
+	require.NoError(t, err)
+	var streamed string
+	for chunk := range output.GetStream().Events() {
+		if content, ok := chunk.(*providers.StreamChunkContent); ok {
+			streamed += content.Content.(*providers.ContentBlockText).Value
+		}
+	}
+
+	entries, err := LoadTranscript(transcript)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, streamed, entries[0].Response)
+	assert.Equal(t, "stream", entries[0].Prompt)
+
+	output, err = NewReplayProviderFromEntries(entries).ChatStream(ctx, request("stream"))
+	require.NoError(t, err)
+	var replayed string
+	for chunk := range output.GetStream().Events() {
+		if content, ok := chunk.(*providers.StreamChunkContent); ok {
+			replayed += content.Content.(*providers.ContentBlockText).Value
+		}
+	}
+	assert.Equal(t, streamed, replayed)
+}
+
+func TestRecordStreamCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	transcript := filepath.Join(t.TempDir(), "transcript.jsonl")
+
+	output, err := NewRecorder(&fakeProvider{}, transcript).ChatStream(ctx, request("stream"))
+	require.NoError(t, err)
+	events := output.GetStream().Events()
+	<-events
+	cancel()
+
+	// the recorder stops instead of blocking on a consumer that is gone
+	time.Sleep(50 * time.Millisecond)
+	_, ok := <-events
+	assert.False(t, ok, "stream is closed after cancellation")
+	assert.NoFileExists(t, transcript, "a cancelled stream is not recorded")
+}
+
+func TestMissingTranscript(t *testing.T) {
+	t.Setenv("REPLAY_TRANSCRIPT", "")
+	_, err := NewReplayProvider(providers.ModelProviderConfig{})
+	assert.ErrorIs(t, err, ErrNoTranscript)
+}
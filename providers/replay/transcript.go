@@ -0,0 +1,143 @@
+package replay
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/centralmind/gateway/providers"
+	"golang.org/x/xerrors"
+)
+
+// Entry is a single recorded model call.
+type Entry struct {
+	Key          string `json:"key"`
+	ProviderName string `json:"providerName,omitempty"`
+	ModelId      string `json:"modelId,omitempty"`
+	Prompt       string `json:"prompt,omitempty"`
+	Response     string `json:"response"`
+	// ToolCalls holds the tools the model asked to call, in order.
+	ToolCalls  []providers.ContentBlockToolUse `json:"toolCalls,omitempty"`
+	StopReason providers.StopReason            `json:"stopReason,omitempty"`
+	Usage      *providers.ModelUsage           `json:"usage,omitempty"`
+}
+
+// RequestKey identifies a request by its system prompt and messages.
+// Tool calls and tool results are part of the messages, so every round of a tool loop gets its own key.
+// Model and sampling options are not part of the key, so a transcript can be replayed with any settings.
+func RequestKey(req *providers.ConversationRequest) string {
+	h := sha256.New()
+	h.Write([]byte(req.System))
+	for _, msg := range req.Messages {
+		h.Write([]byte{0})
+		h.Write([]byte(msg.Role))
+		h.Write([]byte{0})
+		h.Write([]byte(messageText(msg)))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func messageText(msg providers.Message) string {
+	var builder strings.Builder
+	for _, block := range msg.Content {
+		switch block := block.(type) {
+		case *providers.ContentBlockText:
+			builder.WriteString(block.Value)
+		case *providers.ContentBlockToolUse:
+			builder.WriteString(block.ID)
+			builder.WriteString(block.Name)
+			builder.Write(block.Input)
+		case *providers.ContentBlockToolResult:
+			builder.WriteString(block.ToolUseID)
+			builder.WriteString(block.Content)
+		}
+	}
+	return builder.String()
+}
+
+func responseText(resp *providers.ConversationResponse) string {
+	var builder strings.Builder
+	for _, block := range resp.Content {
+		if text, ok := block.(*providers.ContentBlockText); ok {
+			builder.WriteString(text.Value)
+		}
+	}
+	return builder.String()
+}
+
+func responseToolCalls(resp *providers.ConversationResponse) []providers.ContentBlockToolUse {
+	var calls []providers.ContentBlockToolUse
+	for _, call := range providers.ToolCalls(resp) {
+		calls = append(calls, *call)
+	}
+	return calls
+}
+
+// content rebuilds the recorded response blocks, text first and then tool calls.
+func (e Entry) content() []providers.ContentBlock {
+	var blocks []providers.ContentBlock
+	if e.Response != "" || len(e.ToolCalls) == 0 {
+		blocks = append(blocks, &providers.ContentBlockText{Value: e.Response})
+	}
+	for i := range e.ToolCalls {
+		call := e.ToolCalls[i]
+		blocks = append(blocks, &call)
+	}
+	return blocks
+}
+
+// LoadTranscript reads a transcript written by the Recorder, one JSON entry per line.
+func LoadTranscript(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to open transcript: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+			return nil, xerrors.Errorf("unable to parse transcript line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, xerrors.Errorf("unable to read transcript: %w", err)
+	}
+	return entries, nil
+}
+
+// transcriptWriter appends entries to a transcript file, it is safe for concurrent use.
+type transcriptWriter struct {
+	mu   sync.Mutex
+	path string
+}
+
+func (w *transcriptWriter) append(entry Entry) error {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return xerrors.Errorf("unable to marshal transcript entry: %w", err)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	f, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return xerrors.Errorf("unable to open transcript: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(raw, '\n')); err != nil {
+		return xerrors.Errorf("unable to write transcript: %w", err)
+	}
+	return nil
+}
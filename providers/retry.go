@@ -0,0 +1,258 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMaxRetries     = 5
+	defaultRetryBaseDelay = time.Second
+	defaultRetryMaxDelay  = time.Minute
+)
+
+var ErrBudgetExceeded = errors.New("AI budget exceeded")
+
+// RetryConfig configures RetryProvider, zero delays fall back to defaults.
+type RetryConfig struct {
+	// MaxRetries is the number of retries after the first attempt, zero disables retries.
+	// Callers that don't expose a setting use DefaultMaxRetries.
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	// Budget limits tokens and requests, it may be shared by several providers of the same run.
+	Budget *Budget
+	// OnRetry is called before waiting for the next attempt.
+	OnRetry func(attempt int, delay time.Duration, err error)
+}
+
+// Budget limits tokens and requests spent in a run and collects usage of every attempt, including retried ones.
+// It is safe for concurrent use.
+type Budget struct {
+	// MaxTokens is the total number of tokens allowed, zero means unlimited.
+	MaxTokens int
+	// MaxRequests is the total number of requests allowed including retries, zero means unlimited.
+	MaxRequests int
+
+	mu    sync.Mutex
+	stats BudgetStats
+}
+
+type BudgetStats struct {
+	Requests     int
+	Retries      int
+	Usage        ModelUsage
+	CostEstimate float64
+}
+
+func NewBudget(maxTokens, maxRequests int) *Budget {
+	return &Budget{MaxTokens: maxTokens, MaxRequests: maxRequests}
+}
+
+// Stats returns requests, retries, usage and cost spent so far.
+func (b *Budget) Stats() BudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+func (b *Budget) begin(retry bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.MaxRequests > 0 && b.stats.Requests >= b.MaxRequests {
+		return fmt.Errorf("%w: %d of %d requests made", ErrBudgetExceeded, b.stats.Requests, b.MaxRequests)
+	}
+	if b.MaxTokens > 0 && b.stats.Usage.TotalTokens >= b.MaxTokens {
+		return fmt.Errorf("%w: %d of %d tokens used", ErrBudgetExceeded, b.stats.Usage.TotalTokens, b.MaxTokens)
+	}
+	b.stats.Requests++
+	if retry {
+		b.stats.Retries++
+	}
+	return nil
+}
+
+func (b *Budget) add(usage *ModelUsage, cost float64) {
+	if usage == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stats.Usage.InputTokens += usage.InputTokens
+	b.stats.Usage.OutputTokens += usage.OutputTokens
+	b.stats.Usage.TotalTokens += usage.TotalTokens
+	b.stats.CostEstimate += cost
+}
+
+// RetryProvider retries transient failures of another provider with exponential backoff and jitter,
+// honours Retry-After hints of the API and enforces the budget.
+type RetryProvider struct {
+	provider ModelProvider
+	config   RetryConfig
+	sleep    func(ctx context.Context, delay time.Duration) error
+}
+
+var _ ModelProvider = (*RetryProvider)(nil)
+
+func WithRetry(provider ModelProvider, config RetryConfig) *RetryProvider {
+	if config.BaseDelay <= 0 {
+		config.BaseDelay = defaultRetryBaseDelay
+	}
+	if config.MaxDelay <= 0 {
+		config.MaxDelay = defaultRetryMaxDelay
+	}
+	if config.Budget == nil {
+		config.Budget = &Budget{}
+	}
+	return &RetryProvider{
+		provider: provider,
+		config:   config,
+		sleep:    sleepContext,
+	}
+}
+
+func (r *RetryProvider) GetName() string {
+	return r.provider.GetName()
+}
+
+func (r *RetryProvider) CostEstimate(modelId string, usage ModelUsage) float64 {
+	return r.provider.CostEstimate(modelId, usage)
+}
+
+func (r *RetryProvider) Chat(ctx context.Context, req *ConversationRequest) (*ConversationResponse, error) {
+	for attempt := 0; ; attempt++ {
+		if err := r.config.Budget.begin(attempt > 0); err != nil {
+			return nil, err
+		}
+		resp, err := r.provider.Chat(ctx, req)
+		if err == nil {
+			r.addUsage(resp.ModelId, resp.Usage)
+			return resp, nil
+		}
+		if waitErr := r.wait(ctx, attempt, err); waitErr != nil {
+			return nil, waitErr
+		}
+	}
+}
+
+type retryStreamOutput struct {
+	stream *retryStream
+}
+
+func (o *retryStreamOutput) GetStream() ChatStream {
+	return o.stream
+}
+
+type retryStream struct {
+	eventCh chan StreamChunk
+}
+
+func (s *retryStream) Events() <-chan StreamChunk {
+	return s.eventCh
+}
+
+// ChatStream retries failures to open the stream and errors received before the first chunk.
+// Once content was delivered to the caller the stream is passed through as is.
+func (r *RetryProvider) ChatStream(ctx context.Context, req *ConversationRequest) (ChatStreamOutput, error) {
+	for attempt := 0; ; attempt++ {
+		if err := r.config.Budget.begin(attempt > 0); err != nil {
+			return nil, err
+		}
+		output, err := r.provider.ChatStream(ctx, req)
+		if err != nil {
+			if waitErr := r.wait(ctx, attempt, err); waitErr != nil {
+				return nil, waitErr
+			}
+			continue
+		}
+
+		events := output.GetStream().Events()
+		first, ok := <-events
+		if chunkErr, isErr := first.(*StreamChunkError); ok && isErr && IsRetryable(chunkErr.Err) {
+			go func() {
+				for range events {
+				}
+			}()
+			if waitErr := r.wait(ctx, attempt, chunkErr.Err); waitErr != nil {
+				return nil, waitErr
+			}
+			continue
+		}
+
+		eventCh := make(chan StreamChunk)
+		go func() {
+			defer close(eventCh)
+			if !ok {
+				return
+			}
+			r.forward(eventCh, first)
+			for chunk := range events {
+				r.forward(eventCh, chunk)
+			}
+		}()
+		return &retryStreamOutput{stream: &retryStream{eventCh: eventCh}}, nil
+	}
+}
+
+func (r *RetryProvider) forward(eventCh chan<- StreamChunk, chunk StreamChunk) {
+	if usage, ok := chunk.(*StreamChunkUsage); ok {
+		r.addUsage(usage.ModelId, usage.Usage)
+	}
+	eventCh <- chunk
+}
+
+func (r *RetryProvider) addUsage(modelId string, usage *ModelUsage) {
+	if usage == nil {
+		return
+	}
+	r.config.Budget.add(usage, r.provider.CostEstimate(modelId, *usage))
+}
+
+// wait sleeps before the next attempt, or returns the error if it must not be retried.
+// A Retry-After longer than MaxDelay is not waited for, e.g. a daily quota would stall the run for hours.
+func (r *RetryProvider) wait(ctx context.Context, attempt int, err error) error {
+	if attempt >= r.config.MaxRetries || !IsRetryable(err) {
+		return err
+	}
+	delay := r.backoff(attempt)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		if apiErr.RetryAfter > r.config.MaxDelay {
+			return err
+		}
+		delay = apiErr.RetryAfter
+	}
+	if r.config.OnRetry != nil {
+		r.config.OnRetry(attempt+1, delay, err)
+	}
+	if sleepErr := r.sleep(ctx, delay); sleepErr != nil {
+		return err
+	}
+	return nil
+}
+
+// backoff doubles the delay with every attempt up to MaxDelay, the second half of the delay is random
+// so concurrent clients don't retry in lockstep.
+func (r *RetryProvider) backoff(attempt int) time.Duration {
+	delay := r.config.MaxDelay
+	if attempt < 30 {
+		delay = min(r.config.BaseDelay<<attempt, r.config.MaxDelay)
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
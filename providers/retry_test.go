@@ -0,0 +1,184 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyProvider fails with the given errors before it starts to answer.
+type flakyProvider struct {
+	failures []error
+	calls    int
+}
+
+func (f *flakyProvider) GetName() string {
+	return "Flaky"
+}
+
+func (f *flakyProvider) CostEstimate(modelId string, usage ModelUsage) float64 {
+	return float64(usage.TotalTokens) / 1000
+}
+
+func (f *flakyProvider) next() error {
+	f.calls++
+	if len(f.failures) == 0 {
+		return nil
+	}
+	err := f.failures[0]
+	f.failures = f.failures[1:]
+	return err
+}
+
+func (f *flakyProvider) Chat(ctx context.Context, req *ConversationRequest) (*ConversationResponse, error) {
+	if err := f.next(); err != nil {
+		return nil, err
+	}
+	return &ConversationResponse{
+		ModelId:    "flaky",
+		Content:    []ContentBlock{&ContentBlockText{Value: "{}"}},
+		StopReason: StopReasonStop,
+		Usage:      &ModelUsage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+	}, nil
+}
+
+func (f *flakyProvider) ChatStream(ctx context.Context, req *ConversationRequest) (ChatStreamOutput, error) {
+	eventCh := make(chan StreamChunk, 3)
+	if err := f.next(); err != nil {
+		eventCh <- &StreamChunkError{Error: err.Error(), Err: err}
+	} else {
+		eventCh <- &StreamChunkContent{Content: &ContentBlockText{Value: "{}"}}
+		eventCh <- &StreamChunkStop{StopReason: StopReasonStop}
+		eventCh <- &StreamChunkUsage{ModelId: "flaky", Usage: &ModelUsage{TotalTokens: 15}}
+	}
+	close(eventCh)
+	return &retryStreamOutput{stream: &retryStream{eventCh: eventCh}}, nil
+}
+
+func newTestRetryProvider(provider ModelProvider, config RetryConfig) (*RetryProvider, *[]time.Duration) {
+	var delays []time.Duration
+	retry := WithRetry(provider, config)
+	retry.sleep = func(ctx context.Context, delay time.Duration) error {
+		delays = append(delays, delay)
+		return nil
+	}
+	return retry, &delays
+}
+
+func overloaded(retryAfter time.Duration) error {
+	return &APIError{StatusCode: http.StatusServiceUnavailable, RetryAfter: retryAfter, Err: errors.New("overloaded")}
+}
+
+func TestRetryTransientErrors(t *testing.T) {
+	flaky := &flakyProvider{failures: []error{overloaded(7 * time.Second), overloaded(0)}}
+	budget := NewBudget(0, 0)
+	provider, delays := newTestRetryProvider(flaky, RetryConfig{MaxRetries: DefaultMaxRetries, Budget: budget})
+
+	resp, err := provider.Chat(context.Background(), &ConversationRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "{}", resp.Content[0].(*ContentBlockText).Value)
+	assert.Equal(t, 3, flaky.calls)
+
+	require.Len(t, *delays, 2)
+	assert.Equal(t, 7*time.Second, (*delays)[0], "Retry-After must be honoured")
+	assert.GreaterOrEqual(t, (*delays)[1], time.Second)
+	assert.LessOrEqual(t, (*delays)[1], 2*time.Second)
+
+	stats := budget.Stats()
+	assert.Equal(t, 3, stats.Requests)
+	assert.Equal(t, 2, stats.Retries)
+	assert.Equal(t, 15, stats.Usage.TotalTokens)
+	assert.InDelta(t, 0.015, stats.CostEstimate, 1e-9)
+}
+
+func TestRetryAfterAboveMaxDelay(t *testing.T) {
+	flaky := &flakyProvider{failures: []error{overloaded(24 * time.Hour)}}
+	provider, delays := newTestRetryProvider(flaky, RetryConfig{MaxRetries: DefaultMaxRetries, MaxDelay: time.Minute})
+	_, err := provider.Chat(context.Background(), &ConversationRequest{})
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, 24*time.Hour, apiErr.RetryAfter)
+	assert.Equal(t, 1, flaky.calls, "a Retry-After above MaxDelay is not waited for")
+	assert.Empty(t, *delays)
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	badRequest := &APIError{StatusCode: http.StatusBadRequest, Err: errors.New("bad request")}
+	flaky := &flakyProvider{failures: []error{badRequest}}
+	provider, delays := newTestRetryProvider(flaky, RetryConfig{MaxRetries: DefaultMaxRetries})
+	_, err := provider.Chat(context.Background(), &ConversationRequest{})
+	assert.ErrorIs(t, err, badRequest)
+	assert.Equal(t, 1, flaky.calls)
+	assert.Empty(t, *delays)
+
+	flaky = &flakyProvider{failures: []error{overloaded(0), overloaded(0), overloaded(0)}}
+	provider, _ = newTestRetryProvider(flaky, RetryConfig{MaxRetries: 2})
+	_, err = provider.Chat(context.Background(), &ConversationRequest{})
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, 3, flaky.calls)
+
+	// zero retries means a single attempt
+	flaky = &flakyProvider{failures: []error{overloaded(0)}}
+	provider, delays = newTestRetryProvider(flaky, RetryConfig{})
+	_, err = provider.Chat(context.Background(), &ConversationRequest{})
+	assert.Error(t, err)
+	assert.Equal(t, 1, flaky.calls)
+	assert.Empty(t, *delays)
+
+	conflict := &APIError{StatusCode: http.StatusConflict, Err: errors.New("conflict")}
+	flaky = &flakyProvider{failures: []error{conflict}}
+	provider, _ = newTestRetryProvider(flaky, RetryConfig{MaxRetries: DefaultMaxRetries})
+	_, err = provider.Chat(context.Background(), &ConversationRequest{})
+	assert.ErrorIs(t, err, conflict)
+	assert.Equal(t, 1, flaky.calls)
+}
+
+func TestRetryBudget(t *testing.T) {
+	flaky := &flakyProvider{failures: []error{overloaded(0), overloaded(0), overloaded(0)}}
+	provider, _ := newTestRetryProvider(flaky, RetryConfig{MaxRetries: DefaultMaxRetries, Budget: NewBudget(0, 2)})
+	_, err := provider.Chat(context.Background(), &ConversationRequest{})
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Equal(t, 2, flaky.calls)
+
+	flaky = &flakyProvider{}
+	provider, _ = newTestRetryProvider(flaky, RetryConfig{Budget: NewBudget(10, 0)})
+	_, err = provider.Chat(context.Background(), &ConversationRequest{})
+	require.NoError(t, err)
+	_, err = provider.Chat(context.Background(), &ConversationRequest{})
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Equal(t, 1, flaky.calls)
+}
+
+func TestRetryStream(t *testing.T) {
+	flaky := &flakyProvider{failures: []error{&APIError{StatusCode: http.StatusTooManyRequests, Err: errors.New("rate limited")}}}
+	budget := NewBudget(0, 0)
+	provider, delays := newTestRetryProvider(flaky, RetryConfig{MaxRetries: DefaultMaxRetries, Budget: budget})
+
+	output, err := provider.ChatStream(context.Background(), &ConversationRequest{})
+	require.NoError(t, err)
+	var chunks []StreamChunk
+	for chunk := range output.GetStream().Events() {
+		chunks = append(chunks, chunk)
+	}
+	require.Len(t, chunks, 3)
+	assert.IsType(t, &StreamChunkContent{}, chunks[0])
+	assert.Len(t, *delays, 1)
+	assert.Equal(t, 15, budget.Stats().Usage.TotalTokens)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Zero(t, ParseRetryAfter(nil))
+	assert.Equal(t, 3*time.Second, ParseRetryAfter(http.Header{"Retry-After": []string{"3"}}))
+	assert.Equal(t, 1500*time.Millisecond, ParseRetryAfter(http.Header{"Retry-After": []string{"3"}, "Retry-After-Ms": []string{"1500"}}))
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	delay := ParseRetryAfter(http.Header{"Retry-After": []string{date}})
+	assert.Greater(t, delay, 50*time.Second)
+	assert.LessOrEqual(t, delay, time.Minute)
+}
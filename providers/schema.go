@@ -0,0 +1,115 @@
+package providers
+
+import (
+	"encoding/json"
+	"sort"
+
+	"golang.org/x/xerrors"
+)
+
+// StrictSchema converts a JSON schema into the subset accepted by strict structured output of OpenAI compatible APIs:
+// every object lists all its properties as required and forbids additional ones, optional properties become nullable.
+// Schemas that can't be expressed strictly, like free-form objects or untyped values, return an error.
+func StrictSchema(schema json.RawMessage) (json.RawMessage, error) {
+	var node map[string]any
+	if err := json.Unmarshal(schema, &node); err != nil {
+		return nil, xerrors.Errorf("unable to parse schema: %w", err)
+	}
+	delete(node, "$schema")
+	if err := strictNode(node, "#"); err != nil {
+		return nil, err
+	}
+	return json.Marshal(node)
+}
+
+func strictNode(node map[string]any, path string) error {
+	// annotations that strict mode rejects
+	delete(node, "default")
+
+	if anyOf, ok := node["anyOf"].([]any); ok {
+		for _, option := range anyOf {
+			child, ok := option.(map[string]any)
+			if !ok {
+				return xerrors.Errorf("%s: anyOf must contain schemas", path)
+			}
+			if err := strictNode(child, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if _, ok := node["type"]; !ok {
+		return xerrors.Errorf("%s: type is required in strict mode", path)
+	}
+
+	if items, ok := node["items"].(map[string]any); ok {
+		if err := strictNode(items, path+"/items"); err != nil {
+			return err
+		}
+	}
+
+	if !hasType(node, "object") {
+		return nil
+	}
+	properties, ok := node["properties"].(map[string]any)
+	if !ok || len(properties) == 0 {
+		return xerrors.Errorf("%s: free-form objects are not supported in strict mode", path)
+	}
+	required := map[string]bool{}
+	if list, ok := node["required"].([]any); ok {
+		for _, name := range list {
+			if name, ok := name.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(properties))
+	for name, property := range properties {
+		child, ok := property.(map[string]any)
+		if !ok {
+			return xerrors.Errorf("%s/%s: property must be a schema", path, name)
+		}
+		if err := strictNode(child, path+"/"+name); err != nil {
+			return err
+		}
+		if !required[name] {
+			nullable(child)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	node["required"] = names
+	node["additionalProperties"] = false
+	return nil
+}
+
+// nullable lets an optional property be null, strict mode requires it to be present anyway.
+func nullable(node map[string]any) {
+	if anyOf, ok := node["anyOf"].([]any); ok {
+		node["anyOf"] = append(anyOf, map[string]any{"type": "null"})
+		return
+	}
+	if hasType(node, "null") {
+		return
+	}
+	switch typ := node["type"].(type) {
+	case string:
+		node["type"] = []any{typ, "null"}
+	case []any:
+		node["type"] = append(typ, "null")
+	}
+}
+
+func hasType(node map[string]any, name string) bool {
+	switch typ := node["type"].(type) {
+	case string:
+		return typ == name
+	case []any:
+		for _, t := range typ {
+			if t == name {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package providers
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrictSchema(t *testing.T) {
+	strict, err := StrictSchema(json.RawMessage(`{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"properties": {
+			"items": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {
+						"name": {"type": "string"},
+						"enabled": {"type": "boolean", "default": true},
+						"value": {"anyOf": [{"type": "string"}, {"type": "number"}]}
+					},
+					"required": ["name"]
+				}
+			}
+		},
+		"required": ["items"]
+	}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"items": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {
+						"name": {"type": "string"},
+						"enabled": {"type": ["boolean", "null"]},
+						"value": {"anyOf": [{"type": "string"}, {"type": "number"}, {"type": "null"}]}
+					},
+					"required": ["enabled", "name", "value"],
+					"additionalProperties": false
+				}
+			}
+		},
+		"required": ["items"],
+		"additionalProperties": false
+	}`, string(strict))
+
+	_, err = StrictSchema(json.RawMessage(`{"type": "object", "properties": {"data": {"type": "object"}}}`))
+	assert.ErrorContains(t, err, "#/data: free-form objects")
+	_, err = StrictSchema(json.RawMessage(`{"type": "object", "properties": {"value": {"description": "anything"}}}`))
+	assert.ErrorContains(t, err, "#/value: type is required")
+}
+
+func TestStrictSchemaOfPrompts(t *testing.T) {
+	for _, name := range []string{"endpoints_schema.json", "pii_report_schema.json", "ask_schema.json"} {
+		raw, err := os.ReadFile(filepath.Join("..", "prompter", name))
+		require.NoError(t, err)
+		_, err = StrictSchema(raw)
+		assert.NoError(t, err, name)
+	}
+}
@@ -0,0 +1,56 @@
+package providers
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrToolRoundsExceeded = errors.New("model did not finish tool calling within the allowed number of rounds")
+
+// ToolHandler executes a single tool call and returns its result as text.
+// Returned errors are reported back to the model as failed tool results, so it can recover.
+type ToolHandler func(ctx context.Context, call *ContentBlockToolUse) (string, error)
+
+// RunTools drives a tool calling round trip: it calls the model, executes requested tools with the handler
+// and sends results back until the model answers without tool calls or maxRounds is reached.
+// The conversation is accumulated in req.Messages, usage of all rounds is summed up in the returned response.
+func RunTools(ctx context.Context, provider ModelProvider, req *ConversationRequest, handler ToolHandler, maxRounds int) (*ConversationResponse, error) {
+	usage := &ModelUsage{}
+	for round := 0; round < maxRounds; round++ {
+		resp, err := provider.Chat(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Usage != nil {
+			usage.InputTokens += resp.Usage.InputTokens
+			usage.OutputTokens += resp.Usage.OutputTokens
+			usage.TotalTokens += resp.Usage.TotalTokens
+		}
+		resp.Usage = usage
+
+		calls := ToolCalls(resp)
+		if len(calls) == 0 {
+			return resp, nil
+		}
+
+		req.Messages = append(req.Messages,
+			Message{Role: AssistantRole, Content: resp.Content},
+			Message{Role: UserRole, Content: ExecuteTools(ctx, calls, handler)},
+		)
+	}
+	return nil, ErrToolRoundsExceeded
+}
+
+// ExecuteTools runs tool calls one by one and returns their results, failed calls are reported as error results.
+func ExecuteTools(ctx context.Context, calls []*ContentBlockToolUse, handler ToolHandler) []ContentBlock {
+	results := make([]ContentBlock, 0, len(calls))
+	for _, call := range calls {
+		result, err := handler(ctx, call)
+		if err != nil {
+			results = append(results, &ContentBlockToolResult{ToolUseID: call.ID, Content: err.Error(), IsError: true})
+			continue
+		}
+		results = append(results, &ContentBlockToolResult{ToolUseID: call.ID, Content: result})
+	}
+	return results
+}
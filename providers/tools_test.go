@@ -0,0 +1,102 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedProvider answers with the given responses in order and keeps the requests it received.
+type scriptedProvider struct {
+	responses []*ConversationResponse
+	requests  []ConversationRequest
+}
+
+func (s *scriptedProvider) GetName() string {
+	return "Scripted"
+}
+
+func (s *scriptedProvider) CostEstimate(modelId string, usage ModelUsage) float64 {
+	return 0
+}
+
+func (s *scriptedProvider) Chat(ctx context.Context, req *ConversationRequest) (*ConversationResponse, error) {
+	s.requests = append(s.requests, *req)
+	resp := s.responses[0]
+	if len(s.responses) > 1 {
+		s.responses = s.responses[1:]
+	}
+	return resp, nil
+}
+
+func (s *scriptedProvider) ChatStream(ctx context.Context, req *ConversationRequest) (ChatStreamOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func toolCallResponse(calls ...*ContentBlockToolUse) *ConversationResponse {
+	var content []ContentBlock
+	for _, call := range calls {
+		content = append(content, call)
+	}
+	return &ConversationResponse{
+		Content:    content,
+		StopReason: StopReasonToolCalls,
+		Usage:      &ModelUsage{InputTokens: 10, OutputTokens: 2, TotalTokens: 12},
+	}
+}
+
+func TestRunTools(t *testing.T) {
+	provider := &scriptedProvider{responses: []*ConversationResponse{
+		toolCallResponse(
+			&ContentBlockToolUse{ID: "call_1", Name: "count", Input: json.RawMessage(`{"table":"users"}`)},
+			&ContentBlockToolUse{ID: "call_2", Name: "count", Input: json.RawMessage(`{"table":"missing"}`)},
+		),
+		{
+			Content:    []ContentBlock{&ContentBlockText{Value: "users has 42 rows"}},
+			StopReason: StopReasonStop,
+			Usage:      &ModelUsage{InputTokens: 20, OutputTokens: 5, TotalTokens: 25},
+		},
+	}}
+
+	req := &ConversationRequest{
+		Messages: []Message{{Role: UserRole, Content: []ContentBlock{&ContentBlockText{Value: "how many users?"}}}},
+		Tools:    []Tool{{Name: "count", InputSchema: json.RawMessage(`{"type":"object"}`)}},
+	}
+	resp, err := RunTools(context.Background(), provider, req, func(ctx context.Context, call *ContentBlockToolUse) (string, error) {
+		var args struct{ Table string }
+		require.NoError(t, json.Unmarshal(call.Input, &args))
+		if args.Table != "users" {
+			return "", errors.New("unknown table")
+		}
+		return "42", nil
+	}, 5)
+	require.NoError(t, err)
+
+	assert.Equal(t, "users has 42 rows", resp.Content[0].(*ContentBlockText).Value)
+	assert.Equal(t, &ModelUsage{InputTokens: 30, OutputTokens: 7, TotalTokens: 37}, resp.Usage)
+
+	require.Len(t, provider.requests, 2)
+	require.Len(t, req.Messages, 3)
+	assert.Equal(t, AssistantRole, req.Messages[1].Role)
+	assert.Equal(t, []ContentBlock{
+		&ContentBlockToolResult{ToolUseID: "call_1", Content: "42"},
+		&ContentBlockToolResult{ToolUseID: "call_2", Content: "unknown table", IsError: true},
+	}, req.Messages[2].Content)
+}
+
+func TestRunToolsRoundsExceeded(t *testing.T) {
+	provider := &scriptedProvider{responses: []*ConversationResponse{
+		toolCallResponse(&ContentBlockToolUse{ID: "call", Name: "loop", Input: json.RawMessage(`{}`)}),
+	}}
+
+	req := &ConversationRequest{Messages: []Message{{Role: UserRole, Content: []ContentBlock{&ContentBlockText{Value: "go"}}}}}
+	_, err := RunTools(context.Background(), provider, req, func(ctx context.Context, call *ContentBlockToolUse) (string, error) {
+		return "again", nil
+	}, 3)
+	assert.ErrorIs(t, err, ErrToolRoundsExceeded)
+	assert.Len(t, provider.requests, 3)
+}
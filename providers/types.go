@@ -1,6 +1,9 @@
 package providers
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+)
 
 type ConversationRole string
 
@@ -19,19 +22,59 @@ type ContentBlockText struct {
 	Value string `json:"value"`
 }
 
+func (*ContentBlockToolUse) isContentBlock() {}
+
+// ContentBlockToolUse is a request of the model to call a tool, returned with StopReasonToolCalls.
+type ContentBlockToolUse struct {
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
+
+func (*ContentBlockToolResult) isContentBlock() {}
+
+// ContentBlockToolResult is an answer to ContentBlockToolUse, sent back in a user message.
+type ContentBlockToolResult struct {
+	ToolUseID string `json:"toolUseId"`
+	Content   string `json:"content"`
+	IsError   bool   `json:"isError,omitempty"`
+}
+
+// Tool describes a function the model may call.
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+}
+
+// ResponseSchema constrains the model answer to a JSON schema.
+// Providers without native structured output emulate it with a forced tool call,
+// the answer is always returned as a single ContentBlockText with JSON.
+type ResponseSchema struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema"`
+}
+
 type Message struct {
 	Role    ConversationRole `json:"role"`
 	Content []ContentBlock   `json:"content"`
 }
 
 type ConversationRequest struct {
-	ModelId      string    `json:"modelId"`
-	System       string    `json:"system,omitempty"`
-	Messages     []Message `json:"messages"`
-	MaxTokens    int       `json:"maxTokens,omitempty"`
-	Temperature  float32   `json:"temperature,omitempty"`
-	Reasoning    bool      `json:"reasoning,omitempty"`
-	JsonResponse bool      `json:"requireJson,omitempty"`
+	ModelId     string    `json:"modelId"`
+	System      string    `json:"system,omitempty"`
+	Messages    []Message `json:"messages"`
+	MaxTokens   int       `json:"maxTokens,omitempty"`
+	Temperature float32   `json:"temperature,omitempty"`
+	// DefaultTemperature leaves the temperature to the model, Temperature is ignored.
+	DefaultTemperature bool `json:"defaultTemperature,omitempty"`
+	Reasoning          bool `json:"reasoning,omitempty"`
+	JsonResponse       bool `json:"requireJson,omitempty"`
+	// ResponseSchema, if set, makes the model answer with JSON that matches the schema.
+	ResponseSchema *ResponseSchema `json:"responseSchema,omitempty"`
+	// Tools the model may call, calls are returned as ContentBlockToolUse.
+	Tools []Tool `json:"tools,omitempty"`
 }
 
 type StopReason string
@@ -58,6 +101,8 @@ func (*StreamChunkError) isStreamChunk() {}
 
 type StreamChunkError struct {
 	Error string `json:"error,omitempty"`
+	// Err is the underlying error if known, it allows to retry transient failures.
+	Err error `json:"-"`
 }
 
 func (*StreamChunkContent) isStreamChunk() {}
@@ -107,4 +152,6 @@ type ModelProviderConfig struct {
 	BedrockRegion   string
 	VertexAIRegion  string
 	VertexAIProject string
+	// Transcript is a path to a recorded conversation transcript, used by the replay provider.
+	Transcript string
 }
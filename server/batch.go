@@ -0,0 +1,74 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/centralmind/gateway/mcp"
+)
+
+// WithBatchConcurrency runs up to limit tool calls of a JSON-RPC batch at the same time.
+// Other requests of a batch are always handled one by one in their order, the default limit of 1
+// runs tool calls in order as well.
+func WithBatchConcurrency(limit int) ServerOption {
+	return func(s *MCPServer) {
+		s.batchConcurrency = limit
+	}
+}
+
+// isBatch reports whether a message is a JSON-RPC batch, i.e. an array of messages
+func isBatch(message json.RawMessage) bool {
+	trimmed := bytes.TrimLeft(message, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// handleBatch handles every message of a batch and returns their responses in the same order.
+// Notifications have no entry in the response, a batch of notifications only has no response at all.
+func (s *MCPServer) handleBatch(ctx context.Context, message json.RawMessage) mcp.JSONRPCMessage {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(message, &batch); err != nil {
+		return CreateErrorResponse(nil, mcp.PARSE_ERROR, "Failed to parse batch")
+	}
+	if len(batch) == 0 {
+		return CreateErrorResponse(nil, mcp.INVALID_REQUEST, "Empty batch")
+	}
+
+	limit := max(s.batchConcurrency, 1)
+	slots := make(chan struct{}, limit)
+	responses := make([]mcp.JSONRPCMessage, len(batch))
+	var wg sync.WaitGroup
+	for i, entry := range batch {
+		if isBatch(entry) {
+			responses[i] = CreateErrorResponse(nil, mcp.INVALID_REQUEST, "Nested batches are not allowed")
+			continue
+		}
+		var baseMessage struct {
+			Method string `json:"method"`
+		}
+		if limit > 1 && json.Unmarshal(entry, &baseMessage) == nil && baseMessage.Method == "tools/call" {
+			slots <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots }()
+				responses[i] = s.HandleMessage(ctx, entry)
+			}()
+			continue
+		}
+		responses[i] = s.HandleMessage(ctx, entry)
+	}
+	wg.Wait()
+
+	var result []mcp.JSONRPCMessage
+	for _, response := range responses {
+		if response != nil {
+			result = append(result, response)
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/centralmind/gateway/mcp"
+)
+
+// ErrElicitationNotSupported is returned by Elicit when the client of the request can't be asked for input
+var ErrElicitationNotSupported = errors.New("client does not support elicitation")
+
+// clientResponse is the result or the error the client sent for a request of the server
+type clientResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Elicit asks the user of the request's client for input described by a restricted JSON schema,
+// and waits until the user answers or the context is done.
+func (s *MCPServer) Elicit(
+	ctx context.Context,
+	message string,
+	schema map[string]interface{},
+) (*mcp.ElicitResult, error) {
+	if !s.Supports(ctx, mcp.FeatureElicitation) {
+		return nil, ErrElicitationNotSupported
+	}
+	raw, err := s.requestClient(ctx, "elicitation/create", mcp.ElicitParams{
+		Message:         message,
+		RequestedSchema: schema,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result mcp.ElicitResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("invalid elicitation result: %w", err)
+	}
+	return &result, nil
+}
+
+// requestClient sends a request to the client of the request through its session queue and waits for the response
+func (s *MCPServer) requestClient(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	clientContext, ok := ClientFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("no client in context")
+	}
+	queue, ok := s.sessions.Load(clientContext.SessionID)
+	if !ok {
+		return nil, fmt.Errorf("session not found: %s", clientContext.SessionID)
+	}
+
+	id := s.requestID.Add(1)
+	key := inflightKey{session: clientContext.SessionID, id: formatID(id)}
+	waiter := make(chan clientResponse, 1)
+	s.pending.Store(key, waiter)
+	defer s.pending.Delete(key)
+
+	request := &mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      id,
+		Params:  params,
+		Request: mcp.Request{Method: method},
+	}
+	select {
+	case queue.(chan ServerNotification) <- ServerNotification{Context: clientContext, Request: request}:
+	default:
+		return nil, fmt.Errorf("notification queue of session %s is full", clientContext.SessionID)
+	}
+
+	select {
+	case response := <-waiter:
+		if response.Error != nil {
+			return nil, fmt.Errorf("client error %d: %s", response.Error.Code, response.Error.Message)
+		}
+		return response.Result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// handleClientResponse hands a response of the client to the request of the server waiting for it,
+// responses to unknown or abandoned requests are dropped
+func (s *MCPServer) handleClientResponse(ctx context.Context, id interface{}, message json.RawMessage) {
+	clientContext, ok := ClientFromContext(ctx)
+	if !ok {
+		return
+	}
+	var response clientResponse
+	if err := json.Unmarshal(message, &response); err != nil {
+		return
+	}
+	if waiter, ok := s.pending.LoadAndDelete(inflightKey{session: clientContext.SessionID, id: formatID(id)}); ok {
+		waiter.(chan clientResponse) <- response
+	}
+}
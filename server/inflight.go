@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/xcontext"
+)
+
+// progressInterval limits how often progress of a single request is sent to the client
+const progressInterval = time.Second
+
+var errRequestCancelled = errors.New("request cancelled by the client")
+
+// inflightKey identifies a running request, request IDs are only unique within a session
+type inflightKey struct {
+	session string
+	id      string
+}
+
+func requestKey(ctx context.Context, id interface{}) inflightKey {
+	return inflightKey{session: xcontext.Session(ctx), id: formatID(id)}
+}
+
+// formatID prints a request ID the same way whether it's an integer or a JSON number decoded as float64,
+// fmt.Sprint prints a float64 from 1e6 on in exponent notation, e.g. 1e+06.
+func formatID(id interface{}) string {
+	if f, ok := id.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(id)
+}
+
+// trackRequest makes the request cancellable by a notifications/cancelled from the same session.
+// The returned function must be called once the request is finished.
+func (s *MCPServer) trackRequest(ctx context.Context, id interface{}) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	key := requestKey(ctx, id)
+	s.inflight.Store(key, cancel)
+	return ctx, func() {
+		s.inflight.Delete(key)
+		cancel(nil)
+	}
+}
+
+// cancelRequest cancels the context of a running request, requests that already finished are ignored
+func (s *MCPServer) cancelRequest(ctx context.Context, id interface{}) {
+	if id == nil {
+		return
+	}
+	if cancel, ok := s.inflight.LoadAndDelete(requestKey(ctx, id)); ok {
+		cancel.(context.CancelCauseFunc)(errRequestCancelled)
+	}
+}
+
+// progressReporter sends notifications/progress with the token of the request to its client.
+// Progress that doesn't increase is dropped, and updates are sent at most once per progressInterval.
+func (s *MCPServer) progressReporter(ctx context.Context, token mcp.ProgressToken) xcontext.ProgressFunc {
+	var mu sync.Mutex
+	var sentAt time.Time
+	var sent float64
+	return func(progress, total float64) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !sentAt.IsZero() && (progress <= sent || time.Since(sentAt) < progressInterval) {
+			return
+		}
+		var totalPtr *float64
+		if total > 0 {
+			totalPtr = &total
+		}
+		notification := mcp.NewProgressNotification(token, progress, totalPtr)
+		params := map[string]interface{}{
+			"progressToken": notification.Params.ProgressToken,
+			"progress":      notification.Params.Progress,
+		}
+		if notification.Params.Total > 0 {
+			params["total"] = notification.Params.Total
+		}
+		if err := s.SendNotificationToClient(ctx, notification.Method, params); err != nil {
+			return
+		}
+		sentAt = time.Now()
+		sent = progress
+	}
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"context"
+
+	"github.com/centralmind/gateway/mcp"
+)
+
+// SendLogMessage sends notifications/message to the client of the request if it asked
+// for messages of this level with logging/setLevel. Clients that never set a level get no messages.
+func (s *MCPServer) SendLogMessage(
+	ctx context.Context,
+	level mcp.LoggingLevel,
+	logger string,
+	data interface{},
+) error {
+	client, ok := ClientFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	minimum, ok := s.logLevels.Load(client.SessionID)
+	if !ok || !level.Enabled(minimum.(mcp.LoggingLevel)) {
+		return nil
+	}
+	notification := mcp.NewLoggingMessageNotification(level, logger, data)
+	params := map[string]interface{}{
+		"level": notification.Params.Level,
+		"data":  notification.Params.Data,
+	}
+	if notification.Params.Logger != "" {
+		params["logger"] = notification.Params.Logger
+	}
+	return s.sendNotification(client, notification.Method, params)
+}
+
+// Log sends a log message to the client of the request handled by the server from the context.
+// It is a no-op outside of MCP requests, so it is safe to call from connectors and plugins.
+func Log(ctx context.Context, level mcp.LoggingLevel, logger string, data interface{}) {
+	srv := ServerFromContext(ctx)
+	if srv == nil {
+		return
+	}
+	// Logs are best effort, a full queue of a slow client must not fail the request
+	_ = srv.SendLogMessage(ctx, level, logger, data)
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+
+	"github.com/centralmind/gateway/mcp"
+)
+
+// clientSession is what the client of a session told about itself in initialize
+type clientSession struct {
+	protocolVersion string
+	capabilities    mcp.ClientCapabilities
+}
+
+func (s *MCPServer) clientSession(ctx context.Context) (clientSession, bool) {
+	client, ok := ClientFromContext(ctx)
+	if !ok {
+		return clientSession{}, false
+	}
+	session, ok := s.clients.Load(client.SessionID)
+	if !ok {
+		return clientSession{}, false
+	}
+	return session.(clientSession), true
+}
+
+// ProtocolVersion returns the protocol revision negotiated with the client of the request.
+// Requests outside of an initialized session are served with the latest revision.
+func (s *MCPServer) ProtocolVersion(ctx context.Context) string {
+	if session, ok := s.clientSession(ctx); ok {
+		return session.protocolVersion
+	}
+	return mcp.LATEST_PROTOCOL_VERSION
+}
+
+// ClientCapabilities returns capabilities the client of the request declared in initialize
+func (s *MCPServer) ClientCapabilities(ctx context.Context) (mcp.ClientCapabilities, bool) {
+	session, ok := s.clientSession(ctx)
+	return session.capabilities, ok
+}
+
+// Supports reports whether the feature can be used with the client of the request.
+// Features the server asks the client for, such as elicitation, also need the client capability.
+func (s *MCPServer) Supports(ctx context.Context, feature mcp.ProtocolFeature) bool {
+	if !mcp.ProtocolSupports(s.ProtocolVersion(ctx), feature) {
+		return false
+	}
+	if feature == mcp.FeatureElicitation {
+		capabilities, ok := s.ClientCapabilities(ctx)
+		return ok && capabilities.Elicitation != nil
+	}
+	return true
+}
+
+// toolsForClient hides fields of tools that the protocol revision of the client doesn't have
+func (s *MCPServer) toolsForClient(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	annotations := s.Supports(ctx, mcp.FeatureToolAnnotations)
+	structured := s.Supports(ctx, mcp.FeatureStructuredOutput)
+	if annotations && structured {
+		return tools
+	}
+	for i := range tools {
+		if !annotations {
+			tools[i].Annotations = nil
+		}
+		if !structured {
+			tools[i].OutputSchema = nil
+		}
+	}
+	return tools
+}
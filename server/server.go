@@ -4,14 +4,24 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"regexp"
 	"sort"
 	"sync"
 	"sync/atomic"
 
 	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/xcontext"
+)
+
+var (
+	// ErrToolNotFound is returned by CallTool for unknown tools
+	ErrToolNotFound = errors.New("tool not found")
+	// ErrResourceNotFound is returned by ReadResource for URIs without a resource or a matching template
+	ErrResourceNotFound = errors.New("resource not found")
 )
 
 // resourceEntry holds both a resource and its handler
@@ -38,14 +48,21 @@ type ResourceTemplateHandlerFunc func(ctx context.Context, request mcp.ReadResou
 // PromptHandlerFunc handles prompt requests with given arguments.
 type PromptHandlerFunc func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error)
 
+// CompletionHandlerFunc suggests values of an argument of a prompt, resource template or tool.
+type CompletionHandlerFunc func(ctx context.Context, request mcp.CompleteRequest) (*mcp.CompleteResult, error)
+
 // ToolHandlerFunc handles tool calls with given arguments.
 type ToolHandlerFunc func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error)
 
 // ToolMiddlewareFunc add a middleware interceptor for tool
 type ToolMiddlewareFunc func(ctx context.Context, tool ServerTool, request mcp.CallToolRequest) (*mcp.CallToolResult, error)
 
-// AuthChecker verify sse request
-type AuthChecker func(r *http.Request) bool
+// AuthChecker verify sse request, an accepted request may get a context with what the checker validated,
+// e.g. claims of its token, so handlers of the request don't validate it again
+type AuthChecker func(r *http.Request) (context.Context, bool)
+
+// ToolFilterFunc returns tools of tools/list that the client of the request is offered
+type ToolFilterFunc func(ctx context.Context, tools []mcp.Tool) []mcp.Tool
 
 // ServerTool combines a Tool with its ToolHandlerFunc.
 type ServerTool struct {
@@ -59,10 +76,20 @@ type NotificationContext struct {
 	SessionID string
 }
 
-// ServerNotification combines the notification with client context
+// ServerNotification combines the notification with client context.
+// Requests the server sends to the client, such as elicitation, are queued with Request set.
 type ServerNotification struct {
 	Context      NotificationContext
 	Notification mcp.JSONRPCNotification
+	Request      *mcp.JSONRPCRequest
+}
+
+// Message returns what transports write to the client, the request if there is one
+func (n ServerNotification) Message() mcp.JSONRPCMessage {
+	if n.Request != nil {
+		return n.Request
+	}
+	return n.Notification
 }
 
 // NotificationHandlerFunc handles incoming notifications.
@@ -81,13 +108,20 @@ type MCPServer struct {
 	tools                map[string]ServerTool
 	toolMiddlewares      []ToolMiddlewareFunc
 	authCheckers         []AuthChecker
+	toolFilters          []ToolFilterFunc
 	notificationHandlers map[string]NotificationHandlerFunc
+	completionHandler    CompletionHandlerFunc
 	instructions         string
 	capabilities         serverCapabilities
-	notifications        chan ServerNotification
-	clientMu             sync.Mutex // Separate mutex for client context
-	currentClient        NotificationContext
-	initialized          atomic.Bool // Use atomic for the initialized flag
+	sessions             sync.Map // Notification queues by session ID
+	logLevels            sync.Map // Minimum logging levels by session ID
+	clients              sync.Map // clientSession by session ID
+	subscriptions        subscriptions
+	inflight             sync.Map     // Cancel functions of running tool calls by inflightKey
+	pending              sync.Map     // Waiters for responses of the client by inflightKey
+	requestID            atomic.Int64 // IDs of requests sent to clients
+	batchConcurrency     int          // Tool calls of a batch run at the same time
+	initialized          atomic.Bool  // Use atomic for the initialized flag
 }
 
 // serverKey is the context key for storing the server instance
@@ -101,33 +135,88 @@ func ServerFromContext(ctx context.Context) *MCPServer {
 	return nil
 }
 
-// WithContext sets the current client context and returns the provided context
+// clientKey is the context key for storing the client of a request
+type clientKey struct{}
+
+// sessionQueueSize is the number of notifications buffered for a session
+const sessionQueueSize = 100
+
+// WithContext attaches the client context of a request, notifications sent
+// with the returned context are delivered to this client only
 func (s *MCPServer) WithContext(
 	ctx context.Context,
 	notifCtx NotificationContext,
 ) context.Context {
-	s.clientMu.Lock()
-	s.currentClient = notifCtx
-	s.clientMu.Unlock()
-	return ctx
+	return context.WithValue(ctx, clientKey{}, notifCtx)
+}
+
+// ClientFromContext returns the client context set by WithContext
+func ClientFromContext(ctx context.Context) (NotificationContext, bool) {
+	notifCtx, ok := ctx.Value(clientKey{}).(NotificationContext)
+	return notifCtx, ok
+}
+
+// RegisterSession creates a notification queue for the session, transports
+// read it to deliver notifications to their client
+func (s *MCPServer) RegisterSession(sessionID string) <-chan ServerNotification {
+	queue := make(chan ServerNotification, sessionQueueSize)
+	s.sessions.Store(sessionID, queue)
+	return queue
 }
 
-func (s *MCPServer) Notifications() <-chan ServerNotification {
-	return s.notifications
+// UnregisterSession drops the notification queue of a closed session
+func (s *MCPServer) UnregisterSession(sessionID string) {
+	s.sessions.Delete(sessionID)
+	s.logLevels.Delete(sessionID)
+	s.clients.Delete(sessionID)
+	s.unsubscribeSession(sessionID)
 }
 
-// SendNotificationToClient sends a notification to the current client
+// SendNotificationToClient sends a notification to the client of the request
 func (s *MCPServer) SendNotificationToClient(
+	ctx context.Context,
 	method string,
 	params map[string]interface{},
 ) error {
-	if s.notifications == nil {
-		return fmt.Errorf("notification channel not initialized")
+	clientContext, ok := ClientFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("no client in context")
 	}
+	return s.sendNotification(clientContext, method, params)
+}
+
+// SendNotificationToSession sends a notification to the client of the session
+func (s *MCPServer) SendNotificationToSession(
+	sessionID string,
+	method string,
+	params map[string]interface{},
+) error {
+	return s.sendNotification(NotificationContext{ClientID: sessionID, SessionID: sessionID}, method, params)
+}
+
+// BroadcastNotification sends a notification to all connected clients, e.g.
+// list changed notifications
+func (s *MCPServer) BroadcastNotification(
+	method string,
+	params map[string]interface{},
+) {
+	s.sessions.Range(func(key, _ interface{}) bool {
+		// A slow client must not block others, its notification is dropped
+		_ = s.SendNotificationToSession(key.(string), method, params)
+		return true
+	})
+}
 
-	s.clientMu.Lock()
-	clientContext := s.currentClient
-	s.clientMu.Unlock()
+// sendNotification queues a notification for the session of the client
+func (s *MCPServer) sendNotification(
+	clientContext NotificationContext,
+	method string,
+	params map[string]interface{},
+) error {
+	queue, ok := s.sessions.Load(clientContext.SessionID)
+	if !ok {
+		return fmt.Errorf("session not found: %s", clientContext.SessionID)
+	}
 
 	notification := mcp.JSONRPCNotification{
 		JSONRPC: mcp.JSONRPC_VERSION,
@@ -140,13 +229,13 @@ func (s *MCPServer) SendNotificationToClient(
 	}
 
 	select {
-	case s.notifications <- ServerNotification{
+	case queue.(chan ServerNotification) <- ServerNotification{
 		Context:      clientContext,
 		Notification: notification,
 	}:
 		return nil
 	default:
-		return fmt.Errorf("notification channel full or blocked")
+		return fmt.Errorf("notification queue of session %s is full", clientContext.SessionID)
 	}
 }
 
@@ -214,7 +303,6 @@ func NewMCPServer(
 		name:                 name,
 		version:              version,
 		notificationHandlers: make(map[string]NotificationHandlerFunc),
-		notifications:        make(chan ServerNotification, 100),
 	}
 
 	for _, opt := range opts {
@@ -224,7 +312,8 @@ func NewMCPServer(
 	return s
 }
 
-// HandleMessage processes an incoming JSON-RPC message and returns an appropriate response
+// HandleMessage processes an incoming JSON-RPC message and returns an appropriate response.
+// A batch, i.e. an array of messages, gets an array of responses in the order of its requests.
 func (s *MCPServer) HandleMessage(
 	ctx context.Context,
 	message json.RawMessage,
@@ -232,6 +321,10 @@ func (s *MCPServer) HandleMessage(
 	// Add server to context
 	ctx = context.WithValue(ctx, serverKey{}, s)
 
+	if isBatch(message) {
+		return s.handleBatch(ctx, message)
+	}
+
 	var baseMessage struct {
 		JSONRPC string      `json:"jsonrpc"`
 		Method  string      `json:"method"`
@@ -246,6 +339,12 @@ func (s *MCPServer) HandleMessage(
 		)
 	}
 
+	// A message with an ID but no method is the response of the client to a request of the server
+	if baseMessage.Method == "" && baseMessage.ID != nil {
+		s.handleClientResponse(ctx, baseMessage.ID, message)
+		return nil
+	}
+
 	// Check for valid JSONRPC version
 	if baseMessage.JSONRPC != mcp.JSONRPC_VERSION {
 		return CreateErrorResponse(
@@ -340,6 +439,23 @@ func (s *MCPServer) HandleMessage(
 			)
 		}
 		return s.handleReadResource(ctx, baseMessage.ID, request)
+	case "resources/subscribe", "resources/unsubscribe":
+		if s.capabilities.resources == nil || !s.capabilities.resources.subscribe {
+			return CreateErrorResponse(
+				baseMessage.ID,
+				mcp.METHOD_NOT_FOUND,
+				"Resource subscriptions not supported",
+			)
+		}
+		var request mcp.SubscribeRequest
+		if err := json.Unmarshal(message, &request); err != nil {
+			return CreateErrorResponse(
+				baseMessage.ID,
+				mcp.INVALID_REQUEST,
+				"Invalid subscribe request",
+			)
+		}
+		return s.handleSubscribe(ctx, baseMessage.ID, request.Params.URI, baseMessage.Method == "resources/subscribe")
 	case "prompts/list":
 		if s.capabilities.prompts == nil {
 			return CreateErrorResponse(
@@ -374,6 +490,47 @@ func (s *MCPServer) HandleMessage(
 			)
 		}
 		return s.handleGetPrompt(ctx, baseMessage.ID, request)
+	case "completion/complete":
+		s.mu.RLock()
+		handler := s.completionHandler
+		s.mu.RUnlock()
+		if handler == nil {
+			return CreateErrorResponse(
+				baseMessage.ID,
+				mcp.METHOD_NOT_FOUND,
+				"Completions not supported",
+			)
+		}
+		var request mcp.CompleteRequest
+		if err := json.Unmarshal(message, &request); err != nil {
+			return CreateErrorResponse(
+				baseMessage.ID,
+				mcp.INVALID_REQUEST,
+				"Invalid complete request",
+			)
+		}
+		result, err := handler(ctx, request)
+		if err != nil {
+			return CreateErrorResponse(baseMessage.ID, mcp.INTERNAL_ERROR, err.Error())
+		}
+		return createResponse(baseMessage.ID, result)
+	case "logging/setLevel":
+		if !s.capabilities.logging {
+			return CreateErrorResponse(
+				baseMessage.ID,
+				mcp.METHOD_NOT_FOUND,
+				"Logging not supported",
+			)
+		}
+		var request mcp.SetLevelRequest
+		if err := json.Unmarshal(message, &request); err != nil {
+			return CreateErrorResponse(
+				baseMessage.ID,
+				mcp.INVALID_REQUEST,
+				"Invalid set level request",
+			)
+		}
+		return s.handleSetLevel(ctx, baseMessage.ID, request)
 	case "tools/list":
 		if len(s.tools) == 0 {
 			return CreateErrorResponse(
@@ -426,11 +583,12 @@ func (s *MCPServer) AddResource(
 		panic("Resource capabilities not enabled")
 	}
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	s.resources[resource.URI] = resourceEntry{
 		resource: resource,
 		handler:  handler,
 	}
+	s.mu.Unlock()
+	s.resourceListChanged()
 }
 
 // AddResourceTemplate registers a new resource template and its handler
@@ -442,11 +600,37 @@ func (s *MCPServer) AddResourceTemplate(
 		panic("Resource capabilities not enabled")
 	}
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	s.resourceTemplates[template.URITemplate] = resourceTemplateEntry{
 		template: template,
 		handler:  handler,
 	}
+	s.mu.Unlock()
+	s.resourceListChanged()
+}
+
+// DeleteResources removes resources and resource templates from the server
+func (s *MCPServer) DeleteResources(uris ...string) {
+	s.mu.Lock()
+	for _, uri := range uris {
+		delete(s.resources, uri)
+		delete(s.resourceTemplates, uri)
+	}
+	s.mu.Unlock()
+	s.resourceListChanged()
+}
+
+// resourceListChanged notifies clients of changed resources, if the server is initialized and advertises it
+func (s *MCPServer) resourceListChanged() {
+	if s.initialized.Load() && s.capabilities.resources != nil && s.capabilities.resources.listChanged {
+		s.BroadcastNotification("notifications/resources/list_changed", nil)
+	}
+}
+
+// SetCompletionHandler registers the handler of completion requests and enables the completions capability
+func (s *MCPServer) SetCompletionHandler(handler CompletionHandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.completionHandler = handler
 }
 
 // AddPrompt registers a new prompt handler with the given name
@@ -482,9 +666,7 @@ func (s *MCPServer) AddTools(tools ...ServerTool) {
 
 	// Send notification if server is already initialized
 	if initialized {
-		if err := s.SendNotificationToClient("notifications/tools/list_changed", nil); err != nil {
-			// We can't return the error, but in a future version we could log it
-		}
+		s.BroadcastNotification("notifications/tools/list_changed", nil)
 	}
 }
 
@@ -507,9 +689,7 @@ func (s *MCPServer) DeleteTools(names ...string) {
 
 	// Send notification if server is already initialized
 	if initialized {
-		if err := s.SendNotificationToClient("notifications/tools/list_changed", nil); err != nil {
-			// We can't return the error, but in a future version we could log it
-		}
+		s.BroadcastNotification("notifications/tools/list_changed", nil)
 	}
 }
 
@@ -520,17 +700,30 @@ func (s *MCPServer) AddAuthorizer(f AuthChecker) {
 	s.authCheckers = append(s.authCheckers, f)
 }
 
+// AddToolFilter registers a filter of tools/list, e.g. to hide tools the caller isn't authorized to call.
+// Filters are applied in the order they were added.
+func (s *MCPServer) AddToolFilter(f ToolFilterFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.toolFilters = append(s.toolFilters, f)
+}
+
 // NeedAuth check authorizer
 func (s *MCPServer) NeedAuth(r *http.Request) bool {
-	if len(s.authCheckers) == 0 {
-		return false
-	}
+	_, ok := s.Authorize(r)
+	return !ok
+}
+
+// Authorize runs auth checkers, it returns the request with the context they validated and false if one rejected it
+func (s *MCPServer) Authorize(r *http.Request) (*http.Request, bool) {
 	for _, checker := range s.authCheckers {
-		if !checker(r) {
-			return true
+		ctx, ok := checker(r)
+		if !ok {
+			return r, false
 		}
+		r = r.WithContext(ctx)
 	}
-	return false
+	return r, true
 }
 
 // AddNotificationHandler registers a new handler for incoming notifications
@@ -550,7 +743,15 @@ func (s *MCPServer) handleInitialize(
 ) mcp.JSONRPCMessage {
 	capabilities := mcp.ServerCapabilities{}
 
-	capabilities.Resources = nil // Not Supported
+	if s.capabilities.resources != nil {
+		capabilities.Resources = &struct {
+			Subscribe   bool `json:"subscribe,omitempty"`
+			ListChanged bool `json:"listChanged,omitempty"`
+		}{
+			Subscribe:   s.capabilities.resources.subscribe,
+			ListChanged: s.capabilities.resources.listChanged,
+		}
+	}
 
 	capabilities.Prompts = &struct {
 		ListChanged bool `json:"listChanged,omitempty"`
@@ -568,8 +769,22 @@ func (s *MCPServer) handleInitialize(
 		capabilities.Logging = &struct{}{}
 	}
 
+	version := mcp.NegotiateProtocolVersion(request.Params.ProtocolVersion)
+	if client, ok := ClientFromContext(ctx); ok {
+		s.clients.Store(client.SessionID, clientSession{
+			protocolVersion: version,
+			capabilities:    request.Params.Capabilities,
+		})
+	}
+
+	s.mu.RLock()
+	if s.completionHandler != nil && mcp.ProtocolSupports(version, mcp.FeatureCompletions) {
+		capabilities.Completions = &struct{}{}
+	}
+	s.mu.RUnlock()
+
 	result := mcp.InitializeResult{
-		ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
+		ProtocolVersion: version,
 		ServerInfo: mcp.Implementation{
 			Name:    s.name,
 			Version: s.version,
@@ -590,6 +805,26 @@ func (s *MCPServer) handlePing(
 	return createResponse(id, mcp.EmptyResult{})
 }
 
+func (s *MCPServer) handleSetLevel(
+	ctx context.Context,
+	id interface{},
+	request mcp.SetLevelRequest,
+) mcp.JSONRPCMessage {
+	if !request.Params.Level.Valid() {
+		return CreateErrorResponse(
+			id,
+			mcp.INVALID_PARAMS,
+			fmt.Sprintf("Unknown logging level: %s", request.Params.Level),
+		)
+	}
+	client, ok := ClientFromContext(ctx)
+	if !ok {
+		return CreateErrorResponse(id, mcp.INVALID_REQUEST, "Logging requires a session")
+	}
+	s.logLevels.Store(client.SessionID, request.Params.Level)
+	return createResponse(id, mcp.EmptyResult{})
+}
+
 func (s *MCPServer) handleListResources(
 	ctx context.Context,
 	id interface{},
@@ -637,63 +872,86 @@ func (s *MCPServer) handleReadResource(
 	id interface{},
 	request mcp.ReadResourceRequest,
 ) mcp.JSONRPCMessage {
+	contents, err := s.ReadResource(ctx, request)
+	if errors.Is(err, ErrResourceNotFound) {
+		return CreateErrorResponse(
+			id,
+			mcp.INVALID_PARAMS,
+			fmt.Sprintf(
+				"No handler found for resource URI: %s",
+				request.Params.URI,
+			),
+		)
+	}
+	if err != nil {
+		return CreateErrorResponse(id, mcp.INTERNAL_ERROR, err.Error())
+	}
+	return createResponse(id, mcp.ReadResourceResult{Contents: contents})
+}
+
+// ReadResource reads a resource or an instance of a resource template, the same way as a resources/read request.
+func (s *MCPServer) ReadResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
 	s.mu.RLock()
 	// First try direct resource handlers
 	if entry, ok := s.resources[request.Params.URI]; ok {
 		handler := entry.handler
 		s.mu.RUnlock()
-		contents, err := handler(ctx, request)
-		if err != nil {
-			return CreateErrorResponse(id, mcp.INTERNAL_ERROR, err.Error())
-		}
-		return createResponse(id, mcp.ReadResourceResult{Contents: contents})
+		return handler(ctx, request)
 	}
 
 	// If no direct handler found, try matching against templates
 	var matchedHandler ResourceTemplateHandlerFunc
 	var matched bool
 	for uriTemplate, entry := range s.resourceTemplates {
-		if matchesTemplate(request.Params.URI, uriTemplate) {
+		if args, ok := matchTemplate(request.Params.URI, uriTemplate); ok {
 			matchedHandler = entry.handler
 			matched = true
+			// Variables of the template are passed to the handler as arguments
+			if request.Params.Arguments == nil {
+				request.Params.Arguments = make(map[string]interface{}, len(args))
+			}
+			for name, value := range args {
+				request.Params.Arguments[name] = value
+			}
 			break
 		}
 	}
 	s.mu.RUnlock()
 
-	if matched {
-		contents, err := matchedHandler(ctx, request)
-		if err != nil {
-			return CreateErrorResponse(id, mcp.INTERNAL_ERROR, err.Error())
-		}
-		return createResponse(
-			id,
-			mcp.ReadResourceResult{Contents: contents},
-		)
+	if !matched {
+		return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, request.Params.URI)
 	}
-
-	return CreateErrorResponse(
-		id,
-		mcp.INVALID_PARAMS,
-		fmt.Sprintf(
-			"No handler found for resource URI: %s",
-			request.Params.URI,
-		),
-	)
+	return matchedHandler(ctx, request)
 }
 
-// matchesTemplate checks if a URI matches a URI template pattern
-func matchesTemplate(uri string, template string) bool {
+var templateVariable = regexp.MustCompile(`\\\{([^}]+)\\\}`)
+
+// matchTemplate checks if a URI matches a URI template pattern and returns values of template variables
+func matchTemplate(uri string, template string) (map[string]string, bool) {
 	// Convert template into a regex pattern
-	pattern := template
+	pattern := regexp.QuoteMeta(template)
+	names := templateVariable.FindAllStringSubmatch(pattern, -1)
 	// Replace {name} with ([^/]+)
-	pattern = regexp.QuoteMeta(pattern)
-	pattern = regexp.MustCompile(`\\\{[^}]+\\\}`).
-		ReplaceAllString(pattern, `([^/]+)`)
+	pattern = templateVariable.ReplaceAllString(pattern, `([^/]+)`)
 	pattern = "^" + pattern + "$"
 
-	matched, _ := regexp.MatchString(pattern, uri)
-	return matched
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, false
+	}
+	values := re.FindStringSubmatch(uri)
+	if values == nil {
+		return nil, false
+	}
+	args := make(map[string]string, len(names))
+	for i, name := range names {
+		value, err := url.PathUnescape(values[i+1])
+		if err != nil {
+			value = values[i+1]
+		}
+		args[name[1]] = value
+	}
+	return args, true
 }
 
 func (s *MCPServer) handleListPrompts(
@@ -747,7 +1005,19 @@ func (s *MCPServer) handleListTools(
 	id interface{},
 	request mcp.ListToolsRequest,
 ) mcp.JSONRPCMessage {
+	result := mcp.ListToolsResult{
+		Tools: s.toolsForClient(ctx, s.ListToolsFor(ctx)),
+	}
+	if request.Params.Cursor != "" {
+		result.NextCursor = "" // Handle pagination if needed
+	}
+	return createResponse(id, result)
+}
+
+// ListTools returns all registered tools sorted by name
+func (s *MCPServer) ListTools() []mcp.Tool {
 	s.mu.RLock()
+	defer s.mu.RUnlock()
 	tools := make([]mcp.Tool, 0, len(s.tools))
 
 	// Get all tool names for consistent ordering
@@ -763,15 +1033,30 @@ func (s *MCPServer) handleListTools(
 	for _, name := range toolNames {
 		tools = append(tools, s.tools[name].Tool)
 	}
+	return tools
+}
+
+// ListToolsFor returns tools offered to the client of the request, i.e. left by all tool filters
+func (s *MCPServer) ListToolsFor(ctx context.Context) []mcp.Tool {
+	s.mu.RLock()
+	filters := s.toolFilters
 	s.mu.RUnlock()
 
-	result := mcp.ListToolsResult{
-		Tools: tools,
+	tools := s.ListTools()
+	for _, filter := range filters {
+		tools = filter(ctx, tools)
 	}
-	if request.Params.Cursor != "" {
-		result.NextCursor = "" // Handle pagination if needed
+	return tools
+}
+
+// offers reports whether tools/list shows the tool to the client of the request
+func (s *MCPServer) offers(ctx context.Context, name string) bool {
+	for _, tool := range s.ListToolsFor(ctx) {
+		if tool.Name == name {
+			return true
+		}
 	}
-	return createResponse(id, result)
+	return false
 }
 
 func (s *MCPServer) handleToolCall(
@@ -779,19 +1064,50 @@ func (s *MCPServer) handleToolCall(
 	id interface{},
 	request mcp.CallToolRequest,
 ) mcp.JSONRPCMessage {
-	s.mu.RLock()
-	tool, ok := s.tools[request.Params.Name]
-	s.mu.RUnlock()
+	ctx, done := s.trackRequest(ctx, id)
+	defer done()
+	if request.Params.Meta != nil && request.Params.Meta.ProgressToken != nil {
+		ctx = xcontext.WithProgress(ctx, s.progressReporter(ctx, request.Params.Meta.ProgressToken))
+	}
 
-	if !ok {
+	result, err := s.CallTool(ctx, request)
+	if errors.Is(context.Cause(ctx), errRequestCancelled) {
+		// The client won't use the result, so no response is sent
+		return nil
+	}
+	if errors.Is(err, ErrToolNotFound) {
 		return CreateErrorResponse(
 			id,
 			mcp.INVALID_PARAMS,
 			fmt.Sprintf("Tool not found: %s", request.Params.Name),
 		)
 	}
+	if err != nil {
+		return CreateErrorResponse(id, mcp.INTERNAL_ERROR, err.Error())
+	}
+	if result.StructuredContent != nil && !s.Supports(ctx, mcp.FeatureStructuredOutput) {
+		unstructured := *result
+		unstructured.StructuredContent = nil
+		result = &unstructured
+	}
+
+	return createResponse(id, result)
+}
+
+// CallTool executes a tool through all tool middlewares, the same way as a tools/call request.
+// It allows other protocols to reuse tools together with their authorization.
+func (s *MCPServer) CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.mu.RLock()
+	tool, ok := s.tools[request.Params.Name]
+	middlewares := s.toolMiddlewares
+	s.mu.RUnlock()
+
+	// tools hidden from the caller by tool filters can't be called either
+	if !ok || !s.offers(ctx, request.Params.Name) {
+		return nil, fmt.Errorf("%w: %s", ErrToolNotFound, request.Params.Name)
+	}
 
-	for _, m := range s.toolMiddlewares {
+	for _, m := range middlewares {
 		curH := tool.Handler
 		tt := ServerTool{
 			Tool: tool.Tool,
@@ -801,18 +1117,17 @@ func (s *MCPServer) handleToolCall(
 		}
 		tool = tt
 	}
-	result, err := tool.Handler(ctx, request)
-	if err != nil {
-		return CreateErrorResponse(id, mcp.INTERNAL_ERROR, err.Error())
-	}
-
-	return createResponse(id, result)
+	return tool.Handler(ctx, request)
 }
 
 func (s *MCPServer) handleNotification(
 	ctx context.Context,
 	notification mcp.JSONRPCNotification,
 ) mcp.JSONRPCMessage {
+	if notification.Method == "notifications/cancelled" {
+		s.cancelRequest(ctx, notification.Params.AdditionalFields["requestId"])
+	}
+
 	s.mu.RLock()
 	handler, ok := s.notificationHandlers[notification.Method]
 	s.mu.RUnlock()
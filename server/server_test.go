@@ -3,11 +3,17 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/xcontext"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMCPServer_NewMCPServer(t *testing.T) {
@@ -70,7 +76,9 @@ func TestMCPServer_Capabilities(t *testing.T) {
 				assert.Equal(t, "test-server", initResult.ServerInfo.Name)
 				assert.Equal(t, "1.0.0", initResult.ServerInfo.Version)
 
-				assert.Nil(t, initResult.Capabilities.Resources)
+				assert.NotNil(t, initResult.Capabilities.Resources)
+				assert.True(t, initResult.Capabilities.Resources.Subscribe)
+				assert.True(t, initResult.Capabilities.Resources.ListChanged)
 
 				assert.NotNil(t, initResult.Capabilities.Prompts)
 				assert.True(t, initResult.Capabilities.Prompts.ListChanged)
@@ -180,11 +188,12 @@ func TestMCPServer_Tools(t *testing.T) {
               "id": 1,
               "method": "initialize"
             }`))
+			queue := server.RegisterSession("test-session")
 			notifications := make([]ServerNotification, 0)
 			tt.action(server)
 			for done := false; !done; {
 				select {
-				case serverNotification := <-server.notifications:
+				case serverNotification := <-queue:
 					notifications = append(notifications, serverNotification)
 					if len(notifications) == tt.expectedNotifications {
 						done = true
@@ -594,6 +603,480 @@ func TestMCPServer_HandleUndefinedHandlers(t *testing.T) {
 	}
 }
 
+func TestMCPServer_ReadResourceTemplate(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0", WithResourceCapabilities(false, false))
+	server.AddResourceTemplate(
+		mcp.ResourceTemplate{
+			URITemplate: "db://{table}/rows/{pk}",
+			Name:        "Row",
+		},
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{
+					URI:  request.Params.URI,
+					Text: fmt.Sprintf("%v:%v", request.Params.Arguments["table"], request.Params.Arguments["pk"]),
+				},
+			}, nil
+		},
+	)
+
+	response := server.HandleMessage(context.Background(), []byte(`{
+		"jsonrpc": "2.0",
+		"id": 1,
+		"method": "resources/read",
+		"params": {
+			"uri": "db://users/rows/a%20b"
+		}
+	}`))
+	resp, ok := response.(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	result, ok := resp.Result.(mcp.ReadResourceResult)
+	require.True(t, ok)
+	require.Len(t, result.Contents, 1)
+	assert.Equal(t, "users:a b", result.Contents[0].(mcp.TextResourceContents).Text)
+}
+
+func TestMCPServer_Complete(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	message := `{
+		"jsonrpc": "2.0",
+		"id": 1,
+		"method": "completion/complete",
+		"params": {
+			"ref": {"type": "ref/prompt", "name": "greeting"},
+			"argument": {"name": "language", "value": "en"}
+		}
+	}`
+	errorResponse, ok := server.HandleMessage(context.Background(), []byte(message)).(mcp.JSONRPCError)
+	require.True(t, ok)
+	assert.Equal(t, mcp.METHOD_NOT_FOUND, errorResponse.Error.Code)
+
+	server.SetCompletionHandler(func(ctx context.Context, request mcp.CompleteRequest) (*mcp.CompleteResult, error) {
+		result := &mcp.CompleteResult{}
+		result.Completion.Values = []string{request.Params.Argument.Value + "glish"}
+		return result, nil
+	})
+	resp, ok := server.HandleMessage(context.Background(), []byte(message)).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	assert.Equal(t, []string{"english"}, resp.Result.(*mcp.CompleteResult).Completion.Values)
+
+	initResp, ok := server.HandleMessage(context.Background(), []byte(`{"jsonrpc": "2.0", "id": 2, "method": "initialize", "params": {}}`)).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	assert.NotNil(t, initResp.Result.(mcp.InitializeResult).Capabilities.Completions)
+}
+
+func TestMCPServer_CancelToolCall(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	started := make(chan struct{})
+	server.AddTool(mcp.NewTool("slow"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	ctx := xcontext.WithSession(context.Background(), "session-1")
+	responses := make(chan mcp.JSONRPCMessage, 1)
+	go func() {
+		responses <- server.HandleMessage(ctx, []byte(`{"jsonrpc": "2.0", "id": 7, "method": "tools/call", "params": {"name": "slow"}}`))
+	}()
+	<-started
+
+	// the same request ID in another session must not be cancelled
+	other := xcontext.WithSession(context.Background(), "session-2")
+	server.HandleMessage(other, []byte(`{"jsonrpc": "2.0", "method": "notifications/cancelled", "params": {"requestId": 7}}`))
+	select {
+	case <-responses:
+		t.Fatal("request cancelled from another session")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	server.HandleMessage(ctx, []byte(`{"jsonrpc": "2.0", "method": "notifications/cancelled", "params": {"requestId": 7, "reason": "user aborted"}}`))
+	select {
+	case resp := <-responses:
+		assert.Nil(t, resp, "cancelled requests must not be answered")
+	case <-time.After(time.Second):
+		t.Fatal("tool call was not cancelled")
+	}
+}
+
+func TestMCPServer_ToolCallProgress(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	server.AddTool(mcp.NewTool("scan"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		xcontext.ReportProgress(ctx, 10, 100)
+		xcontext.ReportProgress(ctx, 20, 100) // throttled
+		return &mcp.CallToolResult{}, nil
+	})
+
+	queue := server.RegisterSession("session-1")
+	ctx := server.WithContext(context.Background(), NotificationContext{ClientID: "session-1", SessionID: "session-1"})
+	resp := server.HandleMessage(ctx, []byte(`{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "scan", "_meta": {"progressToken": "scan-1"}}}`))
+	require.IsType(t, mcp.JSONRPCResponse{}, resp)
+
+	require.Len(t, queue, 1)
+	notification := <-queue
+	assert.Equal(t, "session-1", notification.Context.SessionID)
+	assert.Equal(t, "notifications/progress", notification.Notification.Method)
+	assert.Equal(t, "scan-1", notification.Notification.Params.AdditionalFields["progressToken"])
+	assert.Equal(t, 10.0, notification.Notification.Params.AdditionalFields["progress"])
+	assert.Equal(t, 100.0, notification.Notification.Params.AdditionalFields["total"])
+
+	// without a token progress is not reported
+	server.HandleMessage(ctx, []byte(`{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "scan"}}`))
+	assert.Empty(t, queue)
+}
+
+func TestMCPServer_Logging(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	queue := server.RegisterSession("session-1")
+	ctx := server.WithContext(context.Background(), NotificationContext{ClientID: "session-1", SessionID: "session-1"})
+	setLevel := `{"jsonrpc": "2.0", "id": 1, "method": "logging/setLevel", "params": {"level": "%s"}}`
+
+	errorResponse, ok := server.HandleMessage(ctx, []byte(fmt.Sprintf(setLevel, "info"))).(mcp.JSONRPCError)
+	require.True(t, ok)
+	assert.Equal(t, mcp.METHOD_NOT_FOUND, errorResponse.Error.Code)
+
+	server = NewMCPServer("test-server", "1.0.0", WithLogging())
+	queue = server.RegisterSession("session-1")
+	server.RegisterSession("session-2")
+	other := server.WithContext(context.Background(), NotificationContext{ClientID: "session-2", SessionID: "session-2"})
+
+	errorResponse, ok = server.HandleMessage(ctx, []byte(fmt.Sprintf(setLevel, "verbose"))).(mcp.JSONRPCError)
+	require.True(t, ok)
+	assert.Equal(t, mcp.INVALID_PARAMS, errorResponse.Error.Code)
+
+	// no messages until the client sets a level
+	require.NoError(t, server.SendLogMessage(ctx, mcp.LoggingLevelError, "test", "ignored"))
+	assert.Empty(t, queue)
+
+	_, ok = server.HandleMessage(ctx, []byte(fmt.Sprintf(setLevel, "warning"))).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	require.NoError(t, server.SendLogMessage(ctx, mcp.LoggingLevelInfo, "test", "too verbose"))
+	require.NoError(t, server.SendLogMessage(ctx, mcp.LoggingLevelError, "test", "query failed"))
+	require.NoError(t, server.SendLogMessage(other, mcp.LoggingLevelError, "test", "another session"))
+
+	require.Len(t, queue, 1)
+	notification := <-queue
+	assert.Equal(t, "notifications/message", notification.Notification.Method)
+	assert.Equal(t, mcp.LoggingLevelError, notification.Notification.Params.AdditionalFields["level"])
+	assert.Equal(t, "test", notification.Notification.Params.AdditionalFields["logger"])
+	assert.Equal(t, "query failed", notification.Notification.Params.AdditionalFields["data"])
+}
+
+func TestMCPServer_ResourceSubscriptions(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0", WithResourceCapabilities(false, false))
+	ctx := server.WithContext(context.Background(), NotificationContext{ClientID: "session-1", SessionID: "session-1"})
+	subscribe := `{"jsonrpc": "2.0", "id": 1, "method": "resources/%s", "params": {"uri": "%s"}}`
+
+	errorResponse, ok := server.HandleMessage(ctx, []byte(fmt.Sprintf(subscribe, "subscribe", "db://users/schema"))).(mcp.JSONRPCError)
+	require.True(t, ok)
+	assert.Equal(t, mcp.METHOD_NOT_FOUND, errorResponse.Error.Code)
+
+	server = NewMCPServer("test-server", "1.0.0", WithResourceCapabilities(true, false))
+	server.AddResource(mcp.Resource{URI: "db://users/schema", Name: "users"}, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return nil, nil
+	})
+	var calls []string
+	server.SetResourceSubscriptionHandler(func(ctx context.Context, uri, sessionID string, subscribed bool) error {
+		assert.Equal(t, "db://users/schema", uri)
+		calls = append(calls, fmt.Sprintf("%s:%v", sessionID, subscribed))
+		return nil
+	})
+	queue := server.RegisterSession("session-1")
+	other := server.RegisterSession("session-2")
+	otherCtx := server.WithContext(context.Background(), NotificationContext{ClientID: "session-2", SessionID: "session-2"})
+
+	errorResponse, ok = server.HandleMessage(ctx, []byte(fmt.Sprintf(subscribe, "subscribe", "db://orders/schema"))).(mcp.JSONRPCError)
+	require.True(t, ok)
+	assert.Equal(t, mcp.INVALID_PARAMS, errorResponse.Error.Code)
+
+	_, ok = server.HandleMessage(ctx, []byte(fmt.Sprintf(subscribe, "subscribe", "db://users/schema"))).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	_, ok = server.HandleMessage(otherCtx, []byte(fmt.Sprintf(subscribe, "subscribe", "db://users/schema"))).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	_, ok = server.HandleMessage(otherCtx, []byte(fmt.Sprintf(subscribe, "subscribe", "db://users/schema"))).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	// the handler hears about every subscribed session once
+	assert.Equal(t, []string{"session-1:true", "session-2:true"}, calls)
+
+	server.NotifyResourceUpdated("db://users/schema")
+	require.Len(t, queue, 1)
+	require.Len(t, other, 1)
+	notification := <-queue
+	assert.Equal(t, "notifications/resources/updated", notification.Notification.Method)
+	assert.Equal(t, "db://users/schema", notification.Notification.Params.AdditionalFields["uri"])
+	<-other
+
+	_, ok = server.HandleMessage(ctx, []byte(fmt.Sprintf(subscribe, "unsubscribe", "db://users/schema"))).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	server.NotifyResourceUpdated("db://users/schema")
+	assert.Empty(t, queue)
+	require.Len(t, other, 1)
+	assert.Equal(t, []string{"session-1:true", "session-2:true", "session-1:false"}, calls)
+
+	// closing a subscribed session unsubscribes it
+	server.UnregisterSession("session-2")
+	assert.Equal(t, []string{"session-1:true", "session-2:true", "session-1:false", "session-2:false"}, calls)
+}
+
+func TestMCPServer_SlowSubscriptionHandler(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0", WithResourceCapabilities(true, false))
+	for _, uri := range []string{"db://users/schema", "db://orders/schema"} {
+		server.AddResource(mcp.Resource{URI: uri}, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			return nil, nil
+		})
+	}
+	started, release := make(chan struct{}), make(chan struct{})
+	server.SetResourceSubscriptionHandler(func(ctx context.Context, uri, sessionID string, subscribed bool) error {
+		if uri == "db://users/schema" {
+			close(started)
+			<-release
+			return errors.New("unable to watch")
+		}
+		return nil
+	})
+	server.RegisterSession("session-1")
+	other := server.RegisterSession("session-2")
+	subscribe := `{"jsonrpc": "2.0", "id": 1, "method": "resources/subscribe", "params": {"uri": "%s"}}`
+
+	ctx := server.WithContext(context.Background(), NotificationContext{ClientID: "session-1", SessionID: "session-1"})
+	failed := make(chan mcp.JSONRPCMessage, 1)
+	go func() {
+		failed <- server.HandleMessage(ctx, []byte(fmt.Sprintf(subscribe, "db://users/schema")))
+	}()
+	<-started
+
+	// a slow handler of one session must not block subscriptions of others
+	otherCtx := server.WithContext(context.Background(), NotificationContext{ClientID: "session-2", SessionID: "session-2"})
+	_, ok := server.HandleMessage(otherCtx, []byte(fmt.Sprintf(subscribe, "db://orders/schema"))).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	server.NotifyResourceUpdated("db://orders/schema")
+	require.Len(t, other, 1)
+
+	close(release)
+	_, ok = (<-failed).(mcp.JSONRPCError)
+	require.True(t, ok)
+	// a subscription the handler refused is dropped
+	server.subscriptions.mu.Lock()
+	assert.NotContains(t, server.subscriptions.sessions, "db://users/schema")
+	server.subscriptions.mu.Unlock()
+}
+
+func TestMCPServer_HandleBatch(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0", WithBatchConcurrency(2))
+	// each call waits for the other one, so the batch only completes if they run at the same time
+	both := make(chan struct{})
+	var calls atomic.Int32
+	server.AddTool(mcp.NewTool("wait"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if calls.Add(1) == 2 {
+			close(both)
+		}
+		select {
+		case <-both:
+		case <-time.After(time.Second):
+			return nil, errors.New("calls of the batch are not concurrent")
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent("done")}}, nil
+	})
+
+	response := server.HandleMessage(context.Background(), []byte(`[
+		{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "wait"}},
+		{"jsonrpc": "2.0", "method": "notifications/initialized"},
+		{"jsonrpc": "2.0", "id": 2, "method": "ping"},
+		{"jsonrpc": "2.0", "id": 3, "method": "tools/call", "params": {"name": "wait"}},
+		{"jsonrpc": "1.0", "id": 4, "method": "ping"},
+		[{"jsonrpc": "2.0", "id": 5, "method": "ping"}]
+	]`))
+	responses, ok := response.([]mcp.JSONRPCMessage)
+	require.True(t, ok)
+	require.Len(t, responses, 5)
+
+	var ids []interface{}
+	for _, response := range responses {
+		switch r := response.(type) {
+		case mcp.JSONRPCResponse:
+			ids = append(ids, r.ID)
+		case mcp.JSONRPCError:
+			ids = append(ids, r.ID)
+		}
+	}
+	assert.Equal(t, []interface{}{float64(1), float64(2), float64(3), float64(4), nil}, ids)
+	for _, i := range []int{0, 2} {
+		_, ok = responses[i].(mcp.JSONRPCResponse)
+		assert.True(t, ok, "tool calls of the batch succeed")
+	}
+	assert.Equal(t, mcp.INVALID_REQUEST, responses[3].(mcp.JSONRPCError).Error.Code)
+	assert.Equal(t, mcp.INVALID_REQUEST, responses[4].(mcp.JSONRPCError).Error.Code)
+
+	// a batch of notifications has no response
+	assert.Nil(t, server.HandleMessage(context.Background(), []byte(`[{"jsonrpc": "2.0", "method": "notifications/initialized"}]`)))
+
+	errorResponse, ok := server.HandleMessage(context.Background(), []byte(`[]`)).(mcp.JSONRPCError)
+	require.True(t, ok)
+	assert.Equal(t, mcp.INVALID_REQUEST, errorResponse.Error.Code)
+}
+
+func TestMCPServer_ProtocolVersion(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	server.SetCompletionHandler(func(ctx context.Context, request mcp.CompleteRequest) (*mcp.CompleteResult, error) {
+		return &mcp.CompleteResult{}, nil
+	})
+	server.AddTool(mcp.NewTool(
+		"users",
+		mcp.WithOutputSchema(map[string]interface{}{"rows": map[string]interface{}{"type": "array"}}),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{Title: "Users"}),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return &mcp.CallToolResult{StructuredContent: map[string]interface{}{"rows": []interface{}{}}}, nil
+	})
+	initialize := `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "%s", "capabilities": {"elicitation": {}}}}`
+
+	for _, tt := range []struct {
+		requested  string
+		negotiated string
+		latest     bool
+	}{
+		{requested: "2024-11-05", negotiated: "2024-11-05"},
+		{requested: "2025-06-18", negotiated: "2025-06-18", latest: true},
+		{requested: "2099-01-01", negotiated: mcp.LATEST_PROTOCOL_VERSION, latest: true},
+	} {
+		t.Run(tt.requested, func(t *testing.T) {
+			ctx := server.WithContext(context.Background(), NotificationContext{ClientID: tt.requested, SessionID: tt.requested})
+			resp, ok := server.HandleMessage(ctx, []byte(fmt.Sprintf(initialize, tt.requested))).(mcp.JSONRPCResponse)
+			require.True(t, ok)
+			result := resp.Result.(mcp.InitializeResult)
+			assert.Equal(t, tt.negotiated, result.ProtocolVersion)
+			assert.Equal(t, tt.negotiated, server.ProtocolVersion(ctx))
+			assert.Equal(t, tt.latest, result.Capabilities.Completions != nil)
+			assert.Equal(t, tt.latest, server.Supports(ctx, mcp.FeatureElicitation))
+
+			resp, ok = server.HandleMessage(ctx, []byte(`{"jsonrpc": "2.0", "id": 2, "method": "tools/list"}`)).(mcp.JSONRPCResponse)
+			require.True(t, ok)
+			tool := resp.Result.(mcp.ListToolsResult).Tools[0]
+			assert.Equal(t, tt.latest, tool.Annotations != nil)
+			assert.Equal(t, tt.latest, tool.OutputSchema != nil)
+
+			resp, ok = server.HandleMessage(ctx, []byte(`{"jsonrpc": "2.0", "id": 3, "method": "tools/call", "params": {"name": "users"}}`)).(mcp.JSONRPCResponse)
+			require.True(t, ok)
+			assert.Equal(t, tt.latest, resp.Result.(*mcp.CallToolResult).StructuredContent != nil)
+		})
+	}
+
+	// other clients see everything, whatever the old one negotiated
+	assert.NotNil(t, server.ListTools()[0].Annotations)
+	assert.Equal(t, mcp.LATEST_PROTOCOL_VERSION, server.ProtocolVersion(context.Background()))
+}
+
+func TestMCPServer_ToolFilters(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	for _, name := range []string{"list_orders", "delete_order", "query"} {
+		server.AddTool(mcp.NewTool(name), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return &mcp.CallToolResult{}, nil
+		})
+	}
+	server.AddToolFilter(func(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+		return slices.DeleteFunc(tools, func(tool mcp.Tool) bool { return tool.Name == "query" })
+	})
+	server.AddToolFilter(func(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+		if xcontext.Header(ctx, "X-Role") == "admin" {
+			return tools
+		}
+		return slices.DeleteFunc(tools, func(tool mcp.Tool) bool { return tool.Name == "delete_order" })
+	})
+
+	list := func(ctx context.Context) []string {
+		resp, ok := server.HandleMessage(ctx, []byte(`{"jsonrpc": "2.0", "id": 1, "method": "tools/list"}`)).(mcp.JSONRPCResponse)
+		require.True(t, ok)
+		var names []string
+		for _, tool := range resp.Result.(mcp.ListToolsResult).Tools {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"list_orders"}, list(context.Background()))
+	admin := xcontext.WithHeader(context.Background(), map[string][]string{"X-Role": {"admin"}})
+	assert.Equal(t, []string{"delete_order", "list_orders"}, list(admin))
+	// filters don't remove tools, they only hide them from the client of the request
+	assert.Len(t, server.ListTools(), 3)
+
+	call := func(ctx context.Context, name string) mcp.JSONRPCMessage {
+		return server.HandleMessage(ctx, []byte(fmt.Sprintf(
+			`{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": %q}}`, name,
+		)))
+	}
+	_, ok := call(admin, "delete_order").(mcp.JSONRPCResponse)
+	assert.True(t, ok)
+	for _, name := range []string{"delete_order", "query"} {
+		resp, ok := call(context.Background(), name).(mcp.JSONRPCError)
+		require.True(t, ok, "hidden tool %s must not be called", name)
+		assert.Equal(t, mcp.INVALID_PARAMS, resp.Error.Code)
+		assert.Equal(t, "Tool not found: "+name, resp.Error.Message)
+	}
+}
+
+func TestMCPServer_Elicit(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	server.AddTool(mcp.NewTool("delete_order"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := ServerFromContext(ctx).Elicit(ctx, "Delete order 42?", map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"approved": map[string]interface{}{"type": "boolean"}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("%s %v", result.Action, result.Content["approved"])), nil
+	})
+	initialize := `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2025-06-18", "capabilities": %s}}`
+	call := []byte(`{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "delete_order"}}`)
+
+	// clients send IDs back as JSON numbers, from 1e6 on they are printed in exponent notation by default
+	for _, lastID := range []int64{0, 999_999} {
+		t.Run(fmt.Sprintf("Client answers after request %d", lastID), func(t *testing.T) {
+			server.requestID.Store(lastID)
+			queue := server.RegisterSession("session-1")
+			defer server.UnregisterSession("session-1")
+			ctx := server.WithContext(context.Background(), NotificationContext{ClientID: "session-1", SessionID: "session-1"})
+			server.HandleMessage(ctx, []byte(fmt.Sprintf(initialize, `{"elicitation": {}}`)))
+
+			done := make(chan mcp.JSONRPCMessage, 1)
+			go func() { done <- server.HandleMessage(ctx, call) }()
+
+			var request *mcp.JSONRPCRequest
+			select {
+			case notification := <-queue:
+				request = notification.Request
+			case <-time.After(time.Second):
+				t.Fatal("no elicitation request")
+			}
+			require.NotNil(t, request)
+			assert.Equal(t, "elicitation/create", request.Method)
+			assert.Equal(t, "Delete order 42?", request.Params.(mcp.ElicitParams).Message)
+			raw, err := json.Marshal(request)
+			require.NoError(t, err)
+			assert.Contains(t, string(raw), `"method":"elicitation/create"`)
+
+			// a response of the client has no response itself
+			response := fmt.Sprintf(`{"jsonrpc": "2.0", "id": %d, "result": {"action": "accept", "content": {"approved": true}}}`, request.ID)
+			assert.Nil(t, server.HandleMessage(ctx, []byte(response)))
+
+			select {
+			case resp := <-done:
+				result := resp.(mcp.JSONRPCResponse).Result.(*mcp.CallToolResult)
+				assert.Equal(t, "accept true", result.Content[0].(mcp.TextContent).Text)
+			case <-time.After(time.Second):
+				t.Fatal("tool call didn't finish after the answer")
+			}
+		})
+	}
+
+	t.Run("Client without elicitation", func(t *testing.T) {
+		server.RegisterSession("session-2")
+		defer server.UnregisterSession("session-2")
+		ctx := server.WithContext(context.Background(), NotificationContext{ClientID: "session-2", SessionID: "session-2"})
+		server.HandleMessage(ctx, []byte(fmt.Sprintf(initialize, `{}`)))
+		_, err := server.Elicit(ctx, "Delete order 42?", nil)
+		assert.ErrorIs(t, err, ErrElicitationNotSupported)
+	})
+}
+
 func TestMCPServer_HandleMethodsWithoutCapabilities(t *testing.T) {
 	server := NewMCPServer(
 		"test-server",
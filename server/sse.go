@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"path"
+	"strings"
 	"sync"
 
 	"github.com/centralmind/gateway/mcp"
@@ -30,6 +31,7 @@ type sseSession struct {
 	flusher    http.Flusher
 	done       chan struct{}
 	eventQueue chan string // Channel for queuing events
+	toolGroups []string    // Tool groups selected with ?tools= on the SSE URL
 }
 
 // NewSSEServer creates a new SSE server instance with the given MCP server and base URL.
@@ -111,25 +113,27 @@ func (s *SSEServer) handleSSE(w http.ResponseWriter, r *http.Request) {
 		done:       make(chan struct{}),
 		eventQueue: make(chan string, 100), // Buffer for events
 	}
+	if tools := r.URL.Query().Get("tools"); tools != "" {
+		session.toolGroups = strings.Split(tools, ",")
+	}
 
 	s.sessions.Store(sessionID, session)
 	defer s.sessions.Delete(sessionID)
+	notifications := s.server.RegisterSession(sessionID)
+	defer s.server.UnregisterSession(sessionID)
 
 	// Start notification handler for this session
 	go func() {
 		for {
 			select {
-			case serverNotification := <-s.server.notifications:
-				// Only forward notifications meant for this session
-				if serverNotification.Context.SessionID == sessionID {
-					eventData, err := json.Marshal(serverNotification.Notification)
-					if err == nil {
-						select {
-						case session.eventQueue <- fmt.Sprintf("event: message\ndata: %s\n\n", eventData):
-							// Event queued successfully
-						case <-session.done:
-							return
-						}
+			case serverNotification := <-notifications:
+				eventData, err := json.Marshal(serverNotification.Message())
+				if err == nil {
+					select {
+					case session.eventQueue <- fmt.Sprintf("event: message\ndata: %s\n\n", eventData):
+						// Event queued successfully
+					case <-session.done:
+						return
 					}
 				}
 			case <-session.done:
@@ -193,6 +197,9 @@ func (s *SSEServer) handleMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	session := sessionI.(*sseSession)
+	if session.toolGroups != nil {
+		ctx = xcontext.WithToolGroups(ctx, session.toolGroups)
+	}
 
 	// Parse message as raw JSON
 	var rawMessage json.RawMessage
@@ -1,16 +1,23 @@
 package server
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/centralmind/gateway/mcp"
+	"github.com/centralmind/gateway/xcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSSEServer(t *testing.T) {
@@ -406,3 +413,229 @@ func TestSSEServer(t *testing.T) {
 		cancel()
 	})
 }
+
+// sseClient is a test client that collects notifications received over its SSE connection.
+type sseClient struct {
+	sessionID     string
+	messageURL    string
+	notifications chan map[string]interface{}
+	close         func()
+}
+
+func connectSSE(t *testing.T, serverURL string) *sseClient {
+	t.Helper()
+	return connectSSEURL(t, serverURL+"/sse")
+}
+
+func connectSSEURL(t *testing.T, sseURL string) *sseClient {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sseURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to connect to SSE endpoint: %v", err)
+	}
+
+	client := &sseClient{
+		notifications: make(chan map[string]interface{}, 100),
+		close: func() {
+			cancel()
+			resp.Body.Close()
+		},
+	}
+	endpoint := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "data: ")
+			if !ok {
+				continue
+			}
+			if strings.HasPrefix(data, "http") {
+				endpoint <- data
+				continue
+			}
+			var message map[string]interface{}
+			if err := json.Unmarshal([]byte(data), &message); err == nil && message["id"] == nil {
+				client.notifications <- message
+			}
+		}
+	}()
+
+	select {
+	case client.messageURL = <-endpoint:
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for endpoint event")
+	}
+	client.sessionID = strings.Split(client.messageURL, "sessionId=")[1]
+	t.Cleanup(client.close)
+	return client
+}
+
+func (c *sseClient) send(t *testing.T, message string) {
+	t.Helper()
+	resp, err := http.Post(c.messageURL, "application/json", strings.NewReader(message))
+	if err != nil {
+		t.Errorf("Failed to send message: %v", err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// expect waits for a notification with the method, other notifications are returned as unexpected.
+func (c *sseClient) expect(method string) (map[string]interface{}, []map[string]interface{}) {
+	var unexpected []map[string]interface{}
+	for {
+		select {
+		case notification := <-c.notifications:
+			if notification["method"] == method {
+				return notification, unexpected
+			}
+			unexpected = append(unexpected, notification)
+		case <-time.After(2 * time.Second):
+			return nil, unexpected
+		}
+	}
+}
+
+func TestSSEServer_SessionNotifications(t *testing.T) {
+	t.Run("Notifications reach only the session of the request", func(t *testing.T) {
+		mcpServer := NewMCPServer("test", "1.0.0")
+		mcpServer.AddTool(mcp.NewTool("echo", mcp.WithString("text")), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			err := ServerFromContext(ctx).SendNotificationToClient(ctx, "test/echo", map[string]interface{}{
+				"text": request.Params.Arguments["text"],
+			})
+			return &mcp.CallToolResult{}, err
+		})
+		testServer := NewTestServer(mcpServer)
+		// Cleanups run in reverse order, so clients disconnect before the server is closed
+		t.Cleanup(testServer.Close)
+
+		numSessions := 5
+		clients := make([]*sseClient, numSessions)
+		for i := range clients {
+			clients[i] = connectSSE(t, testServer.URL)
+		}
+
+		var wg sync.WaitGroup
+		for i, client := range clients {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for call := 0; call < 10; call++ {
+					client.send(t, fmt.Sprintf(
+						`{"jsonrpc": "2.0", "id": %d, "method": "tools/call", "params": {"name": "echo", "arguments": {"text": "session-%d"}}}`,
+						call, i,
+					))
+				}
+			}()
+		}
+		wg.Wait()
+
+		for i, client := range clients {
+			for call := 0; call < 10; call++ {
+				notification, unexpected := client.expect("test/echo")
+				assert.Empty(t, unexpected)
+				if assert.NotNil(t, notification, "session %d: missing notification", i) {
+					params := notification["params"].(map[string]interface{})
+					assert.Equal(t, fmt.Sprintf("session-%d", i), params["text"])
+				}
+			}
+		}
+	})
+
+	t.Run("List changed notifications are broadcast", func(t *testing.T) {
+		mcpServer := NewMCPServer("test", "1.0.0")
+		testServer := NewTestServer(mcpServer)
+		// Cleanups run in reverse order, so clients disconnect before the server is closed
+		t.Cleanup(testServer.Close)
+
+		clients := []*sseClient{connectSSE(t, testServer.URL), connectSSE(t, testServer.URL), connectSSE(t, testServer.URL)}
+		clients[0].send(t, `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {}}`)
+
+		mcpServer.AddTool(mcp.NewTool("new-tool"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return &mcp.CallToolResult{}, nil
+		})
+		for i, client := range clients {
+			notification, _ := client.expect("notifications/tools/list_changed")
+			assert.NotNil(t, notification, "session %d: missing notification", i)
+		}
+	})
+
+	t.Run("Closed sessions are unregistered", func(t *testing.T) {
+		mcpServer := NewMCPServer("test", "1.0.0")
+		testServer := NewTestServer(mcpServer)
+		// Cleanups run in reverse order, so clients disconnect before the server is closed
+		t.Cleanup(testServer.Close)
+
+		client := connectSSE(t, testServer.URL)
+		require.NoError(t, mcpServer.SendNotificationToSession(client.sessionID, "test/ping", nil))
+		client.close()
+		assert.Eventually(t, func() bool {
+			return mcpServer.SendNotificationToSession(client.sessionID, "test/ping", nil) != nil
+		}, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestSSEServer_Batch(t *testing.T) {
+	mcpServer := NewMCPServer("test", "1.0.0")
+	testServer := NewTestServer(mcpServer)
+	t.Cleanup(testServer.Close)
+	client := connectSSE(t, testServer.URL)
+
+	resp, err := http.Post(client.messageURL, "application/json", strings.NewReader(
+		`[{"jsonrpc": "2.0", "id": 1, "method": "ping"}, {"jsonrpc": "2.0", "method": "notifications/initialized"}, {"jsonrpc": "2.0", "id": 2, "method": "unknown"}]`,
+	))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var responses []map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&responses))
+	require.Len(t, responses, 2)
+	assert.Equal(t, float64(1), responses[0]["id"])
+	assert.NotNil(t, responses[0]["result"])
+	assert.Equal(t, float64(2), responses[1]["id"])
+	assert.NotNil(t, responses[1]["error"])
+
+	resp, err = http.Post(client.messageURL, "application/json", strings.NewReader(`[{"jsonrpc": "2.0", "method": "notifications/initialized"}]`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Empty(t, body)
+}
+
+func TestSSEServer_ToolGroups(t *testing.T) {
+	mcpServer := NewMCPServer("test", "1.0.0")
+	mcpServer.AddTool(mcp.NewTool("list_orders"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return &mcp.CallToolResult{}, nil
+	})
+	groups := make(chan []string, 2)
+	mcpServer.AddToolFilter(func(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+		groups <- xcontext.ToolGroups(ctx)
+		return tools
+	})
+	testServer := NewTestServer(mcpServer)
+	t.Cleanup(testServer.Close)
+
+	next := func() []string {
+		select {
+		case selected := <-groups:
+			return selected
+		case <-time.After(time.Second):
+			t.Fatal("tools/list was not filtered")
+			return nil
+		}
+	}
+
+	connectSSEURL(t, testServer.URL+"/sse?tools=orders,raw").send(t, `{"jsonrpc": "2.0", "id": 1, "method": "tools/list"}`)
+	assert.Equal(t, []string{"orders", "raw"}, next())
+
+	connectSSE(t, testServer.URL).send(t, `{"jsonrpc": "2.0", "id": 1, "method": "tools/list"}`)
+	assert.Nil(t, next())
+}
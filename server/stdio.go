@@ -10,6 +10,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
 	"github.com/centralmind/gateway/mcp"
@@ -21,6 +22,7 @@ import (
 type StdioServer struct {
 	server    *MCPServer
 	errLogger *log.Logger
+	writeMu   sync.Mutex // Responses of tool calls and notifications are written concurrently
 }
 
 // NewStdioServer creates a new stdio server wrapper around an MCPServer.
@@ -58,24 +60,23 @@ func (s *StdioServer) Listen(
 
 	ctx = xcontext.WithSession(ctx, "stdio")
 	reader := bufio.NewReader(stdin)
+	notifications := s.server.RegisterSession("stdio")
+	defer s.server.UnregisterSession("stdio")
 
 	// Start notification handler
 	go func() {
 		for {
 			select {
-			case serverNotification := <-s.server.notifications:
-				// Only handle notifications for stdio client
-				if serverNotification.Context.ClientID == "stdio" {
-					err := s.writeResponse(
-						serverNotification.Notification,
-						stdout,
+			case serverNotification := <-notifications:
+				err := s.writeResponse(
+					serverNotification.Message(),
+					stdout,
+				)
+				if err != nil {
+					s.errLogger.Printf(
+						"Error writing notification: %v",
+						err,
 					)
-					if err != nil {
-						s.errLogger.Printf(
-							"Error writing notification: %v",
-							err,
-						)
-					}
 				}
 			case <-ctx.Done():
 				return
@@ -138,6 +139,22 @@ func (s *StdioServer) processMessage(
 		return s.writeResponse(response, writer)
 	}
 
+	// Tool calls and batches, which may contain them, may run for long, so they are handled
+	// in the background to keep reading messages such as notifications/cancelled
+	var baseMessage struct {
+		Method string `json:"method"`
+	}
+	if isBatch(rawMessage) || json.Unmarshal(rawMessage, &baseMessage) == nil && baseMessage.Method == "tools/call" {
+		go func() {
+			if response := s.server.HandleMessage(ctx, rawMessage); response != nil {
+				if err := s.writeResponse(response, writer); err != nil {
+					s.errLogger.Printf("Error writing response: %v", err)
+				}
+			}
+		}()
+		return nil
+	}
+
 	// Handle the message using the wrapped server
 	response := s.server.HandleMessage(ctx, rawMessage)
 
@@ -162,6 +179,9 @@ func (s *StdioServer) writeResponse(
 		return err
 	}
 
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
 	// Write response followed by newline
 	if _, err := fmt.Fprintf(writer, "%s\n", responseBytes); err != nil {
 		return err
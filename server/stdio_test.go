@@ -110,4 +110,43 @@ func TestStdioServer(t *testing.T) {
 			t.Errorf("unexpected server error: %v", err)
 		}
 	})
+
+	t.Run("Can handle batches", func(t *testing.T) {
+		stdinReader, stdinWriter := io.Pipe()
+		stdoutReader, stdoutWriter := io.Pipe()
+
+		mcpServer := NewMCPServer("test", "1.0.0")
+		stdioServer := NewStdioServer(mcpServer)
+		stdioServer.SetErrorLogger(log.New(io.Discard, "", 0))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go stdioServer.Listen(ctx, stdinReader, stdoutWriter)
+
+		batch := `[{"jsonrpc": "2.0", "id": 1, "method": "ping"}, {"jsonrpc": "2.0", "method": "notifications/initialized"}, {"jsonrpc": "2.0", "id": 2, "method": "ping"}]`
+		if _, err := stdinWriter.Write([]byte(batch + "\n")); err != nil {
+			t.Fatal(err)
+		}
+
+		scanner := bufio.NewScanner(stdoutReader)
+		if !scanner.Scan() {
+			t.Fatal("failed to read response")
+		}
+		var responses []map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &responses); err != nil {
+			t.Fatalf("failed to unmarshal batch response: %v", err)
+		}
+		if len(responses) != 2 {
+			t.Fatalf("expected 2 responses, got %v", responses)
+		}
+		for i, response := range responses {
+			if response["id"].(float64) != float64(i+1) {
+				t.Errorf("expected id %d, got %v", i+1, response["id"])
+			}
+		}
+
+		cancel()
+		stdinWriter.Close()
+		stdoutWriter.Close()
+	})
 }
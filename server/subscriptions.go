@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/centralmind/gateway/mcp"
+)
+
+// ResourceSubscriptionHandlerFunc is called when a session subscribes to a resource and when it unsubscribes,
+// so changes are only watched while someone listens, with the authorization of a session that is still subscribed.
+// The context is the one of the subscribing request, or a background one when a session is closed.
+type ResourceSubscriptionHandlerFunc func(ctx context.Context, uri, sessionID string, subscribed bool) error
+
+// subscriptions holds sessions subscribed to resources
+type subscriptions struct {
+	mu       sync.Mutex
+	sessions map[string]map[string]bool // URI -> session IDs
+	handler  ResourceSubscriptionHandlerFunc
+}
+
+// SetResourceSubscriptionHandler registers the handler that starts and stops watching resources for changes
+func (s *MCPServer) SetResourceSubscriptionHandler(handler ResourceSubscriptionHandlerFunc) {
+	s.subscriptions.mu.Lock()
+	defer s.subscriptions.mu.Unlock()
+	s.subscriptions.handler = handler
+}
+
+// NotifyResourceUpdated sends notifications/resources/updated to all sessions subscribed to the resource
+func (s *MCPServer) NotifyResourceUpdated(uri string) {
+	s.subscriptions.mu.Lock()
+	sessions := make([]string, 0, len(s.subscriptions.sessions[uri]))
+	for sessionID := range s.subscriptions.sessions[uri] {
+		sessions = append(sessions, sessionID)
+	}
+	s.subscriptions.mu.Unlock()
+
+	for _, sessionID := range sessions {
+		// A slow client must not block others, its notification is dropped
+		_ = s.SendNotificationToSession(sessionID, "notifications/resources/updated", map[string]interface{}{
+			"uri": uri,
+		})
+	}
+}
+
+func (s *MCPServer) handleSubscribe(
+	ctx context.Context,
+	id interface{},
+	uri string,
+	subscribe bool,
+) mcp.JSONRPCMessage {
+	client, ok := ClientFromContext(ctx)
+	if !ok {
+		return CreateErrorResponse(id, mcp.INVALID_REQUEST, "Subscriptions require a session")
+	}
+	if subscribe && !s.hasResource(uri) {
+		return CreateErrorResponse(
+			id,
+			mcp.INVALID_PARAMS,
+			fmt.Sprintf("No handler found for resource URI: %s", uri),
+		)
+	}
+
+	var err error
+	if subscribe {
+		err = s.subscribe(ctx, uri, client.SessionID)
+	} else {
+		err = s.unsubscribe(ctx, uri, client.SessionID)
+	}
+	if err != nil {
+		return CreateErrorResponse(id, mcp.INTERNAL_ERROR, err.Error())
+	}
+	return createResponse(id, mcp.EmptyResult{})
+}
+
+func (s *MCPServer) hasResource(uri string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.resources[uri]; ok {
+		return true
+	}
+	for uriTemplate := range s.resourceTemplates {
+		if _, ok := matchTemplate(uri, uriTemplate); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// subscribe records the subscription before the handler runs, so the handler of a slow connector
+// doesn't block subscriptions of other sessions, it's dropped again if the handler fails.
+func (s *MCPServer) subscribe(ctx context.Context, uri string, sessionID string) error {
+	s.subscriptions.mu.Lock()
+	if s.subscriptions.sessions == nil {
+		s.subscriptions.sessions = make(map[string]map[string]bool)
+	}
+	sessions, ok := s.subscriptions.sessions[uri]
+	if ok && sessions[sessionID] {
+		s.subscriptions.mu.Unlock()
+		return nil
+	}
+	if !ok {
+		sessions = make(map[string]bool)
+		s.subscriptions.sessions[uri] = sessions
+	}
+	sessions[sessionID] = true
+	handler := s.subscriptions.handler
+	s.subscriptions.mu.Unlock()
+
+	if handler == nil {
+		return nil
+	}
+	if err := handler(ctx, uri, sessionID, true); err != nil {
+		s.subscriptions.mu.Lock()
+		s.dropSubscription(uri, sessionID)
+		s.subscriptions.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (s *MCPServer) unsubscribe(ctx context.Context, uri string, sessionID string) error {
+	s.subscriptions.mu.Lock()
+	subscribed := s.subscriptions.sessions[uri][sessionID]
+	s.dropSubscription(uri, sessionID)
+	handler := s.subscriptions.handler
+	s.subscriptions.mu.Unlock()
+
+	if !subscribed || handler == nil {
+		return nil
+	}
+	return handler(ctx, uri, sessionID, false)
+}
+
+// dropSubscription removes the session from subscribers of the resource, s.subscriptions.mu must be held
+func (s *MCPServer) dropSubscription(uri string, sessionID string) {
+	sessions, ok := s.subscriptions.sessions[uri]
+	if !ok {
+		return
+	}
+	delete(sessions, sessionID)
+	if len(sessions) == 0 {
+		delete(s.subscriptions.sessions, uri)
+	}
+}
+
+// unsubscribeSession drops all subscriptions of a closed session
+func (s *MCPServer) unsubscribeSession(sessionID string) {
+	s.subscriptions.mu.Lock()
+	var uris []string
+	for uri, sessions := range s.subscriptions.sessions {
+		if sessions[sessionID] {
+			uris = append(uris, uri)
+		}
+	}
+	s.subscriptions.mu.Unlock()
+
+	for _, uri := range uris {
+		_ = s.unsubscribe(context.Background(), uri, sessionID)
+	}
+}
@@ -0,0 +1,21 @@
+package xcontext
+
+import "context"
+
+const progressKey contextKey = "progress"
+
+// ProgressFunc receives progress of a long-running operation, total is 0 when unknown.
+type ProgressFunc func(progress, total float64)
+
+func WithProgress(ctx context.Context, f ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey, f)
+}
+
+// ReportProgress passes progress to the caller if it asked for it, e.g. with an MCP progress token.
+func ReportProgress(ctx context.Context, progress, total float64) {
+	f, ok := ctx.Value(progressKey).(ProgressFunc)
+	if !ok {
+		return
+	}
+	f(progress, total)
+}
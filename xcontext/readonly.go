@@ -0,0 +1,16 @@
+package xcontext
+
+import "context"
+
+const readOnlyKey contextKey = "readonly"
+
+// WithReadOnly makes SQL connectors run queries of the context in a read-only transaction,
+// even on a connection that may write, e.g. for samples that must not change data.
+func WithReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey, true)
+}
+
+func ReadOnly(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyKey).(bool)
+	return readOnly
+}
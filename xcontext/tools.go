@@ -0,0 +1,16 @@
+package xcontext
+
+import "context"
+
+const toolGroupsKey contextKey = "tool_groups"
+
+// WithToolGroups limits tools offered to the client to the groups, e.g. selected with ?tools= on the SSE URL.
+func WithToolGroups(ctx context.Context, groups []string) context.Context {
+	return context.WithValue(ctx, toolGroupsKey, groups)
+}
+
+// ToolGroups returns groups of tools selected by the client, nil when it didn't select any.
+func ToolGroups(ctx context.Context) []string {
+	groups, _ := ctx.Value(toolGroupsKey).([]string)
+	return groups
+}